@@ -0,0 +1,168 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var (
+	ErrMissingValue    = errors.New("configuration value is not set")
+	ErrInvalidURL      = errors.New("invalid URL")
+	ErrTLSWithoutHTTPS = errors.New("TLS options are set but the server address is not https")
+	ErrUnknownProfile  = errors.New("unknown configuration profile")
+)
+
+// Config holds the application's configuration values.
+type Config struct {
+	Profile     string     `yaml:"-"` // Profile is the name of the profile the configuration was loaded with.
+	ServerAddr  string     `yaml:"server_addr"`
+	LoginUri    string     `yaml:"login_uri"`
+	RegisterUri string     `yaml:"register_uri"`
+	MatchUri    string     `yaml:"match_uri"`
+	RefreshUri  string     `yaml:"refresh_uri"` // RefreshUri is optional, sessions end with their auth token without it.
+	LogoutUri   string     `yaml:"logout_uri"`  // LogoutUri is optional, logging out only forgets the session locally without it.
+	TLS         TLSConfig  `yaml:"tls"`
+	Keys        KeysConfig `yaml:"keys"`
+	Theme       string     `yaml:"theme"` // Theme is the name of the maze theme, saved when switched in game.
+}
+
+// TLSConfig holds the optional TLS settings of the backend connections, all empty uses the system defaults.
+type TLSConfig struct {
+	CAFile         string   `yaml:"ca_file"`     // CAFile is a PEM bundle of private CAs trusted besides the system ones.
+	Pins           []string `yaml:"pins"`        // Pins are the SPKI pins of the backend chain, "sha256/<base64>".
+	ClientCertFile string   `yaml:"client_cert"` // ClientCertFile and ClientKeyFile authenticate the client with mutual TLS.
+	ClientKeyFile  string   `yaml:"client_key"`
+	MinVersion     string   `yaml:"min_version"` // MinVersion is the lowest TLS version accepted, 1.2 or 1.3.
+}
+
+// KeysConfig holds the key bindings of the game, a preset with some actions bound to other keys.
+type KeysConfig struct {
+	Preset   string              `yaml:"preset"`   // Preset is arrows, vim, wasd or numpad, arrows by default.
+	Bindings map[string][]string `yaml:"bindings"` // Bindings replace the keys of actions, like move_north: [w, Up].
+}
+
+// Default returns the lowest layer of the configuration: the endpoints of the local server and no server address.
+func Default() Config {
+	return Config{
+		LoginUri:    "/auth/login",
+		RegisterUri: "/auth/register",
+		MatchUri:    "/match",
+		RefreshUri:  "/auth/refresh",
+		LogoutUri:   "/auth/logout",
+	}
+}
+
+// The addresses of the remote backends, set when building the releases:
+//
+//	go build -ldflags "-X github.com/sofc-t/puzzle-client/config.stagingServerAddr=https://..."
+//
+// The staging and prod profiles are not usable without them, unless the server address is set by another source.
+var (
+	stagingServerAddr string
+	prodServerAddr    string
+)
+
+// builtinProfiles are the profiles available without a config file. The file may override them.
+// The remote backends are only reached over TLS.
+var builtinProfiles = map[string]Config{
+	"local":   {ServerAddr: "http://127.0.0.1:8080"},
+	"staging": {ServerAddr: stagingServerAddr, TLS: TLSConfig{MinVersion: "1.2"}},
+	"prod":    {ServerAddr: prodServerAddr, TLS: TLSConfig{MinVersion: "1.2"}},
+}
+
+// Enabled reports whether any TLS setting differs from the system defaults.
+func (c TLSConfig) Enabled() bool {
+	return c.CAFile != "" || len(c.Pins) > 0 || c.ClientCertFile != "" || c.ClientKeyFile != "" || c.MinVersion != ""
+}
+
+// Validate reports every missing value and malformed URL of the configuration.
+func (c Config) Validate() error {
+	var errs []error
+	if c.ServerAddr == "" {
+		errs = append(errs, fmt.Errorf("%w: server_addr, set it with -server, SERVER_ADDR, a config file or a profile", ErrMissingValue))
+	} else if err := validateServerAddr(c.ServerAddr); err != nil {
+		errs = append(errs, err)
+	} else if c.TLS.Enabled() && !strings.HasPrefix(c.ServerAddr, "https://") {
+		errs = append(errs, ErrTLSWithoutHTTPS)
+	}
+
+	endpoints := []struct {
+		name, value string
+		required    bool
+	}{
+		{"login_uri", c.LoginUri, true},
+		{"register_uri", c.RegisterUri, true},
+		{"match_uri", c.MatchUri, true},
+		{"refresh_uri", c.RefreshUri, false},
+		{"logout_uri", c.LogoutUri, false},
+	}
+	for _, e := range endpoints {
+		if e.value == "" {
+			if e.required {
+				errs = append(errs, fmt.Errorf("%w: %s", ErrMissingValue, e.name))
+			}
+			continue
+		}
+		if err := validateEndpoint(e.name, e.value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateServerAddr checks that addr is an absolute http or https URL.
+func validateServerAddr(addr string) error {
+	u, err := url.Parse(addr)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: server_addr %q must be an http or https URL", ErrInvalidURL, addr)
+	}
+	return nil
+}
+
+// validateEndpoint checks that uri is a path resolved against the server address, or an absolute URL.
+func validateEndpoint(name, uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return fmt.Errorf("%w: %s %q: %w", ErrInvalidURL, name, uri, err)
+	}
+	if u.IsAbs() {
+		return validateServerAddr(uri)
+	}
+	if u.Host != "" || !strings.HasPrefix(u.Path, "/") {
+		return fmt.Errorf("%w: %s %q must be an absolute path or URL", ErrInvalidURL, name, uri)
+	}
+	return nil
+}
+
+// merge overrides the values of c with the values set in o.
+func (c *Config) merge(o Config) {
+	mergeString(&c.ServerAddr, o.ServerAddr)
+	mergeString(&c.LoginUri, o.LoginUri)
+	mergeString(&c.RegisterUri, o.RegisterUri)
+	mergeString(&c.MatchUri, o.MatchUri)
+	mergeString(&c.RefreshUri, o.RefreshUri)
+	mergeString(&c.LogoutUri, o.LogoutUri)
+	mergeString(&c.TLS.CAFile, o.TLS.CAFile)
+	mergeString(&c.TLS.ClientCertFile, o.TLS.ClientCertFile)
+	mergeString(&c.TLS.ClientKeyFile, o.TLS.ClientKeyFile)
+	mergeString(&c.TLS.MinVersion, o.TLS.MinVersion)
+	if len(o.TLS.Pins) > 0 {
+		c.TLS.Pins = o.TLS.Pins
+	}
+	mergeString(&c.Theme, o.Theme)
+	mergeString(&c.Keys.Preset, o.Keys.Preset)
+	for action, keys := range o.Keys.Bindings {
+		if c.Keys.Bindings == nil {
+			c.Keys.Bindings = make(map[string][]string)
+		}
+		c.Keys.Bindings[action] = keys
+	}
+}
+
+func mergeString(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
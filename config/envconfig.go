@@ -1,44 +1,46 @@
 package config
 
 import (
-	"log"
-	"os"
-
-	"github.com/joho/godotenv"
+	"strings"
 )
 
-// Config holds the application's configuration values.
-type Config struct {
-	ServerAddr  string
-	LoginUri    string
-	RegisterUri string
-	MatchUri    string
-}
-
-// Envs holds the application's configuration loaded from environment variables.
-var Envs = initConfig()
+// Environment variables of the configuration, they also can be set in the .env file.
+const (
+	envFile    = "PUZZLE_CONFIG"
+	envProfile = "PUZZLE_PROFILE"
+)
 
-// initConfig initializes and returns the application configuration.
-// It loads environment variables from a .env file.
-func initConfig() Config {
-	if err := godotenv.Load(); err != nil {
-		log.Printf("[APP] [INFO] .env file not found or could not be loaded: %v", err)
+// fromEnv returns the values set in the environment.
+func fromEnv(lookupEnv func(string) (string, bool)) Config {
+	get := func(key string) string {
+		value, _ := lookupEnv(key)
+		return value
 	}
 
-	// Populate the Config struct with required environment variables
 	return Config{
-		ServerAddr:  mustGetEnv("SERVER_ADDR"),
-		LoginUri:    mustGetEnv("LOGIN_URI"),
-		RegisterUri: mustGetEnv("REGISTER_URI"),
-		MatchUri:    mustGetEnv("MATCH_URI"),
+		ServerAddr:  get("SERVER_ADDR"),
+		LoginUri:    get("LOGIN_URI"),
+		RegisterUri: get("REGISTER_URI"),
+		MatchUri:    get("MATCH_URI"),
+		RefreshUri:  get("REFRESH_URI"),
+		LogoutUri:   get("LOGOUT_URI"),
+		TLS: TLSConfig{
+			CAFile:         get("TLS_CA_FILE"),
+			Pins:           splitList(get("TLS_PINS")),
+			ClientCertFile: get("TLS_CLIENT_CERT"),
+			ClientKeyFile:  get("TLS_CLIENT_KEY"),
+			MinVersion:     get("TLS_MIN_VERSION"),
+		},
 	}
 }
 
-// mustGetEnv retrieves the value of an environment variable or logs a fatal error if not set.
-func mustGetEnv(key string) string {
-	value, exists := os.LookupEnv(key)
-	if !exists {
-		log.Fatalf("[APP] [FATAL] Environment variable %s is not set", key)
+// splitList splits a comma separated value, dropping the empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
-	return value
+	return items
 }
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// fileName is the name of the config file in the user config dir.
+const fileName = "config.yaml"
+
+// Loader merges the configuration sources, each one overriding the values set by the previous ones:
+// the defaults, the config file, the selected profile, the .env file, the environment and the flags.
+type Loader struct {
+	File      string                      // File is the config file, DefaultFile when empty. A missing default file is skipped.
+	Profile   string                      // Profile selects a file or built-in profile, PUZZLE_PROFILE or the file's default when empty.
+	EnvFile   string                      // EnvFile is the .env file, skipped when empty or missing.
+	Flags     Config                      // Flags are the values set on the command line.
+	LookupEnv func(string) (string, bool) // LookupEnv reads the environment, os.LookupEnv when nil.
+}
+
+// file is the content of a config file: the values shared by the profiles, the default profile and the profiles.
+type file struct {
+	Config   `yaml:",inline"`
+	Profile  string            `yaml:"profile"`
+	Profiles map[string]Config `yaml:"profiles"`
+}
+
+// DefaultFile returns the path of the config file in the user config dir.
+func DefaultFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "puzzle-me", fileName), nil
+}
+
+// BindFlags registers the configuration flags on fs, they are set in l when fs is parsed.
+func (l *Loader) BindFlags(fs *flag.FlagSet) {
+	fs.StringVar(&l.File, "config", "", "configuration file, "+fileName+" in the user config dir by default")
+	fs.StringVar(&l.Profile, "profile", "", "configuration profile: local, staging, prod or one of the configuration file")
+	fs.StringVar(&l.Flags.ServerAddr, "server", "", "address of the backend, like https://example.com")
+	fs.StringVar(&l.Flags.TLS.CAFile, "tls-ca", "", "PEM bundle of the private CAs of the backend")
+	fs.Func("tls-pin", "SPKI pin of the backend chain, sha256/<base64>, repeatable", func(pin string) error {
+		l.Flags.TLS.Pins = append(l.Flags.TLS.Pins, pin)
+		return nil
+	})
+	fs.StringVar(&l.Flags.TLS.ClientCertFile, "tls-cert", "", "PEM client certificate for mutual TLS")
+	fs.StringVar(&l.Flags.TLS.ClientKeyFile, "tls-key", "", "PEM private key of the client certificate")
+	fs.StringVar(&l.Flags.TLS.MinVersion, "tls-min-version", "", "lowest TLS version accepted: 1.2 or 1.3")
+	fs.StringVar(&l.Flags.Keys.Preset, "keys", "", "key preset of the game: arrows, vim, wasd or numpad")
+	fs.StringVar(&l.Flags.Theme, "theme", "", "maze theme: default, high-contrast, monochrome, deuteranopia or protanopia")
+}
+
+// Load merges the sources and validates the result.
+func (l Loader) Load() (Config, error) {
+	cfg, err := l.Merge()
+	if err != nil {
+		return Config{}, err
+	}
+	return cfg, cfg.Validate()
+}
+
+// Merge merges the sources without validating the result, for the commands not talking to the backend.
+func (l Loader) Merge() (Config, error) {
+	lookupEnv, err := l.lookupEnv()
+	if err != nil {
+		return Config{}, err
+	}
+
+	f, err := l.readFile(lookupEnv)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg := Default()
+	cfg.merge(f.Config)
+
+	profile := l.Profile
+	if profile == "" {
+		profile, _ = lookupEnv(envProfile)
+	}
+	if profile == "" {
+		profile = f.Profile
+	}
+	if profile != "" {
+		values, err := f.profile(profile)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.merge(values)
+		cfg.Profile = profile
+	}
+
+	cfg.merge(fromEnv(lookupEnv))
+	cfg.merge(l.Flags)
+	return cfg, nil
+}
+
+// lookupEnv returns the environment backed by the .env file, the variables of the environment taking precedence.
+func (l Loader) lookupEnv() (func(string) (string, bool), error) {
+	lookupEnv := l.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	if l.EnvFile == "" {
+		return lookupEnv, nil
+	}
+
+	dotenv, err := godotenv.Read(l.EnvFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return lookupEnv, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("%s: %w", l.EnvFile, err)
+	}
+
+	return func(key string) (string, bool) {
+		if value, ok := lookupEnv(key); ok {
+			return value, true
+		}
+		value, ok := dotenv[key]
+		return value, ok
+	}, nil
+}
+
+// path returns the config file given to l, by PUZZLE_CONFIG or the default one. It reports whether the file
+// is the default one, which may be missing.
+func (l Loader) path(lookupEnv func(string) (string, bool)) (string, bool, error) {
+	if l.File != "" {
+		return l.File, false, nil
+	}
+	if path, _ := lookupEnv(envFile); path != "" {
+		return path, false, nil
+	}
+	path, err := DefaultFile()
+	return path, true, err
+}
+
+// readFile reads the config file. Only the default file may be missing, an unknown key is an error to catch typos.
+func (l Loader) readFile(lookupEnv func(string) (string, bool)) (file, error) {
+	path, optional, err := l.path(lookupEnv)
+	if err != nil {
+		return file{}, fmt.Errorf("config file: %w", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if optional && errors.Is(err, fs.ErrNotExist) {
+		return file{}, nil
+	} else if err != nil {
+		return file{}, err
+	}
+
+	var f file
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return file{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// profile returns the values of the named profile, the file's one overriding the built-in one.
+func (f file) profile(name string) (Config, error) {
+	values, builtin := builtinProfiles[name]
+	defined, ok := f.Profiles[name]
+	if !ok && !builtin {
+		return Config{}, fmt.Errorf("%w %q, expected one of: %s", ErrUnknownProfile, name, strings.Join(f.profileNames(), ", "))
+	}
+	values.merge(defined)
+	return values, nil
+}
+
+// profileNames returns the sorted names of the built-in and file profiles.
+func (f file) profileNames() []string {
+	names := make([]string, 0, len(builtinProfiles)+len(f.Profiles))
+	for name := range builtinProfiles {
+		names = append(names, name)
+	}
+	for name := range f.Profiles {
+		if _, ok := builtinProfiles[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
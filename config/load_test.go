@@ -0,0 +1,236 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testFile = `
+server_addr: https://file.example.com
+match_uri: /file/match
+profile: staging
+profiles:
+  staging:
+    server_addr: https://staging.example.com
+    tls:
+      ca_file: staging-ca.pem
+  prod:
+    server_addr: https://prod.example.com
+`
+
+// writeFile writes content to a file of a temporary dir and returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// env returns an environment holding the variables.
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := vars[key]
+		return value, ok
+	}
+}
+
+// TestLoader contains all the tests related to loading the configuration.
+func TestLoader(t *testing.T) {
+	t.Run("Loader_Defaults", testLoader_Defaults)
+	t.Run("Loader_Precedence", testLoader_Precedence)
+	t.Run("Loader_Profile", testLoader_Profile)
+	t.Run("Loader_UnknownProfile", testLoader_UnknownProfile)
+	t.Run("Loader_RemoteProfiles", testLoader_RemoteProfiles)
+	t.Run("Loader_UnknownKey", testLoader_UnknownKey)
+	t.Run("Loader_MissingFile", testLoader_MissingFile)
+	t.Run("Loader_Validate", testLoader_Validate)
+	t.Run("Loader_Keys", testLoader_Keys)
+	t.Run("Loader_SaveSetting", testLoader_SaveSetting)
+}
+
+// testLoader_Defaults tests that the built-in local profile alone is a valid configuration.
+func testLoader_Defaults(t *testing.T) {
+	cfg, err := Loader{File: writeFile(t, "config.yaml", ""), Profile: "local", LookupEnv: env(nil)}.Load()
+	if err != nil {
+		t.Fatalf("Expected a configuration, got error: %s", err)
+	}
+	if cfg.ServerAddr != "http://127.0.0.1:8080" || cfg.LoginUri != "/auth/login" || cfg.Profile != "local" {
+		t.Errorf("Expected the local defaults, got: %+v", cfg)
+	}
+}
+
+// testLoader_Precedence tests that every source overrides the previous ones.
+func testLoader_Precedence(t *testing.T) {
+	loader := Loader{
+		File:    writeFile(t, "config.yaml", testFile),
+		EnvFile: writeFile(t, ".env", "LOGIN_URI=/dotenv/login\nREGISTER_URI=/dotenv/register\nMATCH_URI=/dotenv/match\n"),
+		LookupEnv: env(map[string]string{
+			"REGISTER_URI": "/env/register",
+			"MATCH_URI":    "/env/match",
+		}),
+		Flags: Config{MatchUri: "/flag/match"},
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Expected a configuration, got error: %s", err)
+	}
+	if cfg.ServerAddr != "https://staging.example.com" {
+		t.Errorf("Expected the server of the file's default profile, got: %s", cfg.ServerAddr)
+	}
+	if cfg.LoginUri != "/dotenv/login" || cfg.RegisterUri != "/env/register" || cfg.MatchUri != "/flag/match" {
+		t.Errorf("Expected .env < environment < flags, got: %+v", cfg)
+	}
+	if cfg.RefreshUri != "/auth/refresh" {
+		t.Errorf("Expected the default refresh URI, got: %s", cfg.RefreshUri)
+	}
+}
+
+// testLoader_Profile tests that the profile is selected by the loader before the environment and the file.
+func testLoader_Profile(t *testing.T) {
+	path := writeFile(t, "config.yaml", testFile)
+
+	cfg, err := Loader{File: path, LookupEnv: env(map[string]string{"PUZZLE_PROFILE": "prod"})}.Load()
+	if err != nil || cfg.ServerAddr != "https://prod.example.com" || cfg.TLS.CAFile != "" {
+		t.Errorf("Expected the prod profile of the environment, got: %+v, %v", cfg, err)
+	}
+
+	cfg, err = Loader{File: path, Profile: "local", LookupEnv: env(map[string]string{"PUZZLE_PROFILE": "prod"})}.Load()
+	if err != nil || cfg.ServerAddr != "http://127.0.0.1:8080" || cfg.MatchUri != "/file/match" {
+		t.Errorf("Expected the local profile over the file's values, got: %+v, %v", cfg, err)
+	}
+}
+
+// testLoader_UnknownProfile tests that selecting a profile defined nowhere fails.
+func testLoader_UnknownProfile(t *testing.T) {
+	_, err := Loader{File: writeFile(t, "config.yaml", testFile), Profile: "qa", LookupEnv: env(nil)}.Load()
+	if !errors.Is(err, ErrUnknownProfile) {
+		t.Errorf("Expected ErrUnknownProfile, got: %v", err)
+	}
+}
+
+// testLoader_UnknownKey tests that a misspelled key of the file is reported.
+func testLoader_UnknownKey(t *testing.T) {
+	_, err := Loader{File: writeFile(t, "config.yaml", "server_adr: https://example.com\n"), LookupEnv: env(nil)}.Load()
+	if err == nil {
+		t.Errorf("Expected an unknown key to be rejected")
+	}
+}
+
+// testLoader_MissingFile tests that only the default config file may be missing.
+func testLoader_MissingFile(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	if _, err := (Loader{File: missing, LookupEnv: env(nil)}).Load(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a missing config file to fail, got: %v", err)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	vars := map[string]string{"SERVER_ADDR": "http://localhost:9000"}
+	if _, err := (Loader{LookupEnv: env(vars)}).Load(); err != nil {
+		t.Errorf("Expected a missing default file to be skipped, got: %v", err)
+	}
+
+	for _, name := range []string{"XDG_CONFIG_HOME", "HOME", "AppData", "home"} {
+		t.Setenv(name, "")
+	}
+	if _, err := (Loader{LookupEnv: env(vars)}).Load(); err == nil {
+		t.Errorf("Expected the lack of a user config dir to be reported")
+	}
+}
+
+// testLoader_RemoteProfiles tests that the staging and prod profiles are built in, reached over TLS and usable
+// with the address of another source when the build did not set it.
+func testLoader_RemoteProfiles(t *testing.T) {
+	path := writeFile(t, "config.yaml", "")
+	for _, profile := range []string{"staging", "prod"} {
+		cfg, err := Loader{File: path, Profile: profile, LookupEnv: env(nil)}.Merge()
+		if err != nil || cfg.TLS.MinVersion != "1.2" {
+			t.Errorf("Expected the built-in %s profile, got: %+v, %v", profile, cfg, err)
+		}
+
+		vars := map[string]string{"SERVER_ADDR": "http://" + profile + ".example.com"}
+		if _, err := (Loader{File: path, Profile: profile, LookupEnv: env(vars)}).Load(); !errors.Is(err, ErrTLSWithoutHTTPS) {
+			t.Errorf("Expected the %s profile to require https, got: %v", profile, err)
+		}
+
+		vars["SERVER_ADDR"] = "https://" + profile + ".example.com"
+		if _, err := (Loader{File: path, Profile: profile, LookupEnv: env(vars)}).Load(); err != nil {
+			t.Errorf("Expected the %s profile to be usable, got: %v", profile, err)
+		}
+	}
+}
+
+// testLoader_Validate tests the reported missing values and malformed URLs.
+func testLoader_Validate(t *testing.T) {
+	cases := map[string]struct {
+		cfg Config
+		err error
+	}{
+		"NoServer":     {Config{}, ErrMissingValue},
+		"Scheme":       {Config{ServerAddr: "ftp://example.com"}, ErrInvalidURL},
+		"NoHost":       {Config{ServerAddr: "https://"}, ErrInvalidURL},
+		"RelativePath": {Config{ServerAddr: "https://example.com", LoginUri: "auth/login"}, ErrInvalidURL},
+		"TLSOverHTTP":  {Config{ServerAddr: "http://example.com", TLS: TLSConfig{MinVersion: "1.3"}}, ErrTLSWithoutHTTPS},
+	}
+	for name, c := range cases {
+		cfg := Default()
+		cfg.merge(c.cfg)
+		if err := cfg.Validate(); !errors.Is(err, c.err) {
+			t.Errorf("%s: expected %v, got: %v", name, c.err, err)
+		}
+	}
+
+	cfg := Default()
+	cfg.merge(Config{ServerAddr: "https://example.com", MatchUri: "https://match.example.com/match"})
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected an absolute endpoint URL to be valid, got: %v", err)
+	}
+}
+
+// testLoader_Keys tests that the bindings of the sources are merged action by action and not validated by Merge.
+func testLoader_Keys(t *testing.T) {
+	content := "keys:\n  preset: vim\n  bindings:\n    quit: [q]\n    help: [h]\n"
+	loader := Loader{
+		File:      writeFile(t, "config.yaml", content),
+		LookupEnv: env(nil),
+		Flags:     Config{Keys: KeysConfig{Preset: "wasd", Bindings: map[string][]string{"help": {"F1"}}}},
+	}
+
+	cfg, err := loader.Merge()
+	if err != nil {
+		t.Fatalf("Expected a configuration without server address, got error: %s", err)
+	}
+	if cfg.Keys.Preset != "wasd" || cfg.Keys.Bindings["quit"][0] != "q" || cfg.Keys.Bindings["help"][0] != "F1" {
+		t.Errorf("Expected the bindings merged by action, got: %+v", cfg.Keys)
+	}
+}
+
+// testLoader_SaveSetting tests that a saved setting is loaded back and the rest of the file is kept.
+func testLoader_SaveSetting(t *testing.T) {
+	path := writeFile(t, "config.yaml", "# Staging servers\nserver_addr: https://example.com # shared\ntheme: default\n")
+	loader := Loader{File: path, LookupEnv: env(nil)}
+
+	if err := loader.SaveSetting("theme", "monochrome"); err != nil {
+		t.Fatalf("Expected the setting to be saved, got error: %s", err)
+	}
+	cfg, err := loader.Load()
+	if err != nil || cfg.Theme != "monochrome" || cfg.ServerAddr != "https://example.com" {
+		t.Errorf("Expected the saved theme and the kept server, got: %+v, %v", cfg, err)
+	}
+	if content, _ := os.ReadFile(path); !strings.Contains(string(content), "# Staging servers") {
+		t.Errorf("Expected the comments to be kept, got:\n%s", content)
+	}
+
+	created := Loader{File: filepath.Join(t.TempDir(), "puzzle-me", "config.yaml"), LookupEnv: env(nil)}
+	if err := created.SaveSetting("theme", "protanopia"); err != nil {
+		t.Fatalf("Expected a missing file to be created, got error: %s", err)
+	}
+	if cfg, err := created.Merge(); err != nil || cfg.Theme != "protanopia" {
+		t.Errorf("Expected the theme of the created file, got: %+v, %v", cfg, err)
+	}
+}
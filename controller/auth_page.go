@@ -8,6 +8,7 @@ import (
 
 type loginResponseHandler func(*dmn.Player, string)
 
+// AuthPage shows the sign in form, the sign up form is pushed on top of it.
 type AuthPage struct {
 	authService i.AuthServer
 	onLogin     loginResponseHandler
@@ -20,38 +21,55 @@ func NewAuthPage(as i.AuthServer, onLogin loginResponseHandler) (*AuthPage, erro
 	}, nil
 }
 
-func (a *AuthPage) Start(app *tview.Application) error {
-	if err := app.SetRoot(a.signInForm(app), true).Run(); err != nil {
-		return err
+// Resume logs the remembered session in without showing the page. It reports whether there was one.
+func (a *AuthPage) Resume() bool {
+	player, token, err := a.authService.Resume()
+	if err != nil {
+		return false
 	}
-	return nil
+	a.onLogin(player, token)
+	return true
 }
 
-func (a *AuthPage) signInForm(app *tview.Application) tview.Primitive {
+// OnEnter implements PageController.
+func (a *AuthPage) OnEnter(nav *Navigator) tview.Primitive {
+	return a.signInForm(nav)
+}
+
+// OnLeave implements PageController.
+func (a *AuthPage) OnLeave() {}
+
+func (a *AuthPage) signInForm(nav *Navigator) tview.Primitive {
 	header := tview.NewTextView().SetText("Login / Sign Up").SetTextAlign(tview.AlignCenter)
 	footer := tview.NewTextView().SetText("").SetTextAlign(tview.AlignLeft)
 
 	form := tview.NewForm()
 	form.AddInputField("Username:", "", 20, nil, nil)
 	form.AddPasswordField("Password:", "", 20, '*', nil)
+	form.AddCheckbox("Remember me:", false, nil)
 
 	form.AddButton("Login", func() {
 		username := form.GetFormItem(0).(*tview.InputField).GetText()
 		password := form.GetFormItem(1).(*tview.InputField).GetText()
 		player, token, err := a.authService.Login(username, password)
 		if err != nil {
-			footer.SetText(err.Error())
+			footer.SetText(friendlyError(err, errorMessage{i.ErrUnauthorized, "Wrong username or password."}))
 			return
 		}
+
+		// A session that cannot be remembered is still usable for this launch.
+		if form.GetFormItem(2).(*tview.Checkbox).IsChecked() {
+			_ = a.authService.Remember()
+		}
 		a.onLogin(player, token)
 	})
 
 	form.AddButton("Sign Up", func() {
-		app.SetRoot(a.signUpForm(app), true)
+		nav.Push(&signUpPage{authService: a.authService})
 	})
 
 	form.AddButton("Quit", func() {
-		app.Stop()
+		nav.Stop()
 	})
 
 	flex := tview.NewFlex().SetDirection(tview.FlexRow).
@@ -62,7 +80,13 @@ func (a *AuthPage) signInForm(app *tview.Application) tview.Primitive {
 	return flex
 }
 
-func (a *AuthPage) signUpForm(app *tview.Application) tview.Primitive {
+// signUpPage registers a new player and goes back to the sign in form.
+type signUpPage struct {
+	authService i.AuthServer
+}
+
+// OnEnter implements PageController.
+func (s *signUpPage) OnEnter(nav *Navigator) tview.Primitive {
 	header := tview.NewTextView().SetText("Sign Up").SetTextAlign(tview.AlignCenter)
 	footer := tview.NewTextView().SetText("").SetTextAlign(tview.AlignLeft)
 
@@ -73,16 +97,16 @@ func (a *AuthPage) signUpForm(app *tview.Application) tview.Primitive {
 	form.AddButton("Register", func() {
 		username := form.GetFormItem(0).(*tview.InputField).GetText()
 		password := form.GetFormItem(1).(*tview.InputField).GetText()
-		err := a.authService.Register(username, password)
+		err := s.authService.Register(username, password)
 		if err != nil {
-			footer.SetText(err.Error())
+			footer.SetText(friendlyError(err, errorMessage{i.ErrConflict, "This username is taken, pick another one."}))
 			return
 		}
-		app.SetRoot(a.signInForm(app), true)
+		nav.Pop()
 	})
 
 	form.AddButton("Back", func() {
-		app.SetRoot(a.signInForm(app), true)
+		nav.Pop()
 	})
 
 	flex := tview.NewFlex().SetDirection(tview.FlexRow).
@@ -92,3 +116,6 @@ func (a *AuthPage) signUpForm(app *tview.Application) tview.Primitive {
 
 	return flex
 }
+
+// OnLeave implements PageController.
+func (s *signUpPage) OnLeave() {}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+const sparklineWidth = 20 // SparklineWidth is the number of RTT samples drawn in the network panel.
+
+// sparkBars are the bars of a sparkline, from the lowest to the highest value.
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// networkRepr returns the network panel: the measures of the pings, their RTT history and the connection quality.
+func networkRepr(stats i.NetworkStats, conn i.ConnectionState) string {
+	color := qualityColor(stats.Quality())
+	return fmt.Sprintf("[yellow]NETWORK\n\n"+
+		"[white]RTT: [cyan]%s\n[white]Jitter: [cyan]%s\n[white]Loss: [cyan]%.0f%%\n[white]Clock offset: [cyan]%s\n"+
+		"[%s]%s\n[white]Quality: [%s]%s\n[white]Connection: %s",
+		durationRepr(stats.RTT), durationRepr(stats.Jitter), stats.Loss*100, offsetRepr(stats.Offset),
+		color, sparkline(stats.History, sparklineWidth), color, stats.Quality(), connStateRepr(conn))
+}
+
+// sparkline draws the last width durations as bars scaled from zero to the highest of them.
+func sparkline(history []time.Duration, width int) string {
+	history = history[max(len(history)-width, 0):]
+
+	highest := time.Duration(0)
+	for _, d := range history {
+		highest = max(highest, d)
+	}
+
+	var builder strings.Builder
+	for _, d := range history {
+		level := 0
+		if highest > 0 {
+			level = int(d * time.Duration(len(sparkBars)-1) / highest)
+		}
+		builder.WriteRune(sparkBars[level])
+	}
+	return builder.String()
+}
+
+// qualityColor returns the color of a connection quality.
+func qualityColor(q i.ConnectionQuality) string {
+	switch q {
+	case i.QualityGood:
+		return "green"
+	case i.QualityFair:
+		return "yellow"
+	}
+	return "red"
+}
+
+// durationRepr formats a network measure in milliseconds.
+func durationRepr(d time.Duration) string {
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
+
+// offsetRepr formats a clock offset in milliseconds with its sign.
+func offsetRepr(d time.Duration) string {
+	return fmt.Sprintf("%+dms", d.Milliseconds())
+}
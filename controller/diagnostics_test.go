@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// TestDiagnostics contains all the tests related to the network panel.
+func TestDiagnostics(t *testing.T) {
+	t.Run("Diagnostics_Sparkline", testDiagnostics_Sparkline)
+	t.Run("Diagnostics_Quality", testDiagnostics_Quality)
+}
+
+// testDiagnostics_Sparkline tests that the sparkline scales the last samples from zero to the highest.
+func testDiagnostics_Sparkline(t *testing.T) {
+	ms := func(values ...int) []time.Duration {
+		durations := make([]time.Duration, 0, len(values))
+		for _, v := range values {
+			durations = append(durations, time.Duration(v)*time.Millisecond)
+		}
+		return durations
+	}
+
+	tests := []struct {
+		history  []time.Duration
+		width    int
+		expected string
+	}{
+		{nil, 5, ""},
+		{ms(0, 0), 5, "▁▁"},
+		{ms(0, 35, 70), 5, "▁▄█"},
+		{ms(70, 10, 20, 40), 3, "▂▄█"},
+	}
+	for _, tt := range tests {
+		if got := sparkline(tt.history, tt.width); got != tt.expected {
+			t.Errorf("Expected the sparkline of %v to be %q, got: %q", tt.history, tt.expected, got)
+		}
+	}
+}
+
+// testDiagnostics_Quality tests that the connection is graded by its worst measure and colored accordingly.
+func testDiagnostics_Quality(t *testing.T) {
+	tests := []struct {
+		stats    i.NetworkStats
+		expected i.ConnectionQuality
+	}{
+		{i.NetworkStats{RTT: 30 * time.Millisecond}, i.QualityGood},
+		{i.NetworkStats{RTT: 150 * time.Millisecond}, i.QualityFair},
+		{i.NetworkStats{RTT: 30 * time.Millisecond, Jitter: 60 * time.Millisecond}, i.QualityPoor},
+		{i.NetworkStats{RTT: 30 * time.Millisecond, Loss: 0.2}, i.QualityPoor},
+	}
+	for _, tt := range tests {
+		if got := tt.stats.Quality(); got != tt.expected {
+			t.Errorf("Expected %+v to be %s, got: %s", tt.stats, tt.expected, got)
+		}
+	}
+
+	text := networkRepr(i.NetworkStats{RTT: 300 * time.Millisecond, Offset: -12 * time.Millisecond}, i.ConnectionConnected)
+	if !strings.Contains(text, "[red]poor") || !strings.Contains(text, "-12ms") {
+		t.Errorf("Expected a red poor quality and a signed offset, got: %q", text)
+	}
+}
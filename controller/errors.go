@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// errorMessage is the message shown to the player for the errors matching target.
+type errorMessage struct {
+	target error
+	text   string
+}
+
+// friendlyError describes err to the player. The page specific messages are checked first, in order,
+// then the errors common to every request.
+func friendlyError(err error, messages ...errorMessage) string {
+	for _, message := range messages {
+		if errors.Is(err, message.target) {
+			return message.text
+		}
+	}
+
+	var apiErr *i.APIError
+	switch {
+	case errors.Is(err, i.ErrRateLimited):
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			return fmt.Sprintf("Too many attempts, try again in %s.", apiErr.RetryAfter.Round(time.Second))
+		}
+		return "Too many attempts, try again later."
+	case errors.Is(err, i.ErrServerUnavailable):
+		return "The server is unreachable, check your connection and try again."
+	case errors.As(err, &apiErr) && apiErr.Message != "":
+		return apiErr.Message
+	}
+	return err.Error()
+}
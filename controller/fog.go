@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// cellPos is the row and column of a maze cell.
+type cellPos struct {
+	row, col int
+}
+
+// fogOfWar remembers the cells the local player visited. A cell is revealed once visited, and while the player
+// sees it along an open corridor.
+type fogOfWar struct {
+	visited map[cellPos]bool
+}
+
+func newFogOfWar() *fogOfWar {
+	return &fogOfWar{visited: make(map[cellPos]bool)}
+}
+
+// reveal marks the player's cell as visited and returns the visited cells along with the ones in sight.
+func (f *fogOfWar) reveal(player cellPos, sight map[cellPos]bool) map[cellPos]bool {
+	f.visited[player] = true
+
+	revealed := make(map[cellPos]bool, len(f.visited)+len(sight))
+	for pos := range f.visited {
+		revealed[pos] = true
+	}
+	for pos := range sight {
+		revealed[pos] = true
+	}
+	return revealed
+}
+
+// lineOfSight returns the cells seen from pos, going straight in every direction until a wall.
+func lineOfSight(grid [][]i.Cell, pos cellPos) map[cellPos]bool {
+	seen := map[cellPos]bool{pos: true}
+	if !inGrid(grid, pos) {
+		return seen
+	}
+
+	directions := []struct {
+		dRow, dCol int
+		wall       func(i.Cell) bool
+	}{
+		{-1, 0, i.Cell.HasNorthWall},
+		{1, 0, i.Cell.HasSouthWall},
+		{0, 1, i.Cell.HasEastWall},
+		{0, -1, i.Cell.HasWestWall},
+	}
+	for _, d := range directions {
+		current := pos
+		for !d.wall(grid[current.row][current.col]) {
+			current = cellPos{current.row + d.dRow, current.col + d.dCol}
+			if !inGrid(grid, current) {
+				break
+			}
+			seen[current] = true
+		}
+	}
+	return seen
+}
+
+// inGrid reports whether pos is a cell of the grid.
+func inGrid(grid [][]i.Cell, pos cellPos) bool {
+	return pos.row >= 0 && pos.row < len(grid) && pos.col >= 0 && pos.col < len(grid[pos.row])
+}
+
+// hideTiles covers the tiles of the cells that are not revealed with fog, the walls around a revealed cell
+// staying visible. Tiles are laid out like mazeTiles: cell row, col is the tile at 2*col+1, 2*row+1.
+func hideTiles(tiles [][]tile, revealed map[cellPos]bool) {
+	for y, row := range tiles {
+		for x := range row {
+			if !tileRevealed(x, y, revealed) {
+				row[x] = tile{kind: tileFog}
+			}
+		}
+	}
+}
+
+// tileRevealed reports whether the tile at x, y belongs to a revealed cell or to the walls around one.
+func tileRevealed(x, y int, revealed map[cellPos]bool) bool {
+	for _, r := range []int{(y - 1) / 2, y / 2} {
+		for _, c := range []int{(x - 1) / 2, x / 2} {
+			if 2*r <= y && y <= 2*r+2 && 2*c <= x && x <= 2*c+2 && revealed[cellPos{r, c}] {
+				return true
+			}
+		}
+	}
+	return false
+}
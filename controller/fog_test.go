@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	gamepb "github.com/sofc-t/puzzle-client/infrastruture/pb_encoder/game"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// TestFogOfWar contains all the tests related to the fog-of-war mode.
+func TestFogOfWar(t *testing.T) {
+	t.Run("FogOfWar_LineOfSight", testFogOfWar_LineOfSight)
+	t.Run("FogOfWar_Visited", testFogOfWar_Visited)
+	t.Run("FogOfWar_HideTiles", testFogOfWar_HideTiles)
+	t.Run("FogOfWar_Opponents", testFogOfWar_Opponents)
+	t.Run("FogOfWar_Disabled", testFogOfWar_Disabled)
+}
+
+// testFogOfWar_LineOfSight tests that a player sees along the open corridor up to the first wall.
+func testFogOfWar_LineOfSight(t *testing.T) {
+	grid := corridor()
+
+	sight := lineOfSight(grid, cellPos{0, 0})
+	if len(sight) != 2 || !sight[cellPos{0, 0}] || !sight[cellPos{0, 1}] {
+		t.Errorf("Expected the first two cells in sight, got: %v", sight)
+	}
+
+	sight = lineOfSight(grid, cellPos{0, 3})
+	if len(sight) != 2 || !sight[cellPos{0, 2}] || !sight[cellPos{0, 3}] {
+		t.Errorf("Expected the last two cells in sight, got: %v", sight)
+	}
+}
+
+// testFogOfWar_Visited tests that the visited cells stay revealed while the cells only seen do not.
+func testFogOfWar_Visited(t *testing.T) {
+	grid := corridor()
+	fog := newFogOfWar()
+
+	fog.reveal(cellPos{0, 0}, lineOfSight(grid, cellPos{0, 0}))
+	revealed := fog.reveal(cellPos{0, 2}, lineOfSight(grid, cellPos{0, 2}))
+
+	for _, pos := range []cellPos{{0, 0}, {0, 2}, {0, 3}} {
+		if !revealed[pos] {
+			t.Errorf("Expected %v to be revealed", pos)
+		}
+	}
+	if revealed[cellPos{0, 1}] {
+		t.Errorf("Expected the cell only seen before to be hidden again")
+	}
+}
+
+// testFogOfWar_HideTiles tests that the fog covers the tiles of hidden cells but not the walls of revealed ones.
+func testFogOfWar_HideTiles(t *testing.T) {
+	tiles := testTiles(9, 3, 1, 1)
+	hideTiles(tiles, map[cellPos]bool{{0, 0}: true})
+
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 9; x++ {
+			hidden := tiles[y][x].kind == tileFog
+			if x <= 2 && hidden {
+				t.Errorf("Expected the tile at (%d, %d) of the revealed cell to be visible", x, y)
+			}
+			if x > 2 && !hidden {
+				t.Errorf("Expected the tile at (%d, %d) of a hidden cell to be covered", x, y)
+			}
+		}
+	}
+}
+
+// testFogOfWar_Opponents tests that the opponents out of sight are not drawn.
+func testFogOfWar_Opponents(t *testing.T) {
+	g, playerID := newFogGame(t)
+	g.renderMaze(corridorState(playerID, true, 0, 1, 3))
+
+	tiles := g.mazeView.tiles
+	if tiles[1][1].kind != tileSelf || tiles[1][3].kind != tileOpponent {
+		t.Errorf("Expected the player and the opponent in sight to be drawn")
+	}
+	if tiles[1][5].kind != tileFog || tiles[1][7].kind != tileFog {
+		t.Errorf("Expected the cells out of sight to be covered, got: %v", tiles[1])
+	}
+}
+
+// testFogOfWar_Disabled tests that the whole maze is drawn when the state does not ask for the fog.
+func testFogOfWar_Disabled(t *testing.T) {
+	g, playerID := newFogGame(t)
+	g.renderMaze(corridorState(playerID, false, 0, 3))
+
+	for x, tl := range g.mazeView.tiles[1] {
+		if tl.kind == tileFog {
+			t.Errorf("Expected no fog, got some at column %d", x)
+		}
+	}
+	if g.mazeView.tiles[1][7].kind != tileOpponent {
+		t.Errorf("Expected the opponent to be drawn")
+	}
+}
+
+// corridor returns a single row of four cells, with a wall between the second and the third.
+func corridor() [][]i.Cell {
+	row := make([]i.Cell, 4)
+	for col := range row {
+		row[col] = &gamepb.Cell{NorthWall: true, SouthWall: true, WestWall: col == 0 || col == 2, EastWall: col == 1 || col == 3}
+	}
+	return [][]i.Cell{row}
+}
+
+// corridorState returns a state on the corridor with the local player and the opponents in the given columns.
+func corridorState(playerID uuid.UUID, fogOfWar bool, selfCol int, opponentCols ...int) i.GameState {
+	encoder := &gamepb.Protobuf{}
+	maze := encoder.NewMaze()
+	maze.SetGrid(corridor())
+
+	newPlayer := func(id uuid.UUID, col int) i.Player {
+		pos := encoder.NewCellPosition()
+		pos.SetCol(int32(col))
+		player := encoder.NewPlayer()
+		player.SetID(id)
+		player.SetPos(pos)
+		return player
+	}
+	players := []i.Player{newPlayer(playerID, selfCol)}
+	for _, col := range opponentCols {
+		players = append(players, newPlayer(uuid.New(), col))
+	}
+
+	gs := encoder.NewGameState()
+	gs.SetMaze(maze)
+	gs.SetPlayers(players)
+	gs.SetFogOfWar(fogOfWar)
+	return gs
+}
+
+func newFogGame(t *testing.T) (*Game, uuid.UUID) {
+	t.Helper()
+	playerID := uuid.New()
+	g, err := NewGame(nil, playerID, nil, nil)
+	if err != nil {
+		t.Fatalf("Expected a game, got error: %s", err)
+	}
+	return g, playerID
+}
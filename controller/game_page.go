@@ -3,7 +3,7 @@ package controller
 import (
 	"fmt"
 	"sort"
-	"strings"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/google/uuid"
@@ -11,92 +11,323 @@ import (
 	"github.com/sofc-t/puzzle-client/service/i"
 )
 
-// directions maps movement directions (North, South, East, West) to row and column deltas.
-var directions = map[tcell.Key]string{
-	tcell.KeyUp:    "North",
-	tcell.KeyDown:  "South",
-	tcell.KeyLeft:  "West",
-	tcell.KeyRight: "East",
-}
+const (
+	replaySeekStep    = 5000                   // ReplaySeekStep is the playback offset, in milliseconds, skipped by the seek keys of a replay.
+	timerRefreshDelay = 200 * time.Millisecond // TimerRefreshDelay is the refresh interval of the time left of a timed game.
+	helpPage          = "help"
+	gamePage          = "game"
+)
 
-// Additional handling for Vim motions
-var vimDirections = map[rune]string{
-	'k': "North", // Vim motion: k for up
-	'j': "South", // Vim motion: j for down
-	'h': "West",  // Vim motion: h for left
-	'l': "East",  // Vim motion: l for right
-}
+type gameEndHandler func(i.GameState)
+
+// GameOption configures a Game.
+type GameOption func(*Game)
 
 // Game holds the maze, score, ping, and player information
+// Its state is only accessed on the UI goroutine, the callbacks of the game server being posted to it.
 type Game struct {
-	gameServer   i.GameServer
-	playerColors map[uuid.UUID]string
-	playerID     uuid.UUID
-	app          *tview.Application
-	mazeTV       *tview.TextView
-	scoreTV      *tview.Table
-	pingTV       *tview.TextView
-	stopChan     chan struct{}
-}
-
-// NewGame creates a new MazeGame instance
-func NewGame(gmSrvr i.GameServer, pID uuid.UUID) (*Game, error) {
-	return &Game{
+	gameServer       i.GameServer
+	authToken        []byte
+	opponents        map[uuid.UUID]int // Opponents numbers the other players, from 0.
+	playerID         uuid.UUID
+	keymap           *Keymap
+	theme            *Theme
+	onThemeChange    func(*Theme)
+	nav              *Navigator
+	mazeView         *MazeView
+	minimap          *Minimap
+	fog              *fogOfWar // Fog remembers the explored cells, when the state asks for the fog-of-war mode.
+	scoreTV          *tview.Table
+	pingTV           *tview.TextView
+	timerTV          *tview.TextView // TimerTV shows the time left of a timed game.
+	helpTable        *tview.Table
+	layout           *tview.Flex
+	pages            *tview.Pages // Pages shows the help over the layout.
+	scoreboardHidden bool
+	sidebar          *tview.Flex // Sidebar stacks the timer, the ping and the minimap.
+	minimapHidden    bool
+	netStats         i.NetworkStats    // NetStats are the measures of the last pong.
+	startedAt        int64             // StartedAt is the server time the match started at, in milliseconds.
+	connState        i.ConnectionState // ConnState is the state of the connection to the game server.
+	started          bool              // Started is set once the game server is started, by the first OnEnter.
+	doneChan         chan struct{}     // DoneChan is closed when the game ends.
+	leaveChan        chan struct{}     // LeaveChan is closed when the page is left.
+	onGameEnd        gameEndHandler
+}
+
+// NewGame creates a new MazeGame instance. The game server is started with authToken when the page is shown,
+// and onGameEnd is called on the UI goroutine. The keys are the ones of DefaultKeyPreset unless set by an option.
+func NewGame(gmSrvr i.GameServer, pID uuid.UUID, authToken []byte, onGameEnd gameEndHandler, options ...GameOption) (*Game, error) {
+	g := &Game{
 		gameServer: gmSrvr,
+		authToken:  authToken,
 		playerID:   pID,
-		mazeTV:     tview.NewTextView().SetDynamicColors(true),
+		onGameEnd:  onGameEnd,
 		scoreTV:    tview.NewTable(),
 		pingTV:     tview.NewTextView().SetDynamicColors(true),
-		stopChan:   make(chan struct{}),
-	}, nil
+		timerTV:    tview.NewTextView().SetDynamicColors(true),
+		helpTable:  tview.NewTable(),
+		fog:        newFogOfWar(),
+		doneChan:   make(chan struct{}),
+		leaveChan:  make(chan struct{}),
+	}
+	for _, opt := range options {
+		opt(g)
+	}
+
+	if g.theme == nil {
+		g.theme, _ = ThemeByName(DefaultThemeName)
+	}
+	if g.keymap == nil {
+		keymap, err := NewKeymap(DefaultKeyPreset, nil)
+		if err != nil {
+			return nil, err
+		}
+		g.keymap = keymap
+	}
+
+	// Combine maze, scoreboard, timer, ping and minimap into a Flex layout
+	g.mazeView = NewMazeView(g.theme)
+	g.minimap = NewMinimap(g.theme)
+	g.minimap.SetBorder(true).SetTitle(" Map ")
+	g.sidebar = tview.NewFlex().SetDirection(tview.FlexRow)
+	if _, ok := g.gameServer.(i.TimedGame); ok {
+		g.sidebar.AddItem(g.timerTV, 5, 0, false)
+	}
+	g.sidebar.
+		AddItem(g.pingTV, 0, 1, false).
+		AddItem(g.minimap, 0, 1, false)
+	g.layout = tview.NewFlex().
+		AddItem(g.mazeView, 0, 3, true). // Maze occupies 3/4 of the screen width
+		AddItem(g.scoreTV, 0, 1, false). // Scoreboard
+		AddItem(g.sidebar, 0, 1, false)  // Timer, ping and minimap
+
+	g.renderHelp()
+	g.pages = tview.NewPages().
+		AddPage(gamePage, g.layout, true, true).
+		AddPage(helpPage, centered(g.helpTable, 50, len(g.keymap.Help(g.isReplay()))+4), true, false)
+	return g, nil
+}
+
+// GameWithKeymap sets the keys of the game actions.
+func GameWithKeymap(keymap *Keymap) GameOption {
+	return func(g *Game) {
+		g.keymap = keymap
+	}
+}
+
+// GameWithTheme sets the theme of the maze, onChange is called with the theme picked by the player, if not nil.
+func GameWithTheme(theme *Theme, onChange func(*Theme)) GameOption {
+	return func(g *Game) {
+		g.theme = theme
+		g.onThemeChange = onChange
+	}
+}
+
+// HandleInput implements InputHandler. While the help is shown, only the help and quit actions are handled.
+func (g *Game) HandleInput(event *tcell.EventKey) *tcell.EventKey {
+	action, ok := g.keymap.Action(event)
+	if !ok {
+		return event
+	}
+
+	helpShown, _ := g.pages.GetFrontPage()
+	if helpShown == helpPage && action != ActionHelp && action != ActionQuit {
+		return nil
+	}
+
+	switch action {
+	case ActionQuit:
+		g.nav.Stop()
+	case ActionHelp:
+		g.toggleHelp()
+	case ActionToggleScoreboard:
+		g.toggleScoreboard()
+	case ActionCycleTheme:
+		g.cycleTheme()
+	case ActionToggleZoom:
+		g.mazeView.ToggleCompact()
+	case ActionToggleMinimap:
+		g.toggleMinimap()
+	default:
+		if replay, ok := g.gameServer.(i.ReplayController); ok {
+			g.handleReplayAction(replay, action)
+		} else if direction, ok := moveDirections[action]; ok {
+			g.gameServer.Move(direction)
+		}
+	}
+	return nil
+}
+
+// handleReplayAction applies a playback control to a replay.
+func (g *Game) handleReplayAction(replay i.ReplayController, action Action) {
+	switch action {
+	case ActionReplayPause:
+		replay.TogglePause()
+	case ActionReplayStep:
+		replay.Step(1)
+	case ActionReplayStepBack:
+		replay.Step(-1)
+	case ActionReplaySeek:
+		replay.Skip(replaySeekStep)
+	case ActionReplaySeekBack:
+		replay.Skip(-replaySeekStep)
+	case ActionReplayFaster:
+		replay.SetSpeed(replay.Status().Speed * 2)
+	case ActionReplaySlower:
+		replay.SetSpeed(replay.Status().Speed / 2)
+	default:
+		return
+	}
+	g.renderReplayStatus(replay.Status())
 }
 
-func (g *Game) handleInput(event *tcell.EventKey) *tcell.EventKey {
-	if direction, ok := directions[event.Key()]; ok {
-		g.gameServer.Move(direction)
-	} else if direction, ok := vimDirections[event.Rune()]; ok {
-		g.gameServer.Move(direction)
-	} else if event.Key() == tcell.KeyCtrlC {
-		g.stopChan <- struct{}{}
+// toggleHelp shows or hides the help over the game.
+func (g *Game) toggleHelp() {
+	if name, _ := g.pages.GetFrontPage(); name == helpPage {
+		g.pages.HidePage(helpPage)
+	} else {
+		g.pages.ShowPage(helpPage)
 	}
-	return event
 }
 
-// startApp starts the Tview app with the layout
-func (g *Game) Start(app *tview.Application, authToken []byte) {
-	g.app = app
-	g.app.Stop()
+// toggleScoreboard hides or shows the scoreboard, the maze taking its width.
+func (g *Game) toggleScoreboard() {
+	g.scoreboardHidden = !g.scoreboardHidden
+	if g.scoreboardHidden {
+		g.layout.ResizeItem(g.scoreTV, 0, 0)
+	} else {
+		g.layout.ResizeItem(g.scoreTV, 0, 1)
+	}
+}
+
+// toggleMinimap hides or shows the minimap.
+func (g *Game) toggleMinimap() {
+	g.minimapHidden = !g.minimapHidden
+	if g.minimapHidden {
+		g.sidebar.ResizeItem(g.minimap, 0, 0)
+	} else {
+		g.sidebar.ResizeItem(g.minimap, 0, 1)
+	}
+}
+
+// isReplay reports whether the game is a replay.
+func (g *Game) isReplay() bool {
+	_, ok := g.gameServer.(i.ReplayController)
+	return ok
+}
+
+// renderHelp lists the bindings of the keymap in the help table.
+func (g *Game) renderHelp() {
+	g.helpTable.SetBorder(true).SetTitle(" Keys ")
+	for row, binding := range g.keymap.Help(g.isReplay()) {
+		g.helpTable.SetCell(row, 0, tview.NewTableCell(tview.Escape(binding[0])).SetTextColor(tcell.ColorYellow))
+		g.helpTable.SetCell(row, 1, tview.NewTableCell(binding[1]).SetTextColor(tcell.ColorWhite).SetExpansion(1))
+	}
+}
+
+// centered returns p centered in a box of width and height.
+func centered(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 0, true).
+			AddItem(nil, 0, 1, false), width, 0, true).
+		AddItem(nil, 0, 1, false)
+}
+
+// OnEnter implements PageController. The game server is started the first time the page is shown.
+// The callbacks of the game server are posted to the UI goroutine in the order they are called. They are never
+// waited for, as the game server may call them on the UI goroutine, when a move is predicted.
+func (g *Game) OnEnter(nav *Navigator) tview.Primitive {
+	g.nav = nav
+	if g.started {
+		return g.pages
+	}
+	g.started = true
+
 	g.gameServer.SetOnStateChange(func(gs i.GameState) {
-		g.renderMaze(gs)
-		g.renderScoreboard(gs)
-		g.app.Draw()
+		g.nav.Post(func() {
+			g.startedAt = gs.GetStartedAt()
+			g.renderMaze(gs)
+			g.renderScoreboard(gs)
+			if replay, ok := g.gameServer.(i.ReplayController); ok {
+				g.renderReplayStatus(replay.Status())
+			}
+		})
 	})
-	g.gameServer.SetOnPingResult(func(ping int64) {
-		g.renderPing(ping)
-		g.app.Draw()
+	g.gameServer.SetOnPingResult(func(stats i.NetworkStats) {
+		g.nav.Post(func() {
+			g.netStats = stats
+			g.renderPing()
+		})
+	})
+	g.gameServer.SetOnConnectionStateChange(func(s i.ConnectionState) {
+		g.nav.Post(func() {
+			g.connState = s
+			g.renderPing()
+		})
+	})
+	g.gameServer.SetOnGameEnd(func(gs i.GameState) {
+		close(g.doneChan)
+		g.nav.Post(func() {
+			g.onGameEnd(gs)
+		})
 	})
 
-	// Combine maze, scoreboard, and ping into a Flex layout
-	layout := tview.NewFlex().
-		AddItem(g.mazeTV, 0, 3, true).   // Maze occupies 3/4 of the screen width
-		AddItem(g.scoreTV, 0, 1, false). // Scoreboard
-		AddItem(g.pingTV, 0, 1, false)   // Ping
-
-	g.app.SetInputCapture(g.handleInput)
-	g.mazeTV.SetText("loading...")
+	g.mazeView.SetRedraw(nav.Draw)
 	go func() {
-		_ = g.gameServer.Start(authToken)
+		_ = g.gameServer.Start(g.authToken)
 	}()
 
-	go func() {
-		if err := app.SetRoot(layout, true).Run(); err != nil {
-			panic(err)
-		}
-	}()
+	if timed, ok := g.gameServer.(i.TimedGame); ok {
+		go g.renderTimer(timed)
+	}
+	return g.pages
+}
 
-	for range g.stopChan {
-		g.app.Stop()
+// OnLeave implements PageController. Leaving the page before the end of the game stops it.
+func (g *Game) OnLeave() {
+	select {
+	case <-g.leaveChan:
 		return
+	default:
+		close(g.leaveChan)
+	}
+
+	select {
+	case <-g.doneChan:
+	default:
+		if g.started {
+			_ = g.gameServer.Stop()
+		}
+	}
+}
+
+// renderTimer refreshes the time left of a timed game until the game is over.
+func (g *Game) renderTimer(timed i.TimedGame) {
+	ticker := time.NewTicker(timerRefreshDelay)
+	defer ticker.Stop()
+
+	for {
+		left := timed.TimeLeft()
+		color := "green"
+		if left < 10*time.Second {
+			color = "red"
+		}
+		text := fmt.Sprintf("[yellow]TIMER\n\n[white]Time left: [%s]%s\n\n%s", color, left.Round(time.Second), g.helpHint())
+		g.nav.Post(func() {
+			g.timerTV.SetText(text)
+		})
+
+		select {
+		case <-ticker.C:
+		case <-g.doneChan:
+			return
+		case <-g.leaveChan:
+			return
+		}
 	}
 }
 
@@ -122,65 +353,155 @@ func (g *Game) renderScoreboard(gs i.GameState) {
 	}
 }
 
-func (g *Game) renderPing(ping int64) {
-	text := fmt.Sprintf("[yellow]PING\n\n[white]Ping: [cyan]%dms", ping)
+// renderPing shows the network diagnostics of the last pong, after the match time when the server clock is known.
+// It runs on the UI goroutine.
+func (g *Game) renderPing() {
+	text := networkRepr(g.netStats, g.connState) + "\n\n" + g.helpHint()
+	if clock, ok := g.gameServer.(i.ServerClock); ok && g.startedAt > 0 {
+		elapsed := max(clock.ServerNow().UnixMilli()-g.startedAt, 0)
+		text = fmt.Sprintf("[white]Match time: [cyan]%s\n\n", replayTimeRepr(elapsed)) + text
+	}
+	g.pingTV.SetText(text)
+}
+
+// renderReplayStatus shows the playback position and controls of a replay in place of the ping.
+// It runs on the UI goroutine.
+func (g *Game) renderReplayStatus(s i.ReplayStatus) {
+	state := "[green]playing"
+	if s.Paused {
+		state = "[yellow]paused"
+	}
+
+	text := fmt.Sprintf("[yellow]REPLAY\n\n[white]%s / %s\n[white]Speed: [cyan]%gx\n%s\n\n%s",
+		replayTimeRepr(s.Position), replayTimeRepr(s.Duration), s.Speed, state, g.helpHint())
 	g.pingTV.SetText(text)
-	g.app.Draw()
 }
 
-// renderMaze renders the maze into a string
+// helpHint tells the keys showing the help, if any.
+func (g *Game) helpHint() string {
+	if keys := g.keymap.KeyNames(ActionHelp); keys != "" {
+		return "[white]Keys: [yellow]" + tview.Escape(keys)
+	}
+	return ""
+}
+
+// replayTimeRepr formats a playback time in milliseconds as minutes and seconds.
+func replayTimeRepr(ms int64) string {
+	return fmt.Sprintf("%02d:%02d", ms/60000, ms/1000%60)
+}
+
+// connStateRepr returns a colored representation of the connection state.
+func connStateRepr(s i.ConnectionState) string {
+	switch s {
+	case i.ConnectionConnected:
+		return "[green]" + s.String()
+	case i.ConnectionDegraded, i.ConnectionReconnecting:
+		return "[yellow]" + s.String()
+	default:
+		return "[red]" + s.String()
+	}
+}
+
+// renderMaze hands the tiles of the maze and the players to the maze view and the minimap.
+// In the fog-of-war mode, the cells the local player did not explore are hidden, and so are the opponents
+// out of sight.
 func (g *Game) renderMaze(gs i.GameState) {
-	var builder strings.Builder
-	grid := mazeGridRepr(gs)
-	playersRpr := g.playerMap(gs)
+	tiles := mazeTiles(gs)
+	players := gs.RetrivePlayers()
 
-	// Top border
-	builder.WriteString(strings.Repeat("[:blue]  [:black]", len(grid[0])) + "\n")
-	for y, r := range grid {
-		for x, c := range r {
-			if repr, ok := playersRpr[fmt.Sprintf("%d,%d", x, y)]; ok {
-				builder.WriteString(repr) // Player position
-			} else if c == -1 {
-				builder.WriteString("[:blue]  [:black]") // Wall
-			} else if c == 1 {
-				builder.WriteString("[white] ●[black]") // Reward 1
-			} else if c == 5 {
-				builder.WriteString("[yellow] ●[black]") // Reward 5
-			} else {
-				builder.WriteString("  ") // Empty space
-			}
+	var sight map[cellPos]bool
+	for _, p := range players {
+		if p.GetID() == g.playerID && gs.GetFogOfWar() {
+			self := cellPos{int(p.RetrivePos().GetRow()), int(p.RetrivePos().GetCol())}
+			sight = lineOfSight(gs.RetriveMaze().RetriveGrid(), self)
+			hideTiles(tiles, g.fog.reveal(self, sight))
 		}
-		builder.WriteString("\n")
 	}
-	g.mazeTV.SetText(builder.String())
+
+	focusX, focusY, hasFocus := 0, 0, false
+	for _, p := range players {
+		pos := cellPos{int(p.RetrivePos().GetRow()), int(p.RetrivePos().GetCol())}
+		x, y := pos.col*2+1, pos.row*2+1
+		if y >= len(tiles) || x >= len(tiles[y]) {
+			continue
+		}
+		if p.GetID() == g.playerID {
+			tiles[y][x] = tile{kind: tileSelf}
+			focusX, focusY, hasFocus = x, y, true
+		} else if tiles[y][x].kind != tileSelf && (sight == nil || sight[pos]) {
+			// The local player stays visible over an opponent.
+			tiles[y][x] = tile{kind: tileOpponent, value: int32(g.opponentNumber(p.GetID(), players))}
+		}
+	}
+	g.mazeView.SetTiles(tiles, focusX, focusY, hasFocus)
+	g.minimap.SetTiles(tiles)
 }
 
+// playerRepr returns the markup of the player.
 func (g *Game) playerRepr(pID uuid.UUID, players []i.Player) string {
-	if g.playerColors == nil {
-		g.playerColors = make(map[uuid.UUID]string)
-		colors := [6]string{"yellow", "orange", "lime", "purple", "magenta"}
-		i := 0
+	if pID == g.playerID {
+		return g.theme.self().markup()
+	}
+	return g.theme.opponent(g.opponentNumber(pID, players)).markup()
+}
+
+// opponentNumber returns the number of an opponent, counted from 0 in the order of the first state.
+func (g *Game) opponentNumber(pID uuid.UUID, players []i.Player) int {
+	if g.opponents == nil {
+		g.opponents = make(map[uuid.UUID]int)
 		for _, p := range players {
-			if p.GetID() == g.playerID {
-				g.playerColors[p.GetID()] = "⭕"
-			} else {
-				g.playerColors[p.GetID()] = fmt.Sprintf("[%s]P%d[black]", colors[i], i+1)
+			if p.GetID() != g.playerID {
+				g.opponents[p.GetID()] = len(g.opponents)
 			}
-			i++
 		}
 	}
 
-	return g.playerColors[pID]
+	n, ok := g.opponents[pID]
+	if !ok {
+		n = len(g.opponents)
+		g.opponents[pID] = n
+	}
+	return n
 }
 
-func (g *Game) playerMap(gs i.GameState) map[string]string {
-	rprMap := make(map[string]string)
-	for _, p := range gs.RetrivePlayers() {
-		key := fmt.Sprintf("%d,%d", p.RetrivePos().GetCol()*2+1, p.RetrivePos().GetRow()*2)
-		rprMap[key] = g.playerRepr(p.GetID(), gs.RetrivePlayers())
+// cycleTheme switches to the next theme and draws the maze with it.
+func (g *Game) cycleTheme() {
+	g.theme = nextTheme(g.theme)
+	theme := g.theme
+
+	// The scoreboard follows with the next state, it is only drawn with the states.
+	g.mazeView.SetTheme(theme)
+	g.minimap.SetTheme(theme)
+	if g.onThemeChange != nil {
+		g.onThemeChange(theme)
 	}
+}
 
-	return rprMap
+// mazeTiles returns the tiles of the maze with its border, without the players.
+func mazeTiles(gs i.GameState) [][]tile {
+	grid := mazeGridRepr(gs)
+	if len(grid) == 0 {
+		return nil
+	}
+
+	tiles := make([][]tile, 0, len(grid)+1)
+	border := make([]tile, len(grid[0]))
+	for x := range border {
+		border[x] = tile{kind: tileWall}
+	}
+	tiles = append(tiles, border)
+	for _, r := range grid {
+		row := make([]tile, len(r))
+		for x, c := range r {
+			if c == -1 {
+				row[x] = tile{kind: tileWall}
+			} else if c > 0 {
+				row[x] = tile{kind: tileReward, value: int32(c)}
+			}
+		}
+		tiles = append(tiles, row)
+	}
+	return tiles
 }
 
 // mazeGridRepr generates a grid representation from the maze skipping players.
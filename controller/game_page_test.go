@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/google/uuid"
+	"github.com/rivo/tview"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// fakeTimedServer is a timed i.GameServer whose callbacks are called by the test.
+type fakeTimedServer struct {
+	onPingResult            func(i.NetworkStats)
+	onConnectionStateChange func(i.ConnectionState)
+}
+
+func (f *fakeTimedServer) Move(string)                             {}
+func (f *fakeTimedServer) Start([]byte) error                      { return nil }
+func (f *fakeTimedServer) Stop() error                             { return nil }
+func (f *fakeTimedServer) SetOnGameEnd(func(i.GameState))          {}
+func (f *fakeTimedServer) SetOnStateChange(func(i.GameState))      {}
+func (f *fakeTimedServer) TimeLeft() time.Duration                 { return time.Minute }
+func (f *fakeTimedServer) SetOnPingResult(fn func(i.NetworkStats)) { f.onPingResult = fn }
+func (f *fakeTimedServer) SetOnConnectionStateChange(fn func(i.ConnectionState)) {
+	f.onConnectionStateChange = fn
+}
+
+// predictingServer is an i.GameServer publishing the state of every move before returning, as the prediction does,
+// and ending the game on the second move.
+type predictingServer struct {
+	fakeTimedServer
+	playerID      uuid.UUID
+	moves         int
+	onStateChange func(i.GameState)
+	onGameEnd     func(i.GameState)
+}
+
+func (p *predictingServer) SetOnStateChange(fn func(i.GameState)) { p.onStateChange = fn }
+func (p *predictingServer) SetOnGameEnd(fn func(i.GameState))     { p.onGameEnd = fn }
+func (p *predictingServer) Move(string) {
+	p.moves++
+	state := corridorState(p.playerID, false, p.moves)
+	p.onStateChange(state)
+	if p.moves == 2 {
+		p.onGameEnd(state)
+	}
+}
+
+// TestGamePage contains all the tests related to the game page.
+func TestGamePage(t *testing.T) {
+	t.Run("GamePage_CallbacksOnUIGoroutine", testGamePage_CallbacksOnUIGoroutine)
+	t.Run("GamePage_MovePublishesState", testGamePage_MovePublishesState)
+}
+
+// testGamePage_CallbacksOnUIGoroutine tests that the callbacks of the game server, called from other goroutines,
+// are rendered in order on the UI goroutine, and that the timer does not overwrite the network panel.
+func testGamePage_CallbacksOnUIGoroutine(t *testing.T) {
+	server := &fakeTimedServer{}
+	g, err := NewGame(server, uuid.New(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	screen := tcell.NewSimulationScreen("")
+	nav := NewNavigator(tview.NewApplication().SetScreen(screen))
+	nav.Push(g)
+	done := make(chan error)
+	go func() { done <- nav.Run() }()
+
+	// The checks run on the UI goroutine after the callbacks, once the timer is shown.
+	var check func()
+	check = func() {
+		timer := g.timerTV.GetText(true)
+		if timer == "" {
+			nav.Post(check)
+			return
+		}
+
+		ping := g.pingTV.GetText(true)
+		if !strings.Contains(ping, "Connection: lost") || !strings.Contains(ping, "40ms") {
+			t.Errorf("Expected the last connection state and ping, got: %q", ping)
+		}
+		if strings.Contains(ping, "TIMER") || !strings.Contains(timer, "Time left") {
+			t.Errorf("Expected the timer in its own view, got ping %q and timer %q", ping, timer)
+		}
+		nav.Pop()
+	}
+
+	go func() {
+		server.onPingResult(i.NetworkStats{RTT: 40 * time.Millisecond})
+		for _, s := range []i.ConnectionState{i.ConnectionConnected, i.ConnectionDegraded, i.ConnectionLost} {
+			server.onConnectionStateChange(s)
+		}
+		nav.Post(check)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Expected the app to stop, got error: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the app to stop")
+	}
+}
+
+// testGamePage_MovePublishesState tests that the keys keep being handled when the game server publishes the states
+// and ends the game from the UI goroutine, within Move.
+func testGamePage_MovePublishesState(t *testing.T) {
+	playerID := uuid.New()
+	server := &predictingServer{playerID: playerID}
+	screen := tcell.NewSimulationScreen("")
+	nav := NewNavigator(tview.NewApplication().SetScreen(screen))
+
+	ended := make(chan int32, 1)
+	g, err := NewGame(server, playerID, nil, func(gs i.GameState) {
+		ended <- gs.RetrivePlayers()[0].RetrivePos().GetCol()
+		nav.Pop()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nav.Push(g)
+	done := make(chan error)
+	go func() { done <- nav.Run() }()
+	for range 2 {
+		nav.app.QueueEvent(tcell.NewEventKey(tcell.KeyRight, 0, tcell.ModNone))
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Expected the app to stop, got error: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the moves to be handled and the game to end")
+	}
+	if col := <-ended; col != 2 {
+		t.Errorf("Expected the game to end after the second move, got col: %d", col)
+	}
+}
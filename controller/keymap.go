@@ -0,0 +1,268 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// Action is a command of the game page bound to keys by a Keymap.
+type Action string
+
+const (
+	ActionMoveNorth        Action = "move_north"
+	ActionMoveSouth        Action = "move_south"
+	ActionMoveEast         Action = "move_east"
+	ActionMoveWest         Action = "move_west"
+	ActionQuit             Action = "quit"
+	ActionToggleScoreboard Action = "toggle_scoreboard"
+	ActionHelp             Action = "help"
+	ActionCycleTheme       Action = "cycle_theme"
+	ActionToggleZoom       Action = "toggle_zoom"
+	ActionToggleMinimap    Action = "toggle_minimap"
+	ActionReplayPause      Action = "replay_pause"
+	ActionReplayStepBack   Action = "replay_step_back"
+	ActionReplayStep       Action = "replay_step"
+	ActionReplaySeekBack   Action = "replay_seek_back"
+	ActionReplaySeek       Action = "replay_seek"
+	ActionReplaySlower     Action = "replay_slower"
+	ActionReplayFaster     Action = "replay_faster"
+)
+
+// actionScope tells in which games an action is available.
+type actionScope int
+
+const (
+	scopeAll actionScope = iota
+	scopeLive
+	scopeReplay
+)
+
+// actions lists the actions in the order of the help, with their description.
+var actions = []struct {
+	action      Action
+	scope       actionScope
+	description string
+}{
+	{ActionMoveNorth, scopeLive, "Move north"},
+	{ActionMoveSouth, scopeLive, "Move south"},
+	{ActionMoveWest, scopeLive, "Move west"},
+	{ActionMoveEast, scopeLive, "Move east"},
+	{ActionReplayPause, scopeReplay, "Pause or resume"},
+	{ActionReplayStepBack, scopeReplay, "Step back"},
+	{ActionReplayStep, scopeReplay, "Step forward"},
+	{ActionReplaySeekBack, scopeReplay, "Seek back"},
+	{ActionReplaySeek, scopeReplay, "Seek forward"},
+	{ActionReplaySlower, scopeReplay, "Slow down"},
+	{ActionReplayFaster, scopeReplay, "Speed up"},
+	{ActionToggleScoreboard, scopeAll, "Toggle scoreboard"},
+	{ActionCycleTheme, scopeAll, "Switch theme"},
+	{ActionToggleZoom, scopeAll, "Zoom the maze out or in"},
+	{ActionToggleMinimap, scopeAll, "Toggle minimap"},
+	{ActionHelp, scopeAll, "Show or hide this help"},
+	{ActionQuit, scopeAll, "Quit"},
+}
+
+// moveDirections maps the move actions to the directions of the game server.
+var moveDirections = map[Action]string{
+	ActionMoveNorth: i.North,
+	ActionMoveSouth: i.South,
+	ActionMoveEast:  i.East,
+	ActionMoveWest:  i.West,
+}
+
+// DefaultKeyPreset is the preset of the keymap when none is configured.
+const DefaultKeyPreset = "arrows"
+
+// commonBindings are the bindings shared by every preset.
+var commonBindings = map[Action][]string{
+	ActionQuit:             {"Ctrl-C"},
+	ActionToggleScoreboard: {"Tab"},
+	ActionCycleTheme:       {"t"},
+	ActionToggleZoom:       {"z"},
+	ActionToggleMinimap:    {"m"},
+	ActionHelp:             {"?"},
+	ActionReplayPause:      {"Space"},
+	ActionReplayStepBack:   {","},
+	ActionReplayStep:       {"."},
+	ActionReplaySeekBack:   {"["},
+	ActionReplaySeek:       {"]"},
+	ActionReplaySlower:     {"-"},
+	ActionReplayFaster:     {"+"},
+}
+
+// keyPresets are the move bindings of the presets. The arrow keys are kept by every preset.
+var keyPresets = map[string]map[Action][]string{
+	"arrows": {
+		ActionMoveNorth: {"Up"},
+		ActionMoveSouth: {"Down"},
+		ActionMoveWest:  {"Left"},
+		ActionMoveEast:  {"Right"},
+	},
+	"vim": {
+		ActionMoveNorth: {"Up", "k"},
+		ActionMoveSouth: {"Down", "j"},
+		ActionMoveWest:  {"Left", "h"},
+		ActionMoveEast:  {"Right", "l"},
+	},
+	"wasd": {
+		ActionMoveNorth: {"Up", "w"},
+		ActionMoveSouth: {"Down", "s"},
+		ActionMoveWest:  {"Left", "a"},
+		ActionMoveEast:  {"Right", "d"},
+	},
+	"numpad": {
+		ActionMoveNorth: {"Up", "8"},
+		ActionMoveSouth: {"Down", "2"},
+		ActionMoveWest:  {"Left", "4"},
+		ActionMoveEast:  {"Right", "6"},
+	},
+}
+
+var (
+	ErrUnknownKeyPreset = errors.New("unknown key preset")
+	ErrUnknownAction    = errors.New("unknown action")
+	ErrUnknownKey       = errors.New("unknown key")
+	ErrKeyConflict      = errors.New("key bound to several actions")
+)
+
+// Key is a key of the keyboard, either a rune or a special key.
+type Key struct {
+	Code tcell.Key
+	Rune rune // Rune is the character of a tcell.KeyRune key.
+}
+
+// keyCodes are the special keys by lower case name, like "up" or "ctrl-c".
+var keyCodes = func() map[string]tcell.Key {
+	codes := make(map[string]tcell.Key, len(tcell.KeyNames))
+	for code, name := range tcell.KeyNames {
+		codes[strings.ToLower(name)] = code
+	}
+	return codes
+}()
+
+// ParseKey parses a key name: a single character, "Space", or a special key named like tcell, such as "Up",
+// "Tab" or "Ctrl-C". Special key names are case insensitive.
+func ParseKey(name string) (Key, error) {
+	if runes := []rune(name); len(runes) == 1 {
+		return Key{Code: tcell.KeyRune, Rune: runes[0]}, nil
+	}
+	if strings.EqualFold(name, "space") {
+		return Key{Code: tcell.KeyRune, Rune: ' '}, nil
+	}
+	if code, ok := keyCodes[strings.ToLower(name)]; ok && code != tcell.KeyRune {
+		return Key{Code: code}, nil
+	}
+	return Key{}, fmt.Errorf("%w %q", ErrUnknownKey, name)
+}
+
+// eventKey returns the key of an event.
+func eventKey(event *tcell.EventKey) Key {
+	if event.Key() == tcell.KeyRune {
+		return Key{Code: tcell.KeyRune, Rune: event.Rune()}
+	}
+	return Key{Code: event.Key()}
+}
+
+// String returns the name of the key, as parsed by ParseKey.
+func (k Key) String() string {
+	switch {
+	case k.Code == tcell.KeyRune && k.Rune == ' ':
+		return "Space"
+	case k.Code == tcell.KeyRune:
+		return string(k.Rune)
+	}
+	if name, ok := tcell.KeyNames[k.Code]; ok {
+		return name
+	}
+	return fmt.Sprintf("Key[%d]", k.Code)
+}
+
+// Keymap binds keys to the actions of the game page.
+type Keymap struct {
+	keys    map[Action][]Key
+	actions map[Key]Action
+}
+
+// NewKeymap returns the keymap of the named preset, DefaultKeyPreset when empty, with the keys of the actions
+// in overrides replaced. Overrides are keyed by action name, like "move_north". A key bound to two actions is an error.
+func NewKeymap(preset string, overrides map[string][]string) (*Keymap, error) {
+	if preset == "" {
+		preset = DefaultKeyPreset
+	}
+	moves, ok := keyPresets[preset]
+	if !ok {
+		return nil, fmt.Errorf("%w %q, expected one of: %s", ErrUnknownKeyPreset, preset, strings.Join(KeyPresets(), ", "))
+	}
+
+	names := make(map[Action][]string, len(actions))
+	for action, keys := range commonBindings {
+		names[action] = keys
+	}
+	for action, keys := range moves {
+		names[action] = keys
+	}
+	for name, keys := range overrides {
+		if _, ok := names[Action(name)]; !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknownAction, name)
+		}
+		names[Action(name)] = keys
+	}
+
+	k := &Keymap{keys: make(map[Action][]Key, len(names)), actions: make(map[Key]Action)}
+	for _, a := range actions {
+		for _, name := range names[a.action] {
+			key, err := ParseKey(name)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", a.action, err)
+			}
+			if other, ok := k.actions[key]; ok && other != a.action {
+				return nil, fmt.Errorf("%w: %s is bound to %s and %s", ErrKeyConflict, key, other, a.action)
+			}
+			k.actions[key] = a.action
+			k.keys[a.action] = append(k.keys[a.action], key)
+		}
+	}
+	return k, nil
+}
+
+// KeyPresets returns the sorted names of the key presets.
+func KeyPresets() []string {
+	names := make([]string, 0, len(keyPresets))
+	for name := range keyPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Action returns the action bound to the key of event.
+func (k *Keymap) Action(event *tcell.EventKey) (Action, bool) {
+	action, ok := k.actions[eventKey(event)]
+	return action, ok
+}
+
+// KeyNames returns the names of the keys bound to action, separated by slashes.
+func (k *Keymap) KeyNames(action Action) string {
+	names := make([]string, 0, len(k.keys[action]))
+	for _, key := range k.keys[action] {
+		names = append(names, key.String())
+	}
+	return strings.Join(names, "/")
+}
+
+// Help lists the bound actions of a live game or of a replay, with their keys and description.
+func (k *Keymap) Help(replay bool) [][2]string {
+	var help [][2]string
+	for _, a := range actions {
+		if len(k.keys[a.action]) == 0 || (a.scope == scopeLive && replay) || (a.scope == scopeReplay && !replay) {
+			continue
+		}
+		help = append(help, [2]string{k.KeyNames(a.action), a.description})
+	}
+	return help
+}
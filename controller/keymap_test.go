@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// TestKeymap contains all the tests related to the key bindings.
+func TestKeymap(t *testing.T) {
+	t.Run("Keymap_Presets", testKeymap_Presets)
+	t.Run("Keymap_Overrides", testKeymap_Overrides)
+	t.Run("Keymap_Conflict", testKeymap_Conflict)
+	t.Run("Keymap_Invalid", testKeymap_Invalid)
+	t.Run("Keymap_ParseKey", testKeymap_ParseKey)
+	t.Run("Keymap_Help", testKeymap_Help)
+}
+
+// testKeymap_Presets tests that every preset is free of conflicts and keeps the arrow keys.
+func testKeymap_Presets(t *testing.T) {
+	for _, preset := range KeyPresets() {
+		keymap, err := NewKeymap(preset, nil)
+		if err != nil {
+			t.Fatalf("%s: expected a keymap, got error: %s", preset, err)
+		}
+		if action, ok := keymap.Action(tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)); !ok || action != ActionMoveNorth {
+			t.Errorf("%s: expected Up to move north, got: %s", preset, action)
+		}
+	}
+
+	wasd, _ := NewKeymap("wasd", nil)
+	if action, _ := wasd.Action(tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone)); action != ActionMoveWest {
+		t.Errorf("Expected a to move west with wasd, got: %s", action)
+	}
+}
+
+// testKeymap_Overrides tests that an override replaces the keys of its action only.
+func testKeymap_Overrides(t *testing.T) {
+	keymap, err := NewKeymap("vim", map[string][]string{"quit": {"q", "Ctrl-Q"}})
+	if err != nil {
+		t.Fatalf("Expected a keymap, got error: %s", err)
+	}
+
+	if action, _ := keymap.Action(tcell.NewEventKey(tcell.KeyRune, 'q', tcell.ModNone)); action != ActionQuit {
+		t.Errorf("Expected q to quit, got: %s", action)
+	}
+	if _, ok := keymap.Action(tcell.NewEventKey(tcell.KeyCtrlC, 0, tcell.ModCtrl)); ok {
+		t.Errorf("Expected Ctrl-C to be unbound")
+	}
+	if keymap.KeyNames(ActionMoveNorth) != "Up/k" {
+		t.Errorf("Expected the vim keys of move_north, got: %s", keymap.KeyNames(ActionMoveNorth))
+	}
+}
+
+// testKeymap_Conflict tests that a key bound to two actions is rejected.
+func testKeymap_Conflict(t *testing.T) {
+	_, err := NewKeymap("wasd", map[string][]string{"toggle_scoreboard": {"s"}})
+	if !errors.Is(err, ErrKeyConflict) {
+		t.Errorf("Expected ErrKeyConflict, got: %v", err)
+	}
+}
+
+// testKeymap_Invalid tests the unknown presets, actions and keys.
+func testKeymap_Invalid(t *testing.T) {
+	if _, err := NewKeymap("emacs", nil); !errors.Is(err, ErrUnknownKeyPreset) {
+		t.Errorf("Expected ErrUnknownKeyPreset, got: %v", err)
+	}
+	if _, err := NewKeymap("", map[string][]string{"jump": {"j"}}); !errors.Is(err, ErrUnknownAction) {
+		t.Errorf("Expected ErrUnknownAction, got: %v", err)
+	}
+	if _, err := NewKeymap("", map[string][]string{"quit": {"Hyper-Q"}}); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey, got: %v", err)
+	}
+}
+
+// testKeymap_ParseKey tests that the key names round trip.
+func testKeymap_ParseKey(t *testing.T) {
+	for _, name := range []string{"k", "?", "Space", "Up", "Tab", "Ctrl-C", "F5"} {
+		key, err := ParseKey(name)
+		if err != nil {
+			t.Fatalf("Expected %q to parse, got error: %s", name, err)
+		}
+		if key.String() != name {
+			t.Errorf("Expected %q, got: %q", name, key.String())
+		}
+	}
+
+	if key, err := ParseKey("ctrl-c"); err != nil || key.Code != tcell.KeyCtrlC {
+		t.Errorf("Expected special key names to be case insensitive, got: %v, %v", key, err)
+	}
+}
+
+// testKeymap_Help tests that the help only lists the actions of the kind of game.
+func testKeymap_Help(t *testing.T) {
+	keymap, _ := NewKeymap("", nil)
+	has := func(help [][2]string, keys string) bool {
+		for _, binding := range help {
+			if binding[0] == keys {
+				return true
+			}
+		}
+		return false
+	}
+
+	live, replay := keymap.Help(false), keymap.Help(true)
+	if !has(live, "Up") || has(live, "Space") || !has(live, "?") {
+		t.Errorf("Expected the moves and the help in a live game, got: %v", live)
+	}
+	if has(replay, "Up") || !has(replay, "Space") || !has(replay, "Ctrl-C") {
+		t.Errorf("Expected the playback controls and quit in a replay, got: %v", replay)
+	}
+}
@@ -1,55 +1,142 @@
 package controller
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/rivo/tview"
+	"github.com/sofc-t/puzzle-client/dmn"
 	"github.com/sofc-t/puzzle-client/service/i"
 )
 
-type matchHandler func([]byte, string)
+// matchHandler joins a found match, the error is shown in the matching room.
+type matchHandler func(*dmn.Match) error
+
+// matchErrorMessages describe the errors of a search for a match.
+var matchErrorMessages = []errorMessage{
+	{i.ErrUnauthorized, "Your session has expired, please log out and log in again."},
+	{i.ErrConflict, "You are already in a match."},
+}
 
 type MatchingRoomPage struct {
-	matchService i.MatchMaker
-	onMatch      matchHandler
+	matchService  i.MatchMaker
+	authService   i.AuthServer
+	playerID      uuid.UUID
+	onMatch       matchHandler
+	onLogout      func()
+	search        *activeSearch // Search is the running search, nil when idle. Only used on the UI goroutine.
+	searchOnEnter bool          // SearchOnEnter starts a search the next time the page is shown.
 }
 
-func NewMatchingRoomPage(ms i.MatchMaker, onMatch matchHandler) (*MatchingRoomPage, error) {
+// activeSearch is a running search, its identity tells a stale result from the one of the current search.
+type activeSearch struct {
+	cancel context.CancelFunc
+}
+
+// NewMatchingRoomPage creates a new MatchingRoomPage searching matches for the player ID. The auth token of every
+// search is asked to as, onMatch is called on the UI goroutine and onLogout once the player logged out.
+func NewMatchingRoomPage(ms i.MatchMaker, as i.AuthServer, ID uuid.UUID, onMatch matchHandler, onLogout func()) (*MatchingRoomPage, error) {
 	return &MatchingRoomPage{
 		matchService: ms,
+		authService:  as,
+		playerID:     ID,
 		onMatch:      onMatch,
+		onLogout:     onLogout,
 	}, nil
 }
 
-func (m *MatchingRoomPage) Start(app *tview.Application, ID uuid.UUID, token string) error {
-	if err := app.SetRoot(m.matchingRoomUI(app, ID, token), true).Run(); err != nil {
-		return err
+// Requeue starts searching for a match right away the next time the page is shown.
+func (m *MatchingRoomPage) Requeue() {
+	m.searchOnEnter = true
+}
+
+// OnEnter implements PageController.
+func (m *MatchingRoomPage) OnEnter(nav *Navigator) tview.Primitive {
+	searchNow := m.searchOnEnter
+	m.searchOnEnter = false
+	return m.matchingRoomUI(nav, searchNow)
+}
+
+// OnLeave implements PageController. A running search is cancelled.
+func (m *MatchingRoomPage) OnLeave() {
+	if m.search != nil {
+		m.search.cancel()
+		m.search = nil
 	}
-	return nil
 }
 
-func (m *MatchingRoomPage) matchingRoomUI(app *tview.Application, ID uuid.UUID, token string) tview.Primitive {
+func (m *MatchingRoomPage) matchingRoomUI(nav *Navigator, searchNow bool) tview.Primitive {
 	header := tview.NewTextView().SetText("Matching Room").SetTextAlign(tview.AlignCenter)
 	footer := tview.NewTextView().SetText("").SetTextAlign(tview.AlignLeft)
 
-	form := tview.NewForm()
-	form.AddButton("Find Match", func() {
+	findMatch := func() {
+		if m.search != nil {
+			return
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		search := &activeSearch{cancel: cancel}
+		m.search = search
 		footer.SetText("Searching for match...")
-		go func(footer *tview.TextView, ID uuid.UUID) {
-			pubKey, addr, err := m.matchService.Match(ID, token)
-			if err != nil {
-				footer.SetText(err.Error())
-				app.Draw()
-				return
+		go func(footer *tview.TextView) {
+			defer cancel()
+			// The token may be refreshed by a request, which must not block the UI goroutine.
+			token, err := m.authService.Token()
+			var match *dmn.Match
+			if err == nil {
+				match, err = m.matchService.Match(ctx, m.playerID, token, func(p dmn.MatchProgress) {
+					nav.QueueUpdateDraw(func() { footer.SetText(progressRepr(p)) })
+				})
 			}
 
-			m.onMatch(pubKey, addr)
-			footer.SetText("Found a match for you!")
-			app.Draw()
-		}(footer, ID)
-	})
+			nav.QueueUpdateDraw(func() {
+				// A search cancelled by leaving the page has nothing left to show.
+				if m.search != search {
+					return
+				}
+				m.search = nil
+				switch {
+				case errors.Is(err, context.Canceled):
+					footer.SetText("Search cancelled.")
+				case err != nil:
+					footer.SetText(friendlyError(err, matchErrorMessages...))
+				default:
+					footer.SetText("Found a match for you!")
+					if err := m.onMatch(match); err != nil {
+						footer.SetText("Could not join the match: " + friendlyError(err))
+					}
+				}
+			})
+		}(footer)
+	}
 
+	form := tview.NewForm()
+	form.AddButton("Find Match", findMatch)
+
+	// Cancel only stops the search, the player is removed from the queue by the match maker.
 	form.AddButton("Cancel", func() {
-		app.Stop()
+		if m.search != nil {
+			footer.SetText("Cancelling...")
+			m.search.cancel()
+		}
+	})
+
+	form.AddButton("Logout", func() {
+		m.OnLeave()
+		footer.SetText("Logging out...")
+		// Revoking the session is a request, which must not block the UI goroutine.
+		go func() {
+			// The session is forgotten even when the server could not revoke it.
+			_ = m.authService.Logout()
+			nav.QueueUpdateDraw(m.onLogout)
+		}()
+	})
+
+	form.AddButton("Quit", func() {
+		nav.Stop()
 	})
 
 	flex := tview.NewFlex().SetDirection(tview.FlexRow).
@@ -57,5 +144,21 @@ func (m *MatchingRoomPage) matchingRoomUI(app *tview.Application, ID uuid.UUID,
 		AddItem(form, 0, 1, true).
 		AddItem(footer, 0, 1, false)
 
+	if searchNow {
+		findMatch()
+	}
+
 	return flex
 }
+
+// progressRepr describes a pending search, leaving out what the server did not report.
+func progressRepr(p dmn.MatchProgress) string {
+	text := fmt.Sprintf("Searching for match... %s", p.Elapsed.Round(time.Second))
+	if p.QueuePosition > 0 {
+		text += fmt.Sprintf(" | position %d in queue", p.QueuePosition)
+	}
+	if p.EstimatedWait > 0 {
+		text += fmt.Sprintf(" | about %s left", p.EstimatedWait.Round(time.Second))
+	}
+	return text
+}
@@ -0,0 +1,262 @@
+package controller
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/tview"
+)
+
+const (
+	cameraEasing     = 0.35                  // CameraEasing is the part of the distance to its target the camera moves every frame.
+	cameraFrameDelay = 30 * time.Millisecond // CameraFrameDelay is the delay between two frames of a camera move.
+)
+
+type tileKind uint8
+
+const (
+	tileFloor tileKind = iota
+	tileFog            // The fog covers the unexplored tiles in the fog-of-war mode.
+	tileWall
+	tileReward
+	tileOpponent
+	tileSelf // The local player is drawn over the other tiles in the compact view.
+)
+
+// tile is a square of the maze grid, walls included. Value is the value of a reward or the number of an opponent.
+type tile struct {
+	kind  tileKind
+	value int32
+}
+
+// MazeView draws the maze grid to the screen, scrolled to keep the local player in the middle.
+// A full view draws every tile two columns wide, a compact one draws two tiles high in a single cell.
+// It is safe to set the tiles from any goroutine.
+type MazeView struct {
+	*tview.Box
+	mu             sync.Mutex
+	tiles          [][]tile
+	focusX, focusY int  // FocusX and FocusY are the position of the tile followed by the camera.
+	hasFocus       bool // HasFocus is unset without a local player, the maze is then centered.
+	theme          *Theme
+	compact        bool
+	camX, camY     float64 // CamX and CamY are the position of the top left tile of the view, moving to their target.
+	viewW, viewH   int     // ViewW and ViewH are the size of the last view in tiles, a new size snaps the camera.
+	animating      bool
+	redraw         func()
+}
+
+// NewMazeView creates a MazeView drawn with theme.
+func NewMazeView(theme *Theme) *MazeView {
+	return &MazeView{
+		Box:   tview.NewBox(),
+		theme: theme,
+	}
+}
+
+// SetRedraw sets the function asking for a new frame while the camera moves, usually tview.Application.Draw.
+// It is called from another goroutine. Without it, the camera only moves when the screen is drawn.
+func (v *MazeView) SetRedraw(redraw func()) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.redraw = redraw
+}
+
+// SetTiles replaces the tiles of the maze, the camera following the tile at focusX, focusY if hasFocus is set.
+func (v *MazeView) SetTiles(tiles [][]tile, focusX, focusY int, hasFocus bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.tiles = tiles
+	v.focusX, v.focusY, v.hasFocus = focusX, focusY, hasFocus
+}
+
+// SetTheme sets the theme the tiles are drawn with.
+func (v *MazeView) SetTheme(theme *Theme) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.theme = theme
+}
+
+// ToggleCompact switches between the full and the compact view.
+func (v *MazeView) ToggleCompact() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.compact = !v.compact
+}
+
+// Draw implements tview.Primitive.
+func (v *MazeView) Draw(screen tcell.Screen) {
+	v.Box.DrawForSubclass(screen, v)
+	x, y, width, height := v.GetInnerRect()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if len(v.tiles) == 0 {
+		tview.Print(screen, "loading...", x, y, width, tview.AlignLeft, tcell.ColorDefault)
+		return
+	}
+
+	viewW, viewH := width/2, height
+	if v.compact {
+		viewW, viewH = width, height*2
+	}
+	v.moveCamera(viewW, viewH)
+
+	camX, camY := int(math.Round(v.camX)), int(math.Round(v.camY))
+	if v.compact {
+		v.drawCompact(screen, x, y, width, height, camX, camY)
+	} else {
+		v.drawFull(screen, x, y, viewW, viewH, camX, camY)
+	}
+}
+
+// moveCamera moves the camera a frame closer to the target of a view of viewW x viewH tiles.
+// A resized view snaps the camera to its target, and a moving camera asks for the next frame.
+func (v *MazeView) moveCamera(viewW, viewH int) {
+	targetX := cameraTarget(v.focusX, len(v.tiles[0]), viewW, v.hasFocus)
+	targetY := cameraTarget(v.focusY, len(v.tiles), viewH, v.hasFocus)
+
+	if viewW != v.viewW || viewH != v.viewH {
+		v.viewW, v.viewH = viewW, viewH
+		v.camX, v.camY = targetX, targetY
+	}
+	v.camX = ease(v.camX, targetX)
+	v.camY = ease(v.camY, targetY)
+
+	moving := v.camX != targetX || v.camY != targetY
+	if moving && !v.animating && v.redraw != nil {
+		v.animating = true
+		redraw := v.redraw
+		time.AfterFunc(cameraFrameDelay, func() {
+			v.mu.Lock()
+			v.animating = false
+			v.mu.Unlock()
+			redraw()
+		})
+	}
+}
+
+// cameraTarget returns the first tile shown on an axis of size tiles, for a view of view tiles centered on focus.
+// The view stays within the maze, and a maze smaller than the view is centered.
+func cameraTarget(focus, size, view int, hasFocus bool) float64 {
+	if size <= view {
+		return -float64((view - size) / 2)
+	}
+	if !hasFocus {
+		focus = size / 2
+	}
+	return float64(min(max(focus-view/2, 0), size-view))
+}
+
+// ease moves pos toward target by cameraEasing of the distance, reaching it once closer than half a tile.
+func ease(pos, target float64) float64 {
+	pos += (target - pos) * cameraEasing
+	if math.Abs(target-pos) < 0.5 {
+		return target
+	}
+	return pos
+}
+
+// tileAt returns the tile at col, row, floor outside of the maze.
+func (v *MazeView) tileAt(col, row int) tile {
+	if row < 0 || row >= len(v.tiles) || col < 0 || col >= len(v.tiles[row]) {
+		return tile{kind: tileFloor}
+	}
+	return v.tiles[row][col]
+}
+
+// tileCell returns the look of a tile with the theme.
+func (t *Theme) tileCell(tl tile) cell {
+	switch tl.kind {
+	case tileWall:
+		return t.wall()
+	case tileFog:
+		return t.fog()
+	case tileReward:
+		return t.reward(tl.value)
+	case tileSelf:
+		return t.self()
+	case tileOpponent:
+		return t.opponent(int(tl.value))
+	}
+	return t.floor()
+}
+
+// drawFull draws viewW x viewH tiles from camX, camY, two columns per tile.
+func (v *MazeView) drawFull(screen tcell.Screen, x, y, viewW, viewH, camX, camY int) {
+	for row := 0; row < viewH; row++ {
+		for col := 0; col < viewW; col++ {
+			c := v.theme.tileCell(v.tileAt(camX+col, camY+row))
+			style := c.style()
+			sx := x + col*2
+			for _, r := range c.text {
+				screen.SetContent(sx, y+row, r, nil, style)
+				sx += runewidth.RuneWidth(r)
+			}
+		}
+	}
+}
+
+// drawCompact draws a tile per column and two tiles per row from camX, camY.
+func (v *MazeView) drawCompact(screen tcell.Screen, x, y, width, height, camX, camY int) {
+	drawHalfBlocks(screen, v.theme, x, y, width, height, func(col, row int) tile {
+		return v.tileAt(camX+col, camY+row)
+	})
+}
+
+// drawHalfBlocks draws width x height cells, two stacked tiles each, at returning the tile at a column and row.
+// Walls are drawn as half blocks, players and rewards as a glyph and the fog where it covers both tiles.
+func drawHalfBlocks(screen tcell.Screen, theme *Theme, x, y, width, height int, at func(col, row int) tile) {
+	floor := theme.floor().style()
+	wall := floor.Foreground(themeColor(theme.WallColor))
+	fog := theme.fog().style()
+
+	for row := 0; row < height; row++ {
+		for col := 0; col < width; col++ {
+			top, bottom := at(col, row*2), at(col, row*2+1)
+			r, style := compactGlyph(top, bottom), wall
+			switch {
+			case top.kind >= tileReward || bottom.kind >= tileReward:
+				// Players and rewards stand out over the walls, the top tile first.
+				t := top
+				if bottom.kind > top.kind {
+					t = bottom
+				}
+				r, style = compactMarker(t), theme.tileCell(t).style()
+			case top.kind == tileFog && bottom.kind == tileFog:
+				r, style = '░', fog
+			case r == ' ':
+				style = floor
+			}
+			screen.SetContent(x+col, y+row, r, nil, style)
+		}
+	}
+}
+
+// compactGlyph returns the half block drawing the walls of two stacked tiles.
+func compactGlyph(top, bottom tile) rune {
+	switch {
+	case top.kind == tileWall && bottom.kind == tileWall:
+		return '█'
+	case top.kind == tileWall:
+		return '▀'
+	case bottom.kind == tileWall:
+		return '▄'
+	}
+	return ' '
+}
+
+// compactMarker returns the single character standing for a player or a reward in the compact view.
+func compactMarker(t tile) rune {
+	switch t.kind {
+	case tileSelf:
+		return '@'
+	case tileOpponent:
+		return rune('1' + t.value%9)
+	}
+	return '•'
+}
@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// TestMazeView contains all the tests related to the maze viewport.
+func TestMazeView(t *testing.T) {
+	t.Run("MazeView_Follow", testMazeView_Follow)
+	t.Run("MazeView_Clamp", testMazeView_Clamp)
+	t.Run("MazeView_SmallMaze", testMazeView_SmallMaze)
+	t.Run("MazeView_Smooth", testMazeView_Smooth)
+	t.Run("MazeView_Resize", testMazeView_Resize)
+	t.Run("MazeView_Compact", testMazeView_Compact)
+}
+
+// testMazeView_Follow tests that the local player is drawn in the middle of a maze larger than the view.
+func testMazeView_Follow(t *testing.T) {
+	view, screen := newTestMazeView(t, 20, 10)
+	view.SetTiles(testTiles(41, 41, 30, 30), 30, 30, true)
+	view.Draw(screen)
+
+	// The view is 10 tiles wide, two columns each, and 10 tiles high.
+	assertRune(t, screen, 10, 5, '@')
+}
+
+// testMazeView_Clamp tests that the camera stays within the maze when the player is near a border.
+func testMazeView_Clamp(t *testing.T) {
+	view, screen := newTestMazeView(t, 20, 10)
+	view.SetTiles(testTiles(41, 41, 1, 1), 1, 1, true)
+	view.Draw(screen)
+
+	assertRune(t, screen, 0, 0, '█')
+	assertRune(t, screen, 2, 1, '@')
+}
+
+// testMazeView_SmallMaze tests that a maze smaller than the view is centered.
+func testMazeView_SmallMaze(t *testing.T) {
+	view, screen := newTestMazeView(t, 20, 10)
+	view.SetTiles(testTiles(3, 3, 1, 1), 1, 1, true)
+	view.Draw(screen)
+
+	// 3 of 10 tiles leave 3 tiles before the maze on both axes.
+	assertRune(t, screen, 6, 3, '█')
+	assertRune(t, screen, 8, 4, '@')
+	assertRune(t, screen, 4, 3, ' ')
+}
+
+// testMazeView_Smooth tests that the camera moves toward a new position over several frames.
+func testMazeView_Smooth(t *testing.T) {
+	view, screen := newTestMazeView(t, 20, 10)
+	view.SetTiles(testTiles(41, 41, 10, 10), 10, 10, true)
+	view.Draw(screen)
+
+	view.SetTiles(testTiles(41, 41, 30, 10), 30, 10, true)
+	view.Draw(screen)
+	if view.camX <= 5 || view.camX >= 25 {
+		t.Fatalf("Expected the camera between its positions after a frame, got: %g", view.camX)
+	}
+
+	for n := 0; n < 20; n++ {
+		view.Draw(screen)
+	}
+	if view.camX != 25 {
+		t.Fatalf("Expected the camera to reach its target, got: %g", view.camX)
+	}
+	assertRune(t, screen, 10, 5, '@')
+}
+
+// testMazeView_Resize tests that a resized view snaps the camera to the player.
+func testMazeView_Resize(t *testing.T) {
+	view, screen := newTestMazeView(t, 20, 10)
+	view.SetTiles(testTiles(41, 41, 10, 10), 10, 10, true)
+	view.Draw(screen)
+
+	view.SetTiles(testTiles(41, 41, 30, 30), 30, 30, true)
+	view.SetRect(0, 0, 30, 16)
+	view.Draw(screen)
+
+	// The 15x16 tiles view is centered on the player in a single frame.
+	assertRune(t, screen, 14, 8, '@')
+}
+
+// testMazeView_Compact tests that the compact view draws two tiles per cell.
+func testMazeView_Compact(t *testing.T) {
+	view, screen := newTestMazeView(t, 20, 10)
+	view.SetTiles(testTiles(5, 5, 1, 1), 1, 1, true)
+	view.ToggleCompact()
+	view.Draw(screen)
+
+	// The 5x5 tiles are centered in a 20x20 tiles view: 7 columns and 7 rows, 3 cells and a half, before them.
+	assertRune(t, screen, 7, 3, '▄') // Floor over the top wall.
+	assertRune(t, screen, 8, 4, '@') // The player over a floor tile.
+	assertRune(t, screen, 7, 5, '█') // The wall below the player's row and the west wall.
+	assertRune(t, screen, 6, 4, ' ')
+}
+
+// newTestMazeView returns a view of width x height cells drawn with the monochrome theme on a simulation screen.
+func newTestMazeView(t *testing.T, width, height int) (*MazeView, tcell.SimulationScreen) {
+	t.Helper()
+	theme, err := ThemeByName("monochrome")
+	if err != nil {
+		t.Fatalf("Expected the monochrome theme, got error: %s", err)
+	}
+
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("Expected a screen, got error: %s", err)
+	}
+	t.Cleanup(screen.Fini)
+	screen.SetSize(40, 20)
+
+	view := NewMazeView(theme)
+	view.SetRect(0, 0, width, height)
+	return view, screen
+}
+
+// testTiles returns a maze of width x height tiles with a wall border and the local player at x, y.
+func testTiles(width, height, x, y int) [][]tile {
+	tiles := make([][]tile, height)
+	for row := range tiles {
+		tiles[row] = make([]tile, width)
+		for col := range tiles[row] {
+			if row == 0 || col == 0 || row == height-1 || col == width-1 {
+				tiles[row][col] = tile{kind: tileWall}
+			}
+		}
+	}
+	tiles[y][x] = tile{kind: tileSelf}
+	return tiles
+}
+
+func assertRune(t *testing.T, screen tcell.SimulationScreen, x, y int, expected rune) {
+	t.Helper()
+	r, _, _, _ := screen.GetContent(x, y)
+	if r != expected {
+		t.Errorf("Expected %q at (%d, %d), got: %q", expected, x, y, r)
+	}
+}
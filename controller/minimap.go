@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Minimap draws the whole maze scaled down to fit its box, two tiles high per cell, with a dot for every player.
+// Rewards are left out. It is safe to set the tiles from any goroutine.
+type Minimap struct {
+	*tview.Box
+	mu    sync.Mutex
+	tiles [][]tile
+	theme *Theme
+}
+
+// NewMinimap creates a Minimap drawn with theme.
+func NewMinimap(theme *Theme) *Minimap {
+	return &Minimap{
+		Box:   tview.NewBox(),
+		theme: theme,
+	}
+}
+
+// SetTiles replaces the tiles of the maze.
+func (m *Minimap) SetTiles(tiles [][]tile) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tiles = tiles
+}
+
+// SetTheme sets the theme the tiles are drawn with.
+func (m *Minimap) SetTheme(theme *Theme) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.theme = theme
+}
+
+// Draw implements tview.Primitive.
+func (m *Minimap) Draw(screen tcell.Screen) {
+	m.Box.DrawForSubclass(screen, m)
+	x, y, width, height := m.GetInnerRect()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.tiles) == 0 || width <= 0 || height <= 0 {
+		return
+	}
+
+	// Every cell is a block of scale x scale tiles on top of another, the map is centered in the box.
+	tilesW, tilesH := len(m.tiles[0]), len(m.tiles)
+	scale := max(ceilDiv(tilesW, width), ceilDiv(tilesH, height*2), 1)
+	mapW, mapH := ceilDiv(tilesW, scale), ceilDiv(ceilDiv(tilesH, scale), 2)
+	x += (width - mapW) / 2
+	y += (height - mapH) / 2
+
+	drawHalfBlocks(screen, m.theme, x, y, mapW, mapH, func(col, row int) tile {
+		return m.block(col*scale, row*scale, scale)
+	})
+}
+
+// block returns the tile standing for the scale x scale tiles from x, y: the player with the highest kind,
+// or a wall or fog covering most of the block.
+func (m *Minimap) block(x, y, scale int) tile {
+	var player tile
+	walls, fog, count := 0, 0, 0
+	for row := y; row < min(y+scale, len(m.tiles)); row++ {
+		for col := x; col < min(x+scale, len(m.tiles[row])); col++ {
+			t := m.tiles[row][col]
+			switch t.kind {
+			case tileSelf, tileOpponent:
+				if t.kind > player.kind {
+					player = t
+				}
+			case tileWall:
+				walls++
+			case tileFog:
+				fog++
+			}
+			count++
+		}
+	}
+
+	switch {
+	case player.kind != tileFloor:
+		return player
+	case count > 0 && walls*2 > count:
+		return tile{kind: tileWall}
+	case count > 0 && fog*2 > count:
+		return tile{kind: tileFog}
+	}
+	return tile{kind: tileFloor}
+}
+
+// ceilDiv returns a / b rounded up, for positive numbers.
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
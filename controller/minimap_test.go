@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"testing"
+)
+
+// TestMinimap contains all the tests related to the minimap.
+func TestMinimap(t *testing.T) {
+	t.Run("Minimap_Fit", testMinimap_Fit)
+	t.Run("Minimap_Block", testMinimap_Block)
+}
+
+// testMinimap_Fit tests that a maze larger than the box is scaled down to fit, keeping the player's dot.
+func testMinimap_Fit(t *testing.T) {
+	theme, _ := ThemeByName("monochrome")
+	_, screen := newTestMazeView(t, 1, 1)
+	minimap := NewMinimap(theme)
+	minimap.SetRect(0, 0, 10, 5)
+	minimap.SetTiles(testTiles(41, 41, 39, 39))
+	minimap.Draw(screen)
+
+	// 41 tiles shown 5 per column and 10 per row make a 9 x 5 map. The last row and column are only the border.
+	assertRune(t, screen, 7, 3, '@')
+	assertRune(t, screen, 8, 4, '▀')
+	assertRune(t, screen, 8, 2, '█')
+	assertRune(t, screen, 9, 2, ' ')
+}
+
+// testMinimap_Block tests that a block shows a player over the walls, and the walls or fog covering most of it.
+func testMinimap_Block(t *testing.T) {
+	theme, _ := ThemeByName("monochrome")
+	minimap := NewMinimap(theme)
+	tiles := testTiles(4, 4, 1, 1)
+	tiles[3][3] = tile{kind: tileOpponent}
+	tiles[2][2] = tile{kind: tileFog}
+	minimap.SetTiles(tiles)
+
+	tests := []struct {
+		x, y, scale int
+		expected    tileKind
+	}{
+		{0, 0, 2, tileSelf},
+		{2, 2, 2, tileOpponent},
+		{0, 0, 1, tileWall},
+		{2, 2, 1, tileFog},
+		{1, 2, 1, tileFloor},
+	}
+	for _, tt := range tests {
+		if got := minimap.block(tt.x, tt.y, tt.scale).kind; got != tt.expected {
+			t.Errorf("Expected the block at (%d, %d) of scale %d to be %d, got: %d", tt.x, tt.y, tt.scale, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"sync"
+
+	"github.com/rivo/tview"
+)
+
+// Navigator shows a stack of pages in a single app event loop, the top page being the one shown.
+// Push, Pop, Replace and Reset must be called on the UI goroutine, from an event handler or QueueUpdateDraw.
+type Navigator struct {
+	app     *tview.Application
+	stack   []PageController
+	posted  []func() // Posted are the updates waiting for the UI goroutine, in the order they were posted.
+	posting bool     // Posting is set while a goroutine hands the posted updates to the UI goroutine.
+	mu      sync.Mutex
+}
+
+// NewNavigator creates a Navigator showing its pages in app.
+func NewNavigator(app *tview.Application) *Navigator {
+	return &Navigator{app: app}
+}
+
+// Run runs the app on the pages pushed before until it is stopped, then leaves the page on top.
+func (n *Navigator) Run() error {
+	err := n.app.Run()
+	n.leave()
+	n.stack = nil
+	return err
+}
+
+// Push shows page on top of the current one.
+func (n *Navigator) Push(page PageController) {
+	n.leave()
+	n.stack = append(n.stack, page)
+	n.enter()
+}
+
+// Pop removes the page on top and shows the one below it. Popping the last page stops the app.
+func (n *Navigator) Pop() {
+	if len(n.stack) == 0 {
+		return
+	}
+
+	n.leave()
+	n.stack = n.stack[:len(n.stack)-1]
+	if len(n.stack) == 0 {
+		n.app.Stop()
+		return
+	}
+	n.enter()
+}
+
+// Replace shows page in place of the one on top, or pushes it on an empty stack.
+func (n *Navigator) Replace(page PageController) {
+	n.leave()
+	if len(n.stack) > 0 {
+		n.stack = n.stack[:len(n.stack)-1]
+	}
+	n.stack = append(n.stack, page)
+	n.enter()
+}
+
+// Reset removes every page and shows page alone.
+func (n *Navigator) Reset(page PageController) {
+	n.leave()
+	n.stack = []PageController{page}
+	n.enter()
+}
+
+// Top returns the page shown, nil before Run.
+func (n *Navigator) Top() PageController {
+	if len(n.stack) == 0 {
+		return nil
+	}
+	return n.stack[len(n.stack)-1]
+}
+
+// Depth returns the number of pages of the stack.
+func (n *Navigator) Depth() int {
+	return len(n.stack)
+}
+
+// Stop stops the app, Run returns once the page on top is left.
+func (n *Navigator) Stop() {
+	n.app.Stop()
+}
+
+// QueueUpdateDraw runs f on the UI goroutine and redraws the screen. It waits for f to run, so it must not be
+// called from the UI goroutine: Post hands f over without waiting.
+func (n *Navigator) QueueUpdateDraw(f func()) {
+	n.app.QueueUpdateDraw(f)
+}
+
+// Post runs f on the UI goroutine and redraws the screen, in the order of the posts. It does not wait for f to run,
+// so it is safe to call from any goroutine, the UI goroutine included.
+func (n *Navigator) Post(f func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.posted = append(n.posted, f)
+	if !n.posting {
+		n.posting = true
+		go n.deliver()
+	}
+}
+
+// deliver hands the posted updates to the UI goroutine until none is left, those posted meanwhile being run together.
+func (n *Navigator) deliver() {
+	for {
+		n.mu.Lock()
+		updates := n.posted
+		n.posted = nil
+		if len(updates) == 0 {
+			n.posting = false
+			n.mu.Unlock()
+			return
+		}
+		n.mu.Unlock()
+
+		n.app.QueueUpdateDraw(func() {
+			for _, f := range updates {
+				f()
+			}
+		})
+	}
+}
+
+// Draw redraws the screen. Like QueueUpdateDraw, it must not be called from the UI goroutine.
+func (n *Navigator) Draw() {
+	n.app.Draw()
+}
+
+// enter shows the page on top and routes the keys to it.
+func (n *Navigator) enter() {
+	page := n.Top()
+	n.app.SetRoot(page.OnEnter(n), true)
+
+	if handler, ok := page.(InputHandler); ok {
+		n.app.SetInputCapture(handler.HandleInput)
+	} else {
+		n.app.SetInputCapture(nil)
+	}
+}
+
+// leave calls the OnLeave hook of the page on top, if any.
+func (n *Navigator) leave() {
+	if page := n.Top(); page != nil {
+		n.app.SetInputCapture(nil)
+		page.OnLeave()
+	}
+}
@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// fakePage records its lifecycle hooks in a shared log.
+type fakePage struct {
+	name string
+	log  *[]string
+	box  *tview.Box
+}
+
+func newFakePage(name string, log *[]string) *fakePage {
+	return &fakePage{name: name, log: log, box: tview.NewBox()}
+}
+
+func (p *fakePage) OnEnter(*Navigator) tview.Primitive {
+	*p.log = append(*p.log, "enter "+p.name)
+	return p.box
+}
+
+func (p *fakePage) OnLeave() {
+	*p.log = append(*p.log, "leave "+p.name)
+}
+
+// inputPage is a fakePage handling the keys.
+type inputPage struct {
+	*fakePage
+}
+
+func (p *inputPage) HandleInput(event *tcell.EventKey) *tcell.EventKey {
+	return event
+}
+
+// TestNavigator contains all the tests related to the page navigator.
+func TestNavigator(t *testing.T) {
+	t.Run("Navigator_PushPop", testNavigator_PushPop)
+	t.Run("Navigator_Replace", testNavigator_Replace)
+	t.Run("Navigator_Reset", testNavigator_Reset)
+	t.Run("Navigator_InputCapture", testNavigator_InputCapture)
+	t.Run("Navigator_Run", testNavigator_Run)
+}
+
+// testNavigator_PushPop tests that the page on top is shown and its hooks are called in order.
+func testNavigator_PushPop(t *testing.T) {
+	var log []string
+	app := tview.NewApplication()
+	nav := NewNavigator(app)
+	first, second := newFakePage("first", &log), newFakePage("second", &log)
+
+	nav.Push(first)
+	nav.Push(second)
+	if nav.Top() != second || nav.Depth() != 2 || app.GetFocus() != second.box {
+		t.Fatalf("Expected the second page on top and focused")
+	}
+
+	nav.Pop()
+	if nav.Top() != first || app.GetFocus() != first.box {
+		t.Fatalf("Expected the first page back on top")
+	}
+
+	expected := []string{"enter first", "leave first", "enter second", "leave second", "enter first"}
+	if !reflect.DeepEqual(log, expected) {
+		t.Errorf("Expected hooks %v, got: %v", expected, log)
+	}
+}
+
+// testNavigator_Replace tests that the page on top is swapped without touching the ones below.
+func testNavigator_Replace(t *testing.T) {
+	var log []string
+	nav := NewNavigator(tview.NewApplication())
+	first, second, third := newFakePage("first", &log), newFakePage("second", &log), newFakePage("third", &log)
+
+	nav.Replace(first)
+	nav.Push(second)
+	nav.Replace(third)
+	if nav.Top() != third || nav.Depth() != 2 {
+		t.Fatalf("Expected the third page over the first one, got depth %d", nav.Depth())
+	}
+
+	nav.Pop()
+	if nav.Top() != first {
+		t.Errorf("Expected the first page back on top")
+	}
+}
+
+// testNavigator_Reset tests that resetting leaves the page on top only and keeps the new page alone.
+func testNavigator_Reset(t *testing.T) {
+	var log []string
+	nav := NewNavigator(tview.NewApplication())
+	nav.Push(newFakePage("first", &log))
+	nav.Push(newFakePage("second", &log))
+
+	log = nil
+	home := newFakePage("home", &log)
+	nav.Reset(home)
+	if nav.Top() != home || nav.Depth() != 1 {
+		t.Fatalf("Expected the home page alone, got depth %d", nav.Depth())
+	}
+
+	expected := []string{"leave second", "enter home"}
+	if !reflect.DeepEqual(log, expected) {
+		t.Errorf("Expected hooks %v, got: %v", expected, log)
+	}
+}
+
+// testNavigator_InputCapture tests that the keys are only captured while an InputHandler is on top.
+func testNavigator_InputCapture(t *testing.T) {
+	var log []string
+	app := tview.NewApplication()
+	nav := NewNavigator(app)
+
+	nav.Push(newFakePage("form", &log))
+	if app.GetInputCapture() != nil {
+		t.Fatalf("Expected no input capture for a page without handler")
+	}
+
+	nav.Push(&inputPage{newFakePage("game", &log)})
+	if app.GetInputCapture() == nil {
+		t.Fatalf("Expected the input capture of the page on top")
+	}
+
+	nav.Pop()
+	if app.GetInputCapture() != nil {
+		t.Errorf("Expected the input capture to be removed with its page")
+	}
+}
+
+// testNavigator_Run tests that popping the last page stops the single event loop and leaves the page.
+func testNavigator_Run(t *testing.T) {
+	var log []string
+	screen := tcell.NewSimulationScreen("")
+	app := tview.NewApplication().SetScreen(screen)
+	nav := NewNavigator(app)
+	nav.Push(newFakePage("only", &log))
+
+	done := make(chan error)
+	go func() { done <- nav.Run() }()
+	nav.QueueUpdateDraw(func() { nav.Pop() })
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Expected the app to stop, got error: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected popping the last page to stop the app")
+	}
+
+	expected := []string{"enter only", "leave only"}
+	if !reflect.DeepEqual(log, expected) || nav.Depth() != 0 {
+		t.Errorf("Expected hooks %v and an empty stack, got: %v", expected, log)
+	}
+}
@@ -1,7 +1,19 @@
 package controller
 
-import "github.com/rivo/tview"
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
 
+// PageController is a page shown by the Navigator. Its hooks are called on the UI goroutine.
 type PageController interface {
-	Start(*tview.Application) error
+	// OnEnter is called when the page becomes the top of the stack and returns the primitive shown.
+	OnEnter(nav *Navigator) tview.Primitive
+	// OnLeave is called when the page stops being the top of the stack, covered or removed.
+	OnLeave()
+}
+
+// InputHandler is implemented by the pages handling the keys before their primitives, see tview.Application.SetInputCapture.
+type InputHandler interface {
+	HandleInput(event *tcell.EventKey) *tcell.EventKey
 }
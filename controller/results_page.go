@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/google/uuid"
+	"github.com/rivo/tview"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// ResultsPage shows the final standings of a match once the game ended.
+type ResultsPage struct {
+	playerID  uuid.UUID
+	state     i.GameState
+	onRequeue func()
+	onLobby   func()
+}
+
+// NewResultsPage creates a new ResultsPage of the final game state gs. onRequeue searches for a new match
+// and onLobby returns to the matching room.
+func NewResultsPage(pID uuid.UUID, gs i.GameState, onRequeue, onLobby func()) (*ResultsPage, error) {
+	return &ResultsPage{
+		playerID:  pID,
+		state:     gs,
+		onRequeue: onRequeue,
+		onLobby:   onLobby,
+	}, nil
+}
+
+// OnEnter implements PageController.
+func (r *ResultsPage) OnEnter(nav *Navigator) tview.Primitive {
+	return r.resultsUI(nav, r.state)
+}
+
+// OnLeave implements PageController.
+func (r *ResultsPage) OnLeave() {}
+
+func (r *ResultsPage) resultsUI(nav *Navigator, gs i.GameState) tview.Primitive {
+	players := gs.RetrivePlayers()
+	ranks := rankPlayers(players)
+
+	header := tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignCenter)
+	header.SetText(fmt.Sprintf("[yellow]Match Results\n\n[white]%s  •  Duration: %s", r.placement(players, ranks), matchDuration(gs)))
+
+	standings := tview.NewTable().SetBorders(false)
+	for col, title := range []string{"Rank", "Player", "Rewards", "Rating"} {
+		standings.SetCell(0, col, tview.NewTableCell(title).SetTextColor(tcell.ColorYellow).SetAlign(tview.AlignCenter).SetExpansion(1))
+	}
+
+	for row, player := range players {
+		name := "Player " + player.GetID().String()[:8]
+		color := tcell.ColorWhite
+		if player.GetID() == r.playerID {
+			name = "You"
+			color = tcell.ColorAqua
+		}
+
+		standings.SetCell(row+1, 0, tview.NewTableCell(fmt.Sprintf("#%d", ranks[row])).SetTextColor(color).SetAlign(tview.AlignCenter))
+		standings.SetCell(row+1, 1, tview.NewTableCell(name).SetTextColor(color).SetAlign(tview.AlignLeft))
+		standings.SetCell(row+1, 2, tview.NewTableCell(fmt.Sprintf("%d", player.GetReward())).SetTextColor(tcell.ColorGreen).SetAlign(tview.AlignRight))
+		standings.SetCell(row+1, 3, ratingChangeCell(player.GetRatingChange()))
+	}
+
+	form := tview.NewForm()
+	form.AddButton("Play Again", func() {
+		r.onRequeue()
+	})
+
+	form.AddButton("Lobby", func() {
+		r.onLobby()
+	})
+
+	form.AddButton("Quit", func() {
+		nav.Stop()
+	})
+
+	flex := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(header, 4, 1, false).
+		AddItem(standings, 0, 1, false).
+		AddItem(form, 3, 1, true)
+
+	return flex
+}
+
+// rankPlayers sorts the players by reward, keeping the order of the server between tied players,
+// and returns their ranks. Tied players share the same rank.
+func rankPlayers(players []i.Player) []int {
+	sort.SliceStable(players, func(i, j int) bool {
+		return players[i].GetReward() > players[j].GetReward()
+	})
+
+	ranks := make([]int, len(players))
+	for n, player := range players {
+		ranks[n] = n + 1
+		if n > 0 && player.GetReward() == players[n-1].GetReward() {
+			ranks[n] = ranks[n-1]
+		}
+	}
+	return ranks
+}
+
+// placement describes the rank of the local player, players and ranks being sorted by rankPlayers.
+func (r *ResultsPage) placement(players []i.Player, ranks []int) string {
+	for n, player := range players {
+		if player.GetID() != r.playerID {
+			continue
+		}
+
+		tied := (n > 0 && ranks[n-1] == ranks[n]) || (n+1 < len(ranks) && ranks[n+1] == ranks[n])
+		switch {
+		case ranks[n] == 1 && tied:
+			return "[green]You tied for first![white]"
+		case ranks[n] == 1:
+			return "[green]You won![white]"
+		default:
+			return fmt.Sprintf("You placed #%d of %d", ranks[n], len(players))
+		}
+	}
+	return "Game over"
+}
+
+// ratingChangeCell renders a signed, colored rating change.
+func ratingChangeCell(change int32) *tview.TableCell {
+	color := tcell.ColorWhite
+	if change > 0 {
+		color = tcell.ColorGreen
+	} else if change < 0 {
+		color = tcell.ColorRed
+	}
+	return tview.NewTableCell(fmt.Sprintf("%+d", change)).SetTextColor(color).SetAlign(tview.AlignRight)
+}
+
+// matchDuration formats the time between the start and the end of the match, in milliseconds timestamps.
+func matchDuration(gs i.GameState) string {
+	if gs.GetStartedAt() == 0 || gs.GetEndedAt() < gs.GetStartedAt() {
+		return "unknown"
+	}
+	return (time.Duration(gs.GetEndedAt()-gs.GetStartedAt()) * time.Millisecond).Round(time.Second).String()
+}
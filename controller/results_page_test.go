@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	gamepb "github.com/sofc-t/puzzle-client/infrastruture/pb_encoder/game"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// TestResultsPage contains all the tests related to the final standings.
+func TestResultsPage(t *testing.T) {
+	t.Run("ResultsPage_Placement", testResultsPage_Placement)
+	t.Run("ResultsPage_MatchDuration", testResultsPage_MatchDuration)
+}
+
+// rewardPlayers returns players with the rewards, in order, and their IDs.
+func rewardPlayers(rewards ...int32) ([]i.Player, []uuid.UUID) {
+	encoder := &gamepb.Protobuf{}
+	players := make([]i.Player, len(rewards))
+	ids := make([]uuid.UUID, len(rewards))
+	for n, reward := range rewards {
+		ids[n] = uuid.New()
+		players[n] = encoder.NewPlayer()
+		players[n].SetID(ids[n])
+		players[n].SetReward(reward)
+	}
+	return players, ids
+}
+
+// testResultsPage_Placement tests that the players are ranked by reward, tied players sharing their rank
+// and keeping the order of the server.
+func testResultsPage_Placement(t *testing.T) {
+	tests := []struct {
+		name      string
+		rewards   []int32
+		local     int // Local is the index of the local player in rewards, -1 for a spectator.
+		order     []int
+		ranks     []int
+		placement string
+	}{
+		{"Won", []int32{3, 7, 5}, 1, []int{1, 2, 0}, []int{1, 2, 3}, "[green]You won![white]"},
+		{"Placed", []int32{3, 7, 5}, 0, []int{1, 2, 0}, []int{1, 2, 3}, "You placed #3 of 3"},
+		{"TiedFirst", []int32{7, 2, 7}, 2, []int{0, 2, 1}, []int{1, 1, 3}, "[green]You tied for first![white]"},
+		{"TiedSecond", []int32{4, 9, 4, 1}, 2, []int{1, 0, 2, 3}, []int{1, 2, 2, 4}, "You placed #2 of 4"},
+		{"AllTied", []int32{0, 0}, 1, []int{0, 1}, []int{1, 1}, "[green]You tied for first![white]"},
+		{"Alone", []int32{0}, 0, []int{0}, []int{1}, "[green]You won![white]"},
+		{"Spectator", []int32{1, 2}, -1, []int{1, 0}, []int{1, 2}, "Game over"},
+	}
+
+	for _, test := range tests {
+		players, ids := rewardPlayers(test.rewards...)
+		page := &ResultsPage{}
+		if test.local >= 0 {
+			page.playerID = ids[test.local]
+		}
+
+		ranks := rankPlayers(players)
+		for n, player := range players {
+			if player.GetID() != ids[test.order[n]] || ranks[n] != test.ranks[n] {
+				t.Errorf("%s: expected player %d ranked #%d at %d, got reward %d ranked #%d",
+					test.name, test.order[n], test.ranks[n], n, player.GetReward(), ranks[n])
+			}
+		}
+		if placement := page.placement(players, ranks); placement != test.placement {
+			t.Errorf("%s: expected %q, got: %q", test.name, test.placement, placement)
+		}
+	}
+}
+
+// testResultsPage_MatchDuration tests that the duration is rounded to the second, and unknown when the timestamps
+// are missing or inconsistent.
+func testResultsPage_MatchDuration(t *testing.T) {
+	tests := []struct {
+		name      string
+		startedAt int64
+		endedAt   int64
+		duration  string
+	}{
+		{"Rounded", 1_000, 91_600, "1m31s"},
+		{"Instant", 5_000, 5_000, "0s"},
+		{"NotStarted", 0, 5_000, "unknown"},
+		{"NotEnded", 5_000, 0, "unknown"},
+		{"EndedBeforeStart", 5_000, 4_000, "unknown"},
+	}
+
+	encoder := &gamepb.Protobuf{}
+	for _, test := range tests {
+		gs := encoder.NewGameState()
+		gs.SetStartedAt(test.startedAt)
+		gs.SetEndedAt(test.endedAt)
+		if duration := matchDuration(gs); duration != test.duration {
+			t.Errorf("%s: expected %q, got: %q", test.name, test.duration, duration)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// DefaultThemeName is the theme of the maze when none is configured.
+const DefaultThemeName = "default"
+
+var ErrUnknownTheme = errors.New("unknown theme")
+
+// Theme defines the colors and glyphs of the maze. Colors are tview color names or #rrggbb values,
+// "-" keeping the terminal's default. Every glyph is two columns wide, like a maze cell.
+type Theme struct {
+	Name         string
+	WallColor    string       // WallColor is the background of the walls.
+	WallGlyph    string       // WallGlyph is drawn on the walls, for the themes not relying on colors.
+	FloorColor   string       // FloorColor is the background of the cells without wall.
+	RewardTiers  []RewardTier // RewardTiers are sorted by increasing value.
+	SelfGlyph    string       // SelfGlyph marks the local player.
+	SelfColor    string
+	PlayerColors []string // PlayerColors are given to the opponents in turn.
+	FogGlyph     string   // FogGlyph covers the unexplored cells in the fog-of-war mode.
+	FogColor     string
+}
+
+// RewardTier is the look of the rewards worth at least MinValue.
+type RewardTier struct {
+	MinValue int32
+	Color    string
+	Glyph    string
+}
+
+// themes are the built-in themes, in the order they are cycled through.
+// The colorblind-safe ones use the Okabe-Ito palette and tell the reward tiers apart by their glyph too.
+var themes = []*Theme{
+	{
+		Name:         DefaultThemeName,
+		WallColor:    "blue",
+		WallGlyph:    "  ",
+		FloorColor:   "-",
+		RewardTiers:  []RewardTier{{1, "white", " ●"}, {5, "yellow", " ●"}},
+		SelfGlyph:    "⭕",
+		SelfColor:    "-",
+		PlayerColors: []string{"yellow", "orange", "lime", "purple", "magenta"},
+		FogGlyph:     "░░",
+		FogColor:     "gray",
+	},
+	{
+		Name:         "high-contrast",
+		WallColor:    "white",
+		WallGlyph:    "  ",
+		FloorColor:   "black",
+		RewardTiers:  []RewardTier{{1, "aqua", " •"}, {5, "yellow", " ◆"}},
+		SelfGlyph:    "@@",
+		SelfColor:    "lime",
+		PlayerColors: []string{"fuchsia", "yellow", "aqua", "red", "white"},
+		FogGlyph:     "░░",
+		FogColor:     "silver",
+	},
+	{
+		Name:         "monochrome",
+		WallColor:    "-",
+		WallGlyph:    "██",
+		FloorColor:   "-",
+		RewardTiers:  []RewardTier{{1, "-", " ·"}, {5, "-", " ◆"}},
+		SelfGlyph:    "@@",
+		SelfColor:    "-",
+		PlayerColors: []string{"-"},
+		FogGlyph:     "░░",
+		FogColor:     "-",
+	},
+	{
+		Name:         "deuteranopia",
+		WallColor:    "#0072b2",
+		WallGlyph:    "  ",
+		FloorColor:   "-",
+		RewardTiers:  []RewardTier{{1, "#56b4e9", " •"}, {5, "#f0e442", " ◆"}},
+		SelfGlyph:    "@@",
+		SelfColor:    "#ffffff",
+		PlayerColors: []string{"#e69f00", "#cc79a7", "#f0e442", "#56b4e9", "#d55e00"},
+		FogGlyph:     "░░",
+		FogColor:     "#999999",
+	},
+	{
+		Name:         "protanopia",
+		WallColor:    "#0072b2",
+		WallGlyph:    "  ",
+		FloorColor:   "-",
+		RewardTiers:  []RewardTier{{1, "#56b4e9", " •"}, {5, "#f0e442", " ◆"}},
+		SelfGlyph:    "@@",
+		SelfColor:    "#ffffff",
+		PlayerColors: []string{"#e69f00", "#f0e442", "#cc79a7", "#56b4e9", "#009e73"},
+		FogGlyph:     "░░",
+		FogColor:     "#999999",
+	},
+}
+
+// Themes returns the names of the built-in themes.
+func Themes() []string {
+	names := make([]string, 0, len(themes))
+	for _, t := range themes {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// ThemeByName returns the built-in theme with the name, DefaultThemeName when empty.
+func ThemeByName(name string) (*Theme, error) {
+	if name == "" {
+		name = DefaultThemeName
+	}
+	for _, t := range themes {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %q, expected one of: %s", ErrUnknownTheme, name, strings.Join(Themes(), ", "))
+}
+
+// nextTheme returns the built-in theme following t, a theme that is not built-in being followed by the first one.
+func nextTheme(t *Theme) *Theme {
+	for n, other := range themes {
+		if other == t {
+			return themes[(n+1)%len(themes)]
+		}
+	}
+	return themes[0]
+}
+
+// cell is the look of a maze cell: two columns of text and their colors.
+type cell struct {
+	text   string
+	fg, bg string
+}
+
+// markup returns the cell as tview markup.
+func (c cell) markup() string {
+	return fmt.Sprintf("[%s:%s]%s[-:-]", c.fg, c.bg, c.text)
+}
+
+// style returns the colors of the cell as a tcell style.
+func (c cell) style() tcell.Style {
+	return tcell.StyleDefault.Foreground(themeColor(c.fg)).Background(themeColor(c.bg))
+}
+
+// themeColor returns the tcell color of a theme color, "-" being the terminal's default.
+func themeColor(name string) tcell.Color {
+	if name == "-" || name == "" {
+		return tcell.ColorDefault
+	}
+	return tcell.GetColor(name)
+}
+
+// wall returns the look of a wall cell.
+func (t *Theme) wall() cell {
+	return cell{t.WallGlyph, "-", t.WallColor}
+}
+
+// floor returns the look of a cell without wall nor reward.
+func (t *Theme) floor() cell {
+	return cell{"  ", "-", t.FloorColor}
+}
+
+// reward returns the look of a reward of the value, drawn with the highest tier it reaches.
+func (t *Theme) reward(value int32) cell {
+	tier := t.RewardTiers[0]
+	for _, candidate := range t.RewardTiers {
+		if value >= candidate.MinValue {
+			tier = candidate
+		}
+	}
+	return cell{tier.Glyph, tier.Color, t.FloorColor}
+}
+
+// self returns the look of the local player.
+func (t *Theme) self() cell {
+	return cell{t.SelfGlyph, t.SelfColor, t.FloorColor}
+}
+
+// fog returns the look of an unexplored cell.
+func (t *Theme) fog() cell {
+	return cell{t.FogGlyph, t.FogColor, t.FloorColor}
+}
+
+// opponent returns the look of the nth opponent, counted from 0, the colors being reused past the palette.
+func (t *Theme) opponent(n int) cell {
+	return cell{fmt.Sprintf("P%d", n+1), t.PlayerColors[n%len(t.PlayerColors)], t.FloorColor}
+}
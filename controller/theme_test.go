@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rivo/tview"
+	gamepb "github.com/sofc-t/puzzle-client/infrastruture/pb_encoder/game"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// TestTheme contains all the tests related to the maze themes.
+func TestTheme(t *testing.T) {
+	t.Run("Theme_CellWidth", testTheme_CellWidth)
+	t.Run("Theme_RewardTiers", testTheme_RewardTiers)
+	t.Run("Theme_ByName", testTheme_ByName)
+	t.Run("Theme_Cycle", testTheme_Cycle)
+	t.Run("Theme_ManyPlayers", testTheme_ManyPlayers)
+}
+
+// testTheme_CellWidth tests that every cell of every theme is two columns wide, so the maze stays aligned.
+func testTheme_CellWidth(t *testing.T) {
+	for _, theme := range themes {
+		cells := map[string]cell{"wall": theme.wall(), "floor": theme.floor(), "self": theme.self(), "opponent": theme.opponent(0), "fog": theme.fog()}
+		for _, tier := range theme.RewardTiers {
+			cells[fmt.Sprintf("reward %d", tier.MinValue)] = theme.reward(tier.MinValue)
+		}
+
+		for name, c := range cells {
+			if width := tview.TaggedStringWidth(c.markup()); width != 2 {
+				t.Errorf("%s: expected the %s cell to be 2 columns wide, got %d: %q", theme.Name, name, width, c.text)
+			}
+		}
+	}
+}
+
+// testTheme_RewardTiers tests that a reward is drawn with the highest tier it reaches.
+func testTheme_RewardTiers(t *testing.T) {
+	theme, _ := ThemeByName("monochrome")
+	if theme.reward(1) == theme.reward(5) {
+		t.Errorf("Expected the reward tiers to differ without colors")
+	}
+	if theme.reward(7) != theme.reward(5) || theme.reward(0) != theme.reward(1) {
+		t.Errorf("Expected out of range rewards to use the closest tier")
+	}
+}
+
+// testTheme_ByName tests the theme names.
+func testTheme_ByName(t *testing.T) {
+	if theme, err := ThemeByName(""); err != nil || theme.Name != DefaultThemeName {
+		t.Errorf("Expected the default theme, got: %v, %v", theme, err)
+	}
+	if _, err := ThemeByName("solarized"); !errors.Is(err, ErrUnknownTheme) {
+		t.Errorf("Expected ErrUnknownTheme, got: %v", err)
+	}
+}
+
+// testTheme_Cycle tests that switching themes goes through every theme and back.
+func testTheme_Cycle(t *testing.T) {
+	first, _ := ThemeByName("")
+	seen := map[string]bool{}
+	theme := first
+	for range themes {
+		seen[theme.Name] = true
+		theme = nextTheme(theme)
+	}
+	if theme != first || len(seen) != len(themes) {
+		t.Errorf("Expected to cycle through the %d themes, saw %d", len(themes), len(seen))
+	}
+}
+
+// testTheme_ManyPlayers tests that more opponents than colors reuse the palette with their own number.
+func testTheme_ManyPlayers(t *testing.T) {
+	encoder := &gamepb.Protobuf{}
+	players := make([]i.Player, 8)
+	for n := range players {
+		players[n] = encoder.NewPlayer()
+		players[n].SetID(uuid.New())
+	}
+
+	g, err := NewGame(nil, players[3].GetID(), nil, nil)
+	if err != nil {
+		t.Fatalf("Expected a game, got error: %s", err)
+	}
+
+	labels := map[string]bool{}
+	for _, p := range players {
+		labels[g.playerRepr(p.GetID(), players)] = true
+	}
+	if len(labels) != len(players) {
+		t.Errorf("Expected a distinct label for each of the %d players, got %d", len(players), len(labels))
+	}
+	if g.playerRepr(players[3].GetID(), players) != g.theme.self().markup() {
+		t.Errorf("Expected the local player to be drawn as self")
+	}
+}
@@ -0,0 +1,8 @@
+package dmn
+
+// Credentials are the tokens of a logged in player, persisted to skip the login on the next launch.
+type Credentials struct {
+	Player       Player `json:"player"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
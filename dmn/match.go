@@ -0,0 +1,17 @@
+package dmn
+
+import "time"
+
+// Match holds the connection details of the game server assigned to a match.
+type Match struct {
+	SocketPubKey   []byte `json:"socket_pubkey"`
+	SocketAddr     string `json:"socket_addr"`
+	SocketKeyShare []byte `json:"socket_key_share"`
+}
+
+// MatchProgress reports the state of a pending match request.
+type MatchProgress struct {
+	Elapsed       time.Duration // Elapsed is the time since the match was requested.
+	QueuePosition int           // QueuePosition is 1 for the next player to be matched, 0 when unknown.
+	EstimatedWait time.Duration // EstimatedWait is the time left before a match, 0 when unknown.
+}
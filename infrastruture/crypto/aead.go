@@ -0,0 +1,92 @@
+// An implementation of authenticated symmetric cryptography with AES-GCM and ChaCha20-Poly1305
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Cipher suite identifiers negotiated in the handshake.
+const (
+	SuiteAES256GCM        uint32 = 1
+	SuiteChaCha20Poly1305 uint32 = 2
+)
+
+// AEAD implements authenticated symmetric cryptography.
+// The nonce is randomly generated for every message and prepended to the ciphertext.
+type AEAD struct {
+	suite   uint32
+	newAEAD func(key []byte) (cipher.AEAD, error)
+}
+
+// NewAESGCM returns a new instance of AEAD using AES in GCM mode
+func NewAESGCM() *AEAD {
+	return &AEAD{
+		suite: SuiteAES256GCM,
+		newAEAD: func(key []byte) (cipher.AEAD, error) {
+			block, err := aes.NewCipher(key)
+			if err != nil { // Invalid key size
+				return nil, err
+			}
+			return cipher.NewGCM(block)
+		},
+	}
+}
+
+// NewChaCha20Poly1305 returns a new instance of AEAD using ChaCha20-Poly1305
+func NewChaCha20Poly1305() *AEAD {
+	return &AEAD{
+		suite:   SuiteChaCha20Poly1305,
+		newAEAD: chacha20poly1305.New,
+	}
+}
+
+// Suite implements AEAD.
+func (a *AEAD) Suite() uint32 {
+	return a.suite
+}
+
+// Encrypt implements Symmetric.
+func (a *AEAD) Encrypt(p []byte, k []byte) ([]byte, error) {
+	return a.Seal(p, k, nil)
+}
+
+// Decrypt implements Symmetric.
+func (a *AEAD) Decrypt(c []byte, k []byte) ([]byte, error) {
+	return a.Open(c, k, nil)
+}
+
+// Seal encrypts and authenticates p, and authenticates the associated data ad.
+func (a *AEAD) Seal(p []byte, k []byte, ad []byte) ([]byte, error) {
+	aead, err := a.newAEAD(k)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := aead.NonceSize()
+	cipherBytes := make([]byte, nonceSize, nonceSize+len(p)+aead.Overhead())
+	if _, err = io.ReadFull(rand.Reader, cipherBytes); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(cipherBytes, cipherBytes[:nonceSize], p, ad), nil
+}
+
+// Open decrypts and authenticates c and the associated data ad.
+func (a *AEAD) Open(c []byte, k []byte, ad []byte) ([]byte, error) {
+	aead, err := a.newAEAD(k)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := aead.NonceSize()
+	if len(c) < nonceSize+aead.Overhead() {
+		return nil, ErrCipherTextTooShortToDecrypt
+	}
+
+	return aead.Open(nil, c[:nonceSize], c[nonceSize:], ad)
+}
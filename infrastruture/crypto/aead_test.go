@@ -0,0 +1,120 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+var associatedData = []byte{8, 1, 2, 3, 4}
+
+// TestAEAD contains all the tests related to AES-GCM and ChaCha20-Poly1305 encryption and decryption.
+func TestAEAD(t *testing.T) {
+	suites := map[string]func() *AEAD{
+		"AESGCM":           NewAESGCM,
+		"ChaCha20Poly1305": NewChaCha20Poly1305,
+	}
+
+	for name, newAEAD := range suites {
+		t.Run(name+"_Seal", func(t *testing.T) { testAEAD_Seal(t, newAEAD()) })
+		t.Run(name+"_InvalidKeyLength", func(t *testing.T) { testAEAD_InvalidKeyLength(t, newAEAD()) })
+		t.Run(name+"_TamperedCipher", func(t *testing.T) { testAEAD_TamperedCipher(t, newAEAD()) })
+		t.Run(name+"_WrongAssociatedData", func(t *testing.T) { testAEAD_WrongAssociatedData(t, newAEAD()) })
+		t.Run(name+"_ShortCipher", func(t *testing.T) { testAEAD_ShortCipher(t, newAEAD()) })
+	}
+
+	t.Run("AEAD_SuitesAreNotInterchangeable", testAEAD_SuitesAreNotInterchangeable)
+}
+
+// testAEAD_Seal tests sealing and opening with and without associated data.
+func testAEAD_Seal(t *testing.T, a *AEAD) {
+	c, err := a.Seal(plaintext, aesKey, associatedData)
+	if err != nil {
+		t.Errorf("Expected cipher, got error: %s", err)
+		t.FailNow()
+	}
+
+	if bytes.Contains(c, plaintext) {
+		t.Errorf("Cipher contains the plain text")
+		t.FailNow()
+	}
+
+	d, err := a.Open(c, aesKey, associatedData)
+	if err != nil {
+		t.Errorf("Expected decrypted text, got error: %s", err)
+		t.FailNow()
+	}
+
+	if !bytes.Equal(d, plaintext) {
+		t.Errorf("Expected decrypted text: %s, got wrong value: %s", plaintext, d)
+	}
+
+	c, err = a.Encrypt(plaintext, aesKey)
+	if err != nil {
+		t.Errorf("Expected cipher, got error: %s", err)
+		t.FailNow()
+	}
+
+	d, err = a.Decrypt(c, aesKey)
+	if err != nil || !bytes.Equal(d, plaintext) {
+		t.Errorf("Expected decrypted text: %s, got: %s, error: %v", plaintext, d, err)
+	}
+}
+
+// testAEAD_InvalidKeyLength tests encryption with an invalid key length.
+func testAEAD_InvalidKeyLength(t *testing.T, a *AEAD) {
+	c, err := a.Seal(plaintext, invalidKey, associatedData)
+	if err == nil {
+		t.Errorf("Expected error with invalid key length, got cipher: %x", c)
+	}
+}
+
+// testAEAD_TamperedCipher tests that flipping a single bit of the cipher is detected.
+func testAEAD_TamperedCipher(t *testing.T, a *AEAD) {
+	c, err := a.Seal(plaintext, aesKey, associatedData)
+	if err != nil {
+		t.Errorf("Expected cipher, got error: %s", err)
+		t.FailNow()
+	}
+
+	c[len(c)-1] ^= 1
+	d, err := a.Open(c, aesKey, associatedData)
+	if err == nil {
+		t.Errorf("Expected error with tampered cipher, got decrypted text: %s", d)
+	}
+}
+
+// testAEAD_WrongAssociatedData tests that the cipher is bound to its associated data.
+func testAEAD_WrongAssociatedData(t *testing.T, a *AEAD) {
+	c, err := a.Seal(plaintext, aesKey, associatedData)
+	if err != nil {
+		t.Errorf("Expected cipher, got error: %s", err)
+		t.FailNow()
+	}
+
+	d, err := a.Open(c, aesKey, []byte{16, 1, 2, 3, 4})
+	if err == nil {
+		t.Errorf("Expected error with wrong associated data, got decrypted text: %s", d)
+	}
+}
+
+// testAEAD_ShortCipher tests decryption of a cipher shorter than the nonce and tag.
+func testAEAD_ShortCipher(t *testing.T, a *AEAD) {
+	_, err := a.Open([]byte{1, 2, 3}, aesKey, nil)
+	if err != ErrCipherTextTooShortToDecrypt {
+		t.Errorf("Expected error %s, got: %v", ErrCipherTextTooShortToDecrypt, err)
+	}
+}
+
+// testAEAD_SuitesAreNotInterchangeable tests that a cipher sealed by one suite cannot be opened by the other.
+func testAEAD_SuitesAreNotInterchangeable(t *testing.T) {
+	c, err := NewAESGCM().Seal(plaintext, aesKey, associatedData)
+	if err != nil {
+		t.Errorf("Expected cipher, got error: %s", err)
+		t.FailNow()
+	}
+
+	d, err := NewChaCha20Poly1305().Open(c, aesKey, associatedData)
+	if err == nil {
+		t.Errorf("Expected error when opening with another suite, got decrypted text: %s", d)
+	}
+}
@@ -0,0 +1,159 @@
+// Implementations of per-connection symmetric key management
+package crypto
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// errors
+var (
+	ErrInvalidServerKeyShare = errors.New("invalid server X25519 key share")
+)
+
+const (
+	sessionKeySize = 32 // 256 bits session keys for AES-256 and ChaCha20.
+
+	x25519KeyInfo = "puzzle-me session key"
+)
+
+// EphemeralKeys generates a fresh random symmetric key on every rotation.
+// The key is sent to the server in the RSA encrypted client hello.
+type EphemeralKeys struct {
+	key []byte
+	mu  sync.Mutex
+}
+
+// NewEphemeralKeys returns a new instance of EphemeralKeys. Rotate must be called before the first use.
+func NewEphemeralKeys() *EphemeralKeys {
+	return &EphemeralKeys{}
+}
+
+// Key implements KeyManager.
+func (e *EphemeralKeys) Key() []byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.key
+}
+
+// KeyShare implements KeyManager. The key itself is sent to the server, so there is no key share.
+func (e *EphemeralKeys) KeyShare() []byte {
+	return nil
+}
+
+// Rotate implements KeyManager.
+func (e *EphemeralKeys) Rotate() error {
+	key := make([]byte, sessionKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	zero(e.key)
+	e.key = key
+	return nil
+}
+
+// Zero implements KeyManager.
+func (e *EphemeralKeys) Zero() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	zero(e.key)
+	e.key = nil
+}
+
+// X25519Keys derives the symmetric key from an X25519 key exchange with the server.
+// Every rotation generates a new ephemeral key pair, so the key never leaves the client
+// and a compromised RSA key does not reveal past sessions.
+type X25519Keys struct {
+	serverShare *ecdh.PublicKey
+	share       []byte
+	key         []byte
+	mu          sync.Mutex
+}
+
+// NewX25519Keys returns a new instance of X25519Keys for the server's X25519 public key.
+// Rotate must be called before the first use.
+func NewX25519Keys(serverShare []byte) (*X25519Keys, error) {
+	pub, err := ecdh.X25519().NewPublicKey(serverShare)
+	if err != nil {
+		return nil, ErrInvalidServerKeyShare
+	}
+
+	return &X25519Keys{serverShare: pub}, nil
+}
+
+// Key implements KeyManager.
+func (x *X25519Keys) Key() []byte {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.key
+}
+
+// KeyShare implements KeyManager.
+func (x *X25519Keys) KeyShare() []byte {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.share
+}
+
+// Rotate implements KeyManager.
+func (x *X25519Keys) Rotate() error {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	share := priv.PublicKey().Bytes()
+	key, err := DeriveX25519SessionKey(priv, x.serverShare, share)
+	if err != nil {
+		return err
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	zero(x.key)
+	x.key = key
+	x.share = share
+	return nil
+}
+
+// Zero implements KeyManager.
+// The ephemeral private key is dropped right after the derivation in Rotate, so only the derived key is left to erase.
+func (x *X25519Keys) Zero() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	zero(x.key)
+	x.key = nil
+	x.share = nil
+}
+
+// DeriveX25519SessionKey computes the session key shared by priv and peer.
+// The client share is used as salt so both sides derive the key from the same transcript.
+func DeriveX25519SessionKey(priv *ecdh.PrivateKey, peer *ecdh.PublicKey, clientShare []byte) ([]byte, error) {
+	secret, err := priv.ECDH(peer)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(secret)
+
+	key := make([]byte, sessionKeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, clientShare, []byte(x25519KeyInfo)), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// zero overwrites b with zeros.
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
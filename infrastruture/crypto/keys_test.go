@@ -0,0 +1,128 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"testing"
+)
+
+// TestKeys contains all the tests related to session key management.
+func TestKeys(t *testing.T) {
+	t.Run("EphemeralKeys_Rotate", testEphemeralKeys_Rotate)
+	t.Run("EphemeralKeys_Zero", testEphemeralKeys_Zero)
+	t.Run("X25519Keys_SharedKey", testX25519Keys_SharedKey)
+	t.Run("X25519Keys_Rotate", testX25519Keys_Rotate)
+	t.Run("X25519Keys_InvalidServerShare", testX25519Keys_InvalidServerShare)
+}
+
+// testEphemeralKeys_Rotate tests that every rotation generates a new key of the right size.
+func testEphemeralKeys_Rotate(t *testing.T) {
+	keys := NewEphemeralKeys()
+	if err := keys.Rotate(); err != nil {
+		t.Errorf("Expected key, got error: %s", err)
+		t.FailNow()
+	}
+
+	first := append([]byte{}, keys.Key()...)
+	if len(first) != sessionKeySize {
+		t.Errorf("Expected key of %d bytes, got %d", sessionKeySize, len(first))
+	}
+
+	if keys.KeyShare() != nil {
+		t.Errorf("Expected no key share, got: %x", keys.KeyShare())
+	}
+
+	if err := keys.Rotate(); err != nil {
+		t.Errorf("Expected key, got error: %s", err)
+		t.FailNow()
+	}
+
+	if bytes.Equal(first, keys.Key()) {
+		t.Errorf("Expected a new key after rotation, got the same key")
+	}
+}
+
+// testEphemeralKeys_Zero tests that zeroing erases the key in place.
+func testEphemeralKeys_Zero(t *testing.T) {
+	keys := NewEphemeralKeys()
+	if err := keys.Rotate(); err != nil {
+		t.Errorf("Expected key, got error: %s", err)
+		t.FailNow()
+	}
+
+	key := keys.Key()
+	keys.Zero()
+
+	if !bytes.Equal(key, make([]byte, sessionKeySize)) {
+		t.Errorf("Expected key memory to be zeroed, got: %x", key)
+	}
+
+	if keys.Key() != nil {
+		t.Errorf("Expected no key after zeroing, got: %x", keys.Key())
+	}
+}
+
+// testX25519Keys_SharedKey tests that the server derives the same key from the client share.
+func testX25519Keys_SharedKey(t *testing.T) {
+	serverPriv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := NewX25519Keys(serverPriv.PublicKey().Bytes())
+	if err != nil {
+		t.Errorf("Expected key manager, got error: %s", err)
+		t.FailNow()
+	}
+
+	if err := keys.Rotate(); err != nil {
+		t.Errorf("Expected key, got error: %s", err)
+		t.FailNow()
+	}
+
+	clientPub, err := ecdh.X25519().NewPublicKey(keys.KeyShare())
+	if err != nil {
+		t.Errorf("Expected valid client share, got error: %s", err)
+		t.FailNow()
+	}
+
+	serverKey, err := DeriveX25519SessionKey(serverPriv, clientPub, keys.KeyShare())
+	if err != nil {
+		t.Errorf("Expected server key, got error: %s", err)
+		t.FailNow()
+	}
+
+	if !bytes.Equal(serverKey, keys.Key()) {
+		t.Errorf("Expected server key %x to match client key %x", serverKey, keys.Key())
+	}
+}
+
+// testX25519Keys_Rotate tests that rotation replaces both the key and the key share.
+func testX25519Keys_Rotate(t *testing.T) {
+	serverPriv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, _ := NewX25519Keys(serverPriv.PublicKey().Bytes())
+	_ = keys.Rotate()
+	key, share := keys.Key(), keys.KeyShare()
+
+	_ = keys.Rotate()
+	if bytes.Equal(share, keys.KeyShare()) || bytes.Equal(make([]byte, sessionKeySize), keys.Key()) {
+		t.Errorf("Expected a new key share and key after rotation")
+	}
+
+	if !bytes.Equal(key, make([]byte, sessionKeySize)) {
+		t.Errorf("Expected the previous key to be zeroed, got: %x", key)
+	}
+}
+
+// testX25519Keys_InvalidServerShare tests creating a key manager with a malformed server share.
+func testX25519Keys_InvalidServerShare(t *testing.T) {
+	_, err := NewX25519Keys([]byte("short"))
+	if err != ErrInvalidServerKeyShare {
+		t.Errorf("Expected error %s, got: %v", ErrInvalidServerKeyShare, err)
+	}
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// maxErrorBodySize bounds the part of an error body kept as the message.
+const maxErrorBodySize = 4096
+
+// newAPIError builds the error of a response with an error status from its body. JSON bodies are read as
+// {"code": ..., "message": ...} or {"error": ...}, other bodies are kept as text.
+func newAPIError(resp *http.Response) *i.APIError {
+	apiErr := &i.APIError{StatusCode: resp.StatusCode, RetryAfter: retryAfter(resp.Header.Get("Retry-After"))}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	var errorBody struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &errorBody); err == nil {
+		apiErr.Code = errorBody.Code
+		apiErr.Message = errorBody.Message
+		if apiErr.Message == "" {
+			apiErr.Message = errorBody.Error
+		}
+		return apiErr
+	}
+
+	apiErr.Message = strings.TrimSpace(string(body))
+	return apiErr
+}
+
+// retryAfter parses a Retry-After header, given in seconds or as a date. It returns 0 when unset or invalid.
+func retryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
@@ -2,27 +2,61 @@ package http
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"errors"
+	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"time"
+
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+const (
+	defaultTimeout      = 10 * time.Second
+	defaultRetries      = 2
+	defaultRetryBackoff = 200 * time.Millisecond
+	maxRetryDelay       = 10 * time.Second // A longer Retry-After fails the request instead of blocking the caller.
 )
 
 // RequestOption modifies an HTTP request.
 type RequestOption func(*http.Request)
 
+type ClientOption func(*HttpClient)
+
 // HttpClient is a struct that implements the HttpRequester interface.
 type HttpClient struct {
-	Client  *http.Client
-	BaseURL string
+	Client       *http.Client
+	BaseURL      string
+	timeout      time.Duration // Timeout bounds every request but the event streams, which end with their context.
+	retries      int           // Retries is the number of times a failed idempotent request is sent again.
+	retryBackoff time.Duration // RetryBackoff is the delay before the first retry, doubled for every other one.
+	middlewares  []Middleware
+	tlsConfig    *tls.Config // TLSConfig verifies the backend's certificate, the default transport's when nil.
 }
 
-// NewHttpClient creates a new HttpClient with a default HTTP client and an optional base URL.
-func NewHttpClient(baseURL string) *HttpClient {
-	return &HttpClient{
-		Client:  &http.Client{},
-		BaseURL: baseURL,
+// NewHttpClient creates a new HttpClient with an optional base URL. Requests time out after 10 seconds and
+// idempotent requests are retried twice by default.
+func NewHttpClient(baseURL string, options ...ClientOption) *HttpClient {
+	h := &HttpClient{
+		BaseURL:      baseURL,
+		timeout:      defaultTimeout,
+		retries:      defaultRetries,
+		retryBackoff: defaultRetryBackoff,
+	}
+	for _, opt := range options {
+		opt(h)
 	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if h.tlsConfig != nil {
+		transport.TLSClientConfig = h.tlsConfig
+	}
+	h.Client = &http.Client{Transport: chain(transport, h.middlewares)}
+	return h
 }
 
 // buildURL constructs the full URL by combining the base URL with the given path.
@@ -38,54 +72,62 @@ func (h *HttpClient) buildURL(path string) (string, error) {
 	return base.ResolveReference(fullPath).String(), nil
 }
 
-// Post sends a POST request to the specified path with the provided body.
-func (h *HttpClient) Post(path string, body io.Reader, authToken string) (io.Reader, error) {
-	uri, err := h.buildURL(path)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := http.NewRequest(http.MethodPost, uri, body)
-	if err != nil {
-		return nil, err
-	}
+// Post sends a POST request to the specified path with the provided body. It is not retried.
+func (h *HttpClient) Post(ctx context.Context, path string, body io.Reader, authToken string) (io.Reader, error) {
+	return h.do(ctx, http.MethodPost, path, body, authToken)
+}
 
-	req.Header.Set("Content-Type", "application/json")
+// Get sends a GET request to the specified path.
+func (h *HttpClient) Get(ctx context.Context, path, authToken string) (io.Reader, error) {
+	return h.do(ctx, http.MethodGet, path, nil, authToken)
+}
 
-	var resp *http.Response
-	if authToken != "" {
-		resp, err = h.send(req, WithBearerToken(authToken))
-	} else {
-		resp, err = h.send(req)
-	}
+// Delete sends a DELETE request to the specified path.
+func (h *HttpClient) Delete(ctx context.Context, path, authToken string) (io.Reader, error) {
+	return h.do(ctx, http.MethodDelete, path, nil, authToken)
+}
 
+// do sends a request and returns its response body. Idempotent requests failing on an unavailable or
+// rate limiting server are sent again after a backoff, until the retries are exhausted or ctx is done.
+func (h *HttpClient) do(ctx context.Context, method, path string, body io.Reader, authToken string) (io.Reader, error) {
+	uri, err := h.buildURL(path)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		return nil, errors.New("HTTP POST request failed with status: " + resp.Status)
-	}
+	for attempt := 0; ; attempt++ {
+		response, err := h.once(ctx, method, uri, body, authToken)
+		if err == nil || attempt >= h.retries || !idempotent(method) || !retryable(err) {
+			return response, err
+		}
 
-	responseBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+		delay, ok := h.retryDelay(attempt, err)
+		if !ok {
+			return nil, err
+		}
 
-	return bytes.NewReader(responseBody), nil
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
 }
 
-// Get sends a GET request to the specified path.
-func (h *HttpClient) Get(path, authToken string) (io.Reader, error) {
-	uri, err := h.buildURL(path)
+// once sends a single request bounded by the client timeout and reads the whole response body.
+func (h *HttpClient) once(ctx context.Context, method, uri string, body io.Reader, authToken string) (io.Reader, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, method, uri, body)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodGet, uri, nil)
-	if err != nil {
-		return nil, err
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
 
 	var resp *http.Response
@@ -96,17 +138,17 @@ func (h *HttpClient) Get(path, authToken string) (io.Reader, error) {
 	}
 
 	if err != nil {
-		return nil, err
+		return nil, transportError(ctx, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		return nil, errors.New("HTTP GET request failed with status: " + resp.Status)
+		return nil, newAPIError(resp)
 	}
 
 	responseBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, transportError(ctx, err)
 	}
 
 	return bytes.NewReader(responseBody), nil
@@ -120,8 +162,74 @@ func (h *HttpClient) send(req *http.Request, opts ...RequestOption) (*http.Respo
 	return h.Client.Do(req)
 }
 
+// retryDelay returns the delay before the retry following attempt: the Retry-After delay asked by the server,
+// or the backoff doubled after every attempt with a random jitter of up to half of it.
+// It reports false when the server asks for a longer delay than the client waits for.
+func (h *HttpClient) retryDelay(attempt int, err error) (time.Duration, bool) {
+	var apiErr *i.APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter, apiErr.RetryAfter <= maxRetryDelay
+	}
+
+	delay := min(h.retryBackoff<<min(attempt, 16), maxRetryDelay)
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1)), true
+}
+
+// transportError reports a request that did not complete as i.ErrServerUnavailable, unless ctx is done.
+// Hitting the client timeout counts as an unavailable server.
+func transportError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return fmt.Errorf("%w: %w", i.ErrServerUnavailable, err)
+}
+
+// idempotent reports whether a request with the method can be sent twice without changing its effect.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryable reports whether the request may succeed when sent again.
+func retryable(err error) bool {
+	return errors.Is(err, i.ErrServerUnavailable) || errors.Is(err, i.ErrRateLimited)
+}
+
 func WithBearerToken(token string) RequestOption {
 	return func(req *http.Request) {
 		req.Header.Set("Authorization", "Bearer "+token)
 	}
 }
+
+// ClientWithTimeout sets the timeout of every request but the event streams.
+func ClientWithTimeout(d time.Duration) ClientOption {
+	return func(h *HttpClient) {
+		h.timeout = d
+	}
+}
+
+// ClientWithRetries sets the number of retries of a failed idempotent request and the delay before the first one.
+// Zero retries sends every request once.
+func ClientWithRetries(retries int, backoff time.Duration) ClientOption {
+	return func(h *HttpClient) {
+		h.retries = retries
+		h.retryBackoff = backoff
+	}
+}
+
+// ClientWithMiddleware appends middlewares to the round-tripper chain. The first middleware sees the requests first.
+func ClientWithMiddleware(middlewares ...Middleware) ClientOption {
+	return func(h *HttpClient) {
+		h.middlewares = append(h.middlewares, middlewares...)
+	}
+}
+
+// ClientWithTLS sets the TLS configuration of the connections to the backend, see NewTLSConfig.
+func ClientWithTLS(cfg *tls.Config) ClientOption {
+	return func(h *HttpClient) {
+		h.tlsConfig = cfg
+	}
+}
@@ -0,0 +1,237 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// newErrorServer returns a server answering every request with status and body.
+func newErrorServer(t *testing.T, status int, contentType, body string, header http.Header) *HttpClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for key, values := range header {
+			w.Header()[key] = values
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return NewHttpClient(server.URL, ClientWithRetries(0, 0))
+}
+
+// TestHttpClientErrors contains all the tests related to the errors of the HTTP client.
+func TestHttpClientErrors(t *testing.T) {
+	t.Run("HttpClientErrors_JSONBody", testHttpClientErrors_JSONBody)
+	t.Run("HttpClientErrors_TextBody", testHttpClientErrors_TextBody)
+	t.Run("HttpClientErrors_RateLimited", testHttpClientErrors_RateLimited)
+	t.Run("HttpClientErrors_Unreachable", testHttpClientErrors_Unreachable)
+}
+
+// testHttpClientErrors_JSONBody tests that the code and message of a JSON error body are kept.
+func testHttpClientErrors_JSONBody(t *testing.T) {
+	client := newErrorServer(t, http.StatusConflict, "application/json", `{"code":"username_taken","message":"username is taken"}`, nil)
+
+	_, err := client.Post(context.Background(), "/auth/register", nil, "")
+	var apiErr *i.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected an APIError, got: %v", err)
+	}
+
+	if apiErr.StatusCode != http.StatusConflict || apiErr.Code != "username_taken" || apiErr.Message != "username is taken" {
+		t.Errorf("Expected the conflict of the body, got: %+v", apiErr)
+	}
+	if !errors.Is(err, i.ErrConflict) || errors.Is(err, i.ErrUnauthorized) {
+		t.Errorf("Expected the error to only match ErrConflict")
+	}
+}
+
+// testHttpClientErrors_TextBody tests that a plain text body is kept as the message.
+func testHttpClientErrors_TextBody(t *testing.T) {
+	client := newErrorServer(t, http.StatusUnauthorized, "text/plain", "invalid auth token\n", nil)
+
+	_, err := client.Get(context.Background(), "/match/1", "token")
+	var apiErr *i.APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "invalid auth token" || apiErr.Code != "" {
+		t.Fatalf("Expected the text of the body, got: %v", err)
+	}
+	if !errors.Is(err, i.ErrUnauthorized) {
+		t.Errorf("Expected the error to match ErrUnauthorized")
+	}
+}
+
+// testHttpClientErrors_RateLimited tests that the Retry-After delay is parsed.
+func testHttpClientErrors_RateLimited(t *testing.T) {
+	client := newErrorServer(t, http.StatusTooManyRequests, "application/json", `{"error":"slow down"}`,
+		http.Header{"Retry-After": {"7"}})
+
+	_, err := client.Delete(context.Background(), "/match/1", "token")
+	var apiErr *i.APIError
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter != 7*time.Second || apiErr.Message != "slow down" {
+		t.Fatalf("Expected a 7s retry delay, got: %+v", apiErr)
+	}
+	if !errors.Is(err, i.ErrRateLimited) {
+		t.Errorf("Expected the error to match ErrRateLimited")
+	}
+}
+
+// testHttpClientErrors_Unreachable tests that a request not reaching the server reports ErrServerUnavailable.
+func testHttpClientErrors_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	client := NewHttpClient(server.URL, ClientWithRetries(0, 0))
+	server.Close()
+
+	if _, err := client.Get(context.Background(), "/match/1", ""); !errors.Is(err, i.ErrServerUnavailable) {
+		t.Errorf("Expected ErrServerUnavailable, got: %v", err)
+	}
+}
+
+// newFlakyServer returns a client of a server failing with 503 until it was hit failures times.
+func newFlakyServer(t *testing.T, failures int32, options ...ClientOption) (*HttpClient, *atomic.Int32) {
+	hits := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) <= failures {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return NewHttpClient(server.URL, options...), hits
+}
+
+// TestHttpClientRequests contains all the tests related to timeouts, retries and middlewares.
+func TestHttpClientRequests(t *testing.T) {
+	t.Run("HttpClientRequests_RetriesIdempotent", testHttpClientRequests_RetriesIdempotent)
+	t.Run("HttpClientRequests_PostNotRetried", testHttpClientRequests_PostNotRetried)
+	t.Run("HttpClientRequests_RetriesExhausted", testHttpClientRequests_RetriesExhausted)
+	t.Run("HttpClientRequests_Timeout", testHttpClientRequests_Timeout)
+	t.Run("HttpClientRequests_Cancelled", testHttpClientRequests_Cancelled)
+	t.Run("HttpClientRequests_Middlewares", testHttpClientRequests_Middlewares)
+}
+
+// testHttpClientRequests_RetriesIdempotent tests that a GET succeeds once the server recovers.
+func testHttpClientRequests_RetriesIdempotent(t *testing.T) {
+	client, hits := newFlakyServer(t, 2, ClientWithRetries(2, time.Millisecond))
+
+	response, err := client.Get(context.Background(), "/", "")
+	if err != nil {
+		t.Fatalf("Expected the third attempt to succeed, got error: %s", err)
+	}
+	if body, _ := io.ReadAll(response); string(body) != "ok" || hits.Load() != 3 {
+		t.Errorf("Expected ok after 3 hits, got %q after %d", body, hits.Load())
+	}
+}
+
+// testHttpClientRequests_PostNotRetried tests that a POST is sent once.
+func testHttpClientRequests_PostNotRetried(t *testing.T) {
+	client, hits := newFlakyServer(t, 1, ClientWithRetries(2, time.Millisecond))
+
+	if _, err := client.Post(context.Background(), "/", strings.NewReader("{}"), ""); !errors.Is(err, i.ErrServerUnavailable) {
+		t.Errorf("Expected ErrServerUnavailable, got: %v", err)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("Expected a single hit, got: %d", hits.Load())
+	}
+}
+
+// testHttpClientRequests_RetriesExhausted tests that the last error is returned once the retries are exhausted.
+func testHttpClientRequests_RetriesExhausted(t *testing.T) {
+	client, hits := newFlakyServer(t, 5, ClientWithRetries(1, time.Millisecond))
+
+	if _, err := client.Delete(context.Background(), "/", ""); !errors.Is(err, i.ErrServerUnavailable) {
+		t.Errorf("Expected ErrServerUnavailable, got: %v", err)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("Expected 2 hits, got: %d", hits.Load())
+	}
+}
+
+// testHttpClientRequests_Timeout tests that a hung server fails the request after the timeout.
+func testHttpClientRequests_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewHttpClient(server.URL, ClientWithTimeout(20*time.Millisecond), ClientWithRetries(0, 0))
+	start := time.Now()
+	if _, err := client.Post(context.Background(), "/auth/login", strings.NewReader("{}"), ""); !errors.Is(err, i.ErrServerUnavailable) {
+		t.Errorf("Expected ErrServerUnavailable, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the request to time out, it took: %s", elapsed)
+	}
+}
+
+// testHttpClientRequests_Cancelled tests that a cancelled request reports the error of its context without retrying.
+func testHttpClientRequests_Cancelled(t *testing.T) {
+	client, hits := newFlakyServer(t, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.Get(ctx, "/", ""); !errors.Is(err, context.Canceled) || errors.Is(err, i.ErrServerUnavailable) {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+	if hits.Load() != 0 {
+		t.Errorf("Expected no hit, got: %d", hits.Load())
+	}
+}
+
+// testHttpClientRequests_Middlewares tests the order of the middlewares and the built-in ones.
+func testHttpClientRequests_Middlewares(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	metrics := &Metrics{}
+	var logs bytes.Buffer
+	client := NewHttpClient(server.URL, ClientWithMiddleware(
+		trace("outer"), UserAgentMiddleware("puzzle-me/test"), RequestIDMiddleware(),
+		LoggingMiddleware(log.New(&logs, "", 0)), MetricsMiddleware(metrics), trace("inner"),
+	))
+
+	if _, err := client.Get(context.Background(), "/match/1", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(order, ",") != "outer,inner" {
+		t.Errorf("Expected the first middleware to be the outermost, got: %v", order)
+	}
+	if headers.Get("User-Agent") != "puzzle-me/test" || headers.Get(requestIDHeader) == "" {
+		t.Errorf("Expected the user agent and a request ID, got: %v", headers)
+	}
+	if !strings.Contains(logs.String(), "GET /match/1 200") || strings.Contains(logs.String(), "secret") {
+		t.Errorf("Expected the request logged without its token, got: %q", logs.String())
+	}
+	if snapshot := metrics.Snapshot(); snapshot.Requests != 1 || snapshot.Failures != 0 || snapshot.Statuses[200] != 1 {
+		t.Errorf("Expected a successful request in the metrics, got: %+v", snapshot)
+	}
+}
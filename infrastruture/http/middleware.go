@@ -0,0 +1,142 @@
+package http
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// Middleware wraps the round-tripper sending the requests of an HttpClient.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to an http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// chain wraps transport with the middlewares, the first one being the outermost.
+func chain(transport http.RoundTripper, middlewares []Middleware) http.RoundTripper {
+	for n := len(middlewares) - 1; n >= 0; n-- {
+		transport = middlewares[n](transport)
+	}
+	return transport
+}
+
+// UserAgentMiddleware sets the User-Agent header of every request.
+func UserAgentMiddleware(userAgent string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set("User-Agent", userAgent)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// RequestIDMiddleware tags every request without one with a random X-Request-ID header, to correlate it
+// with the server logs. Retries of a request are tagged with a new ID.
+func RequestIDMiddleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(requestIDHeader) == "" {
+				req = req.Clone(req.Context())
+				req.Header.Set(requestIDHeader, uuid.NewString())
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// LoggingMiddleware logs the method, path, status and duration of every request. Headers and bodies,
+// and so the credentials, are never logged.
+func LoggingMiddleware(logger *log.Logger) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			elapsed := time.Since(start).Round(time.Millisecond)
+
+			id := req.Header.Get(requestIDHeader)
+			if err != nil {
+				logger.Printf("[HTTP] [ERROR] %s %s failed after %s: %s %s", req.Method, req.URL.Path, elapsed, err, id)
+				return nil, err
+			}
+			logger.Printf("[HTTP] [INFO] %s %s %d in %s %s", req.Method, req.URL.Path, resp.StatusCode, elapsed, id)
+			return resp, nil
+		})
+	}
+}
+
+// Metrics accumulates the requests sent through a MetricsMiddleware.
+type Metrics struct {
+	requests int
+	failures int           // Failures counts the requests without a response and the responses with an error status.
+	latency  time.Duration // Latency is the total time spent waiting for the response headers.
+	statuses map[int]int
+	mu       sync.Mutex
+}
+
+// MetricsSnapshot is a copy of the metrics at a point in time.
+type MetricsSnapshot struct {
+	Requests       int
+	Failures       int
+	AverageLatency time.Duration
+	Statuses       map[int]int // Statuses counts the responses by status code.
+}
+
+// Snapshot returns a copy of the current metrics.
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := MetricsSnapshot{Requests: m.requests, Failures: m.failures, Statuses: make(map[int]int, len(m.statuses))}
+	if m.requests > 0 {
+		snapshot.AverageLatency = m.latency / time.Duration(m.requests)
+	}
+	for status, count := range m.statuses {
+		snapshot.Statuses[status] = count
+	}
+	return snapshot
+}
+
+func (m *Metrics) record(status int, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.statuses == nil {
+		m.statuses = make(map[int]int)
+	}
+	m.requests++
+	m.latency += latency
+	if status == 0 || status >= 400 {
+		m.failures++
+	}
+	if status != 0 {
+		m.statuses[status]++
+	}
+}
+
+// MetricsMiddleware records every request in metrics.
+func MetricsMiddleware(metrics *Metrics) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+
+			status := 0
+			if err == nil {
+				status = resp.StatusCode
+			}
+			metrics.record(status, time.Since(start))
+			return resp, err
+		})
+	}
+}
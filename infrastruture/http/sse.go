@@ -0,0 +1,102 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+const (
+	eventStreamType  = "text/event-stream"
+	defaultEventName = "message"
+)
+
+var ErrNotEventStream = i.ErrNotEventStream
+
+// Subscribe opens a Server-Sent Events stream on the specified path and delivers its events until the stream ends
+// or ctx is done. It returns ErrNotEventStream when the server answers with anything but an event stream.
+// The stream is not bound by the client timeout, nor retried.
+func (h *HttpClient) Subscribe(ctx context.Context, path, authToken string) (<-chan i.ServerEvent, error) {
+	uri, err := h.buildURL(path)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", eventStreamType)
+	req.Header.Set("Cache-Control", "no-cache")
+
+	var resp *http.Response
+	if authToken != "" {
+		resp, err = h.send(req, WithBearerToken(authToken))
+	} else {
+		resp, err = h.send(req)
+	}
+
+	if err != nil {
+		return nil, transportError(ctx, err)
+	}
+
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		return nil, newAPIError(resp)
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err != nil || mediaType != eventStreamType {
+		resp.Body.Close()
+		return nil, ErrNotEventStream
+	}
+
+	events := make(chan i.ServerEvent)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+		readEvents(ctx, resp.Body, events)
+	}()
+	return events, nil
+}
+
+// readEvents parses the event stream of r into events until r ends or ctx is done.
+// Comments, event IDs and retry delays are ignored.
+func readEvents(ctx context.Context, r io.Reader, events chan<- i.ServerEvent) {
+	scanner := bufio.NewScanner(r)
+	name, data := "", []string(nil)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			// A blank line dispatches the event gathered so far.
+			if data != nil {
+				event := i.ServerEvent{Name: name, Data: strings.Join(data, "\n")}
+				if event.Name == "" {
+					event.Name = defaultEventName
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+			name, data = "", nil
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			name = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+}
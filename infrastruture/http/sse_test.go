@@ -0,0 +1,75 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// TestServerSentEvents contains all the tests related to event streams.
+func TestServerSentEvents(t *testing.T) {
+	t.Run("ServerSentEvents_Parse", testServerSentEvents_Parse)
+	t.Run("ServerSentEvents_Subscribe", testServerSentEvents_Subscribe)
+	t.Run("ServerSentEvents_NotEventStream", testServerSentEvents_NotEventStream)
+}
+
+// testServerSentEvents_Parse tests named, unnamed and multi-line events, skipping comments and unknown fields.
+func testServerSentEvents_Parse(t *testing.T) {
+	stream := ": keep-alive\n\nevent: match\ndata: {\"a\":1}\nid: 7\n\ndata: first\ndata:second\n\nretry: 100\n\n"
+	events := make(chan i.ServerEvent, 4)
+	readEvents(context.Background(), strings.NewReader(stream), events)
+	close(events)
+
+	var got []i.ServerEvent
+	for event := range events {
+		got = append(got, event)
+	}
+
+	expected := []i.ServerEvent{{Name: "match", Data: `{"a":1}`}, {Name: "message", Data: "first\nsecond"}}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got: %v", expected, got)
+	}
+}
+
+// testServerSentEvents_Subscribe tests that the events of a server stream are delivered until it ends.
+func testServerSentEvents_Subscribe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != eventStreamType || r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unexpected headers", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		fmt.Fprint(w, "event: match\ndata: found\n\n")
+	}))
+	defer server.Close()
+
+	events, err := NewHttpClient(server.URL).Subscribe(context.Background(), "/events", "token")
+	if err != nil {
+		t.Fatalf("Expected a stream, got error: %s", err)
+	}
+
+	if event := <-events; event.Name != "match" || event.Data != "found" {
+		t.Errorf("Expected the match event, got: %+v", event)
+	}
+	if _, ok := <-events; ok {
+		t.Errorf("Expected the channel to be closed with the stream")
+	}
+}
+
+// testServerSentEvents_NotEventStream tests that a plain answer is reported as ErrNotEventStream.
+func testServerSentEvents_NotEventStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, "{}")
+	}))
+	defer server.Close()
+
+	if _, err := NewHttpClient(server.URL).Subscribe(context.Background(), "/events", ""); err != ErrNotEventStream {
+		t.Errorf("Expected ErrNotEventStream, got: %v", err)
+	}
+}
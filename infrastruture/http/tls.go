@@ -0,0 +1,137 @@
+package http
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const pinPrefix = "sha256/"
+
+var (
+	ErrNoCACertificates  = errors.New("CA bundle contains no PEM certificate")
+	ErrInvalidPin        = errors.New("SPKI pin must be the base64 SHA-256 of a public key")
+	ErrPinMismatch       = errors.New("server certificate chain matches no SPKI pin")
+	ErrIncompleteKeyPair = errors.New("client certificate and key must be set together")
+	ErrUnknownTLSVersion = errors.New("unknown TLS version, expected 1.2 or 1.3")
+)
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSOptions define how the backend's certificate is verified and how the client authenticates.
+type TLSOptions struct {
+	CAFile         string   // CAFile is a PEM bundle of CAs trusted besides the system ones, for servers with a private CA.
+	Pins           []string // Pins are SPKI pins, "sha256/<base64>"; a certificate of the verified chain must match one.
+	ClientCertFile string   // ClientCertFile is the PEM certificate sent for mutual TLS, with ClientKeyFile.
+	ClientKeyFile  string   // ClientKeyFile is the PEM private key of ClientCertFile.
+	MinVersion     string   // MinVersion is the lowest TLS version accepted, "1.2" or "1.3", defaults to 1.2.
+}
+
+// ParseTLSVersion returns the TLS version with the given name: 1.2 or 1.3.
+func ParseTLSVersion(name string) (uint16, error) {
+	version, ok := tlsVersions[name]
+	if !ok {
+		return 0, ErrUnknownTLSVersion
+	}
+	return version, nil
+}
+
+// NewTLSConfig returns the TLS configuration of the options, loading the CA bundle and the client key pair.
+func NewTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if opts.MinVersion != "" {
+		version, err := ParseTLSVersion(opts.MinVersion)
+		if err != nil {
+			return nil, err
+		}
+		cfg.MinVersion = version
+	}
+
+	if opts.CAFile != "" {
+		pool, err := loadCAPool(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+
+	if (opts.ClientCertFile == "") != (opts.ClientKeyFile == "") {
+		return nil, ErrIncompleteKeyPair
+	}
+	if opts.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.ClientCertFile, opts.ClientKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(opts.Pins) > 0 {
+		pins, err := parsePins(opts.Pins)
+		if err != nil {
+			return nil, err
+		}
+		cfg.VerifyConnection = verifyPins(pins)
+	}
+	return cfg, nil
+}
+
+// SPKIPin returns the pin of the certificate's public key, in the format of TLSOptions.Pins.
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return pinPrefix + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// loadCAPool returns the system CAs with the ones of the PEM bundle at path.
+func loadCAPool(path string) (*x509.CertPool, error) {
+	bundle, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(bundle) {
+		return nil, fmt.Errorf("%w: %s", ErrNoCACertificates, path)
+	}
+	return pool, nil
+}
+
+// parsePins returns the set of the pins, accepting them with or without the sha256/ prefix.
+func parsePins(pins []string) (map[string]bool, error) {
+	set := make(map[string]bool, len(pins))
+	for _, pin := range pins {
+		hash, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(strings.TrimSpace(pin), pinPrefix))
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPin, pin)
+		}
+		set[pinPrefix+base64.StdEncoding.EncodeToString(hash)] = true
+	}
+	return set, nil
+}
+
+// verifyPins checks, after the usual verification of the chain, that a certificate of a verified chain is pinned.
+// Pinning a CA keeps working across the renewals of the server certificate.
+func verifyPins(pins map[string]bool) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		for _, chain := range cs.VerifiedChains {
+			for _, cert := range chain {
+				if pins[SPKIPin(cert)] {
+					return nil
+				}
+			}
+		}
+		return ErrPinMismatch
+	}
+}
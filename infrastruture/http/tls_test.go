@@ -0,0 +1,210 @@
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTLSServer starts a TLS server with its configuration modified by configure, and returns it with the path
+// of a PEM bundle holding its self-signed certificate.
+func newTLSServer(t *testing.T, configure func(*tls.Config)) (*httptest.Server, string) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0) // Rejected handshakes are expected.
+	server.TLS = &tls.Config{}
+	if configure != nil {
+		configure(server.TLS)
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	writePEM(t, caFile, "CERTIFICATE", server.Certificate().Raw)
+	return server, caFile
+}
+
+// newTLSClient returns a client of server using the TLS options, failing the test if they are invalid.
+func newTLSClient(t *testing.T, server *httptest.Server, opts TLSOptions) *HttpClient {
+	cfg, err := NewTLSConfig(opts)
+	if err != nil {
+		t.Fatalf("Expected a TLS config, got error: %s", err)
+	}
+	return NewHttpClient(server.URL, ClientWithRetries(0, 0), ClientWithTLS(cfg))
+}
+
+// TestTLS contains all the tests related to the TLS options of the HTTP client.
+func TestTLS(t *testing.T) {
+	t.Run("TLS_CustomCA", testTLS_CustomCA)
+	t.Run("TLS_PinMatch", testTLS_PinMatch)
+	t.Run("TLS_PinMismatch", testTLS_PinMismatch)
+	t.Run("TLS_MutualTLS", testTLS_MutualTLS)
+	t.Run("TLS_MinVersion", testTLS_MinVersion)
+	t.Run("TLS_InvalidOptions", testTLS_InvalidOptions)
+}
+
+// testTLS_CustomCA tests that a server signed by a private CA is only trusted with the CA bundle.
+func testTLS_CustomCA(t *testing.T) {
+	server, caFile := newTLSServer(t, nil)
+
+	untrusting := NewHttpClient(server.URL, ClientWithRetries(0, 0))
+	if _, err := untrusting.Get(context.Background(), "/", ""); err == nil {
+		t.Errorf("Expected an unknown CA to be rejected")
+	}
+
+	client := newTLSClient(t, server, TLSOptions{CAFile: caFile})
+	if _, err := client.Get(context.Background(), "/", ""); err != nil {
+		t.Errorf("Expected the CA bundle to be trusted, got error: %s", err)
+	}
+}
+
+// testTLS_PinMatch tests that a chain with a pinned key is accepted, with or without the pin prefix.
+func testTLS_PinMatch(t *testing.T) {
+	server, caFile := newTLSServer(t, nil)
+	pin := SPKIPin(server.Certificate())
+
+	for _, p := range []string{pin, pin[len(pinPrefix):]} {
+		client := newTLSClient(t, server, TLSOptions{CAFile: caFile, Pins: []string{otherPin(), p}})
+		if _, err := client.Get(context.Background(), "/", ""); err != nil {
+			t.Errorf("Expected the pin %q to match, got error: %s", p, err)
+		}
+	}
+}
+
+// testTLS_PinMismatch tests that a trusted chain without a pinned key is rejected.
+func testTLS_PinMismatch(t *testing.T) {
+	server, caFile := newTLSServer(t, nil)
+
+	client := newTLSClient(t, server, TLSOptions{CAFile: caFile, Pins: []string{otherPin()}})
+	if _, err := client.Get(context.Background(), "/", ""); !errors.Is(err, ErrPinMismatch) {
+		t.Errorf("Expected ErrPinMismatch, got: %v", err)
+	}
+}
+
+// testTLS_MutualTLS tests that a server requiring a client certificate accepts the configured one.
+func testTLS_MutualTLS(t *testing.T) {
+	certFile, keyFile, clientCert := newClientCertificate(t)
+	server, caFile := newTLSServer(t, func(cfg *tls.Config) {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		cfg.ClientCAs = x509.NewCertPool()
+		cfg.ClientCAs.AddCert(clientCert)
+	})
+
+	anonymous := newTLSClient(t, server, TLSOptions{CAFile: caFile})
+	if _, err := anonymous.Get(context.Background(), "/", ""); err == nil {
+		t.Errorf("Expected a client without certificate to be rejected")
+	}
+
+	client := newTLSClient(t, server, TLSOptions{CAFile: caFile, ClientCertFile: certFile, ClientKeyFile: keyFile})
+	if _, err := client.Get(context.Background(), "/", ""); err != nil {
+		t.Errorf("Expected the client certificate to be accepted, got error: %s", err)
+	}
+}
+
+// testTLS_MinVersion tests that a server capped below the minimum version is rejected.
+func testTLS_MinVersion(t *testing.T) {
+	server, caFile := newTLSServer(t, func(cfg *tls.Config) {
+		cfg.MaxVersion = tls.VersionTLS12
+	})
+
+	client := newTLSClient(t, server, TLSOptions{CAFile: caFile, MinVersion: "1.2"})
+	if _, err := client.Get(context.Background(), "/", ""); err != nil {
+		t.Errorf("Expected TLS 1.2 to be accepted, got error: %s", err)
+	}
+
+	strict := newTLSClient(t, server, TLSOptions{CAFile: caFile, MinVersion: "1.3"})
+	if _, err := strict.Get(context.Background(), "/", ""); err == nil {
+		t.Errorf("Expected TLS 1.2 to be rejected with a 1.3 minimum")
+	}
+}
+
+// testTLS_InvalidOptions tests that invalid options are reported before any connection.
+func testTLS_InvalidOptions(t *testing.T) {
+	emptyBundle := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(emptyBundle, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		opts TLSOptions
+		err  error
+	}{
+		"Pin":      {TLSOptions{Pins: []string{"sha256/short"}}, ErrInvalidPin},
+		"Version":  {TLSOptions{MinVersion: "1.1"}, ErrUnknownTLSVersion},
+		"KeyPair":  {TLSOptions{ClientCertFile: "client.pem"}, ErrIncompleteKeyPair},
+		"CABundle": {TLSOptions{CAFile: emptyBundle}, ErrNoCACertificates},
+	}
+	for name, c := range cases {
+		if _, err := NewTLSConfig(c.opts); !errors.Is(err, c.err) {
+			t.Errorf("%s: expected %v, got: %v", name, c.err, err)
+		}
+	}
+}
+
+// otherPin returns the pin of a key no certificate uses.
+func otherPin() string {
+	sum := sha256.Sum256([]byte("another key"))
+	return pinPrefix + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// newClientCertificate writes a self-signed client certificate and its key, and returns their paths with the certificate.
+func newClientCertificate(t *testing.T) (string, string, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "puzzle-me test client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile, cert
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -12,6 +12,7 @@ func actionFromInterface(a i.Action) *Action {
 		Id:        a.GetID().String(),
 		Direction: a.GetDirection(),
 		From:      cellPositionInterface(a.RetriveFrom()),
+		Seq:       a.GetSeq(),
 	}
 }
 
@@ -40,3 +41,8 @@ func (x *Action) GetID() uuid.UUID {
 func (x *Action) SetID(i uuid.UUID) {
 	x.Id = i.String()
 }
+
+// SetSeq implements game.Action.
+func (x *Action) SetSeq(s int64) {
+	x.Seq = s
+}
@@ -201,9 +201,11 @@ type Player struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Pos    *Pos   `protobuf:"bytes,1,opt,name=pos,proto3" json:"pos,omitempty"`
-	Reward int32  `protobuf:"varint,2,opt,name=reward,proto3" json:"reward,omitempty"`
-	Id     string `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`
+	Pos           *Pos   `protobuf:"bytes,1,opt,name=pos,proto3" json:"pos,omitempty"`
+	Reward        int32  `protobuf:"varint,2,opt,name=reward,proto3" json:"reward,omitempty"`
+	Id            string `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`
+	LastActionSeq int64  `protobuf:"varint,4,opt,name=last_action_seq,json=lastActionSeq,proto3" json:"last_action_seq,omitempty"`
+	RatingChange  int32  `protobuf:"varint,5,opt,name=rating_change,json=ratingChange,proto3" json:"rating_change,omitempty"`
 }
 
 func (x *Player) Reset() {
@@ -257,14 +259,31 @@ func (x *Player) GetId() string {
 	return ""
 }
 
+func (x *Player) GetLastActionSeq() int64 {
+	if x != nil {
+		return x.LastActionSeq
+	}
+	return 0
+}
+
+func (x *Player) GetRatingChange() int32 {
+	if x != nil {
+		return x.RatingChange
+	}
+	return 0
+}
+
 type GameState struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Version int64     `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
-	Maze    *Maze     `protobuf:"bytes,2,opt,name=maze,proto3" json:"maze,omitempty"`
-	Players []*Player `protobuf:"bytes,3,rep,name=players,proto3" json:"players,omitempty"`
+	Version   int64     `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Maze      *Maze     `protobuf:"bytes,2,opt,name=maze,proto3" json:"maze,omitempty"`
+	Players   []*Player `protobuf:"bytes,3,rep,name=players,proto3" json:"players,omitempty"`
+	StartedAt int64     `protobuf:"varint,4,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	EndedAt   int64     `protobuf:"varint,5,opt,name=ended_at,json=endedAt,proto3" json:"ended_at,omitempty"`
+	FogOfWar  bool      `protobuf:"varint,6,opt,name=fog_of_war,json=fogOfWar,proto3" json:"fog_of_war,omitempty"`
 }
 
 func (x *GameState) Reset() {
@@ -318,6 +337,27 @@ func (x *GameState) GetPlayers() []*Player {
 	return nil
 }
 
+func (x *GameState) GetStartedAt() int64 {
+	if x != nil {
+		return x.StartedAt
+	}
+	return 0
+}
+
+func (x *GameState) GetEndedAt() int64 {
+	if x != nil {
+		return x.EndedAt
+	}
+	return 0
+}
+
+func (x *GameState) GetFogOfWar() bool {
+	if x != nil {
+		return x.FogOfWar
+	}
+	return false
+}
+
 type Action struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -326,6 +366,7 @@ type Action struct {
 	Id        string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	Direction string `protobuf:"bytes,2,opt,name=direction,proto3" json:"direction,omitempty"`
 	From      *Pos   `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	Seq       int64  `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`
 }
 
 func (x *Action) Reset() {
@@ -379,6 +420,99 @@ func (x *Action) GetFrom() *Pos {
 	return nil
 }
 
+func (x *Action) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type ReplayFrame struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RecordedAt int64 `protobuf:"varint,1,opt,name=recorded_at,json=recordedAt,proto3" json:"recorded_at,omitempty"`
+	// Types that are assignable to Entry:
+	//	*ReplayFrame_State
+	//	*ReplayFrame_Action
+	Entry isReplayFrame_Entry `protobuf_oneof:"entry"`
+}
+
+func (x *ReplayFrame) Reset() {
+	*x = ReplayFrame{}
+	mi := &file_game_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplayFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplayFrame) ProtoMessage() {}
+
+func (x *ReplayFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplayFrame.ProtoReflect.Descriptor instead.
+func (*ReplayFrame) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ReplayFrame) GetRecordedAt() int64 {
+	if x != nil {
+		return x.RecordedAt
+	}
+	return 0
+}
+
+func (m *ReplayFrame) GetEntry() isReplayFrame_Entry {
+	if m != nil {
+		return m.Entry
+	}
+	return nil
+}
+
+func (x *ReplayFrame) GetState() *GameState {
+	if x, ok := x.GetEntry().(*ReplayFrame_State); ok {
+		return x.State
+	}
+	return nil
+}
+
+func (x *ReplayFrame) GetAction() *Action {
+	if x, ok := x.GetEntry().(*ReplayFrame_Action); ok {
+		return x.Action
+	}
+	return nil
+}
+
+type isReplayFrame_Entry interface {
+	isReplayFrame_Entry()
+}
+
+type ReplayFrame_State struct {
+	State *GameState `protobuf:"bytes,2,opt,name=state,proto3,oneof"`
+}
+
+type ReplayFrame_Action struct {
+	Action *Action `protobuf:"bytes,3,opt,name=action,proto3,oneof"`
+}
+
+func (*ReplayFrame_State) isReplayFrame_Entry() {}
+
+func (*ReplayFrame_Action) isReplayFrame_Entry() {}
+
 type Maze_Row struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -389,7 +523,7 @@ type Maze_Row struct {
 
 func (x *Maze_Row) Reset() {
 	*x = Maze_Row{}
-	mi := &file_game_proto_msgTypes[6]
+	mi := &file_game_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -401,7 +535,7 @@ func (x *Maze_Row) String() string {
 func (*Maze_Row) ProtoMessage() {}
 
 func (x *Maze_Row) ProtoReflect() protoreflect.Message {
-	mi := &file_game_proto_msgTypes[6]
+	mi := &file_game_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -445,24 +579,44 @@ var file_game_proto_rawDesc = []byte{
 	0x65, 0x6c, 0x6c, 0x52, 0x05, 0x63, 0x65, 0x6c, 0x6c, 0x73, 0x22, 0x29, 0x0a, 0x03, 0x50, 0x6f,
 	0x73, 0x12, 0x10, 0x0a, 0x03, 0x72, 0x6f, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03,
 	0x72, 0x6f, 0x77, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x6f, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05,
-	0x52, 0x03, 0x63, 0x6f, 0x6c, 0x22, 0x4b, 0x0a, 0x06, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x12,
-	0x19, 0x0a, 0x03, 0x70, 0x6f, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x07, 0x2e, 0x70,
-	0x62, 0x2e, 0x50, 0x6f, 0x73, 0x52, 0x03, 0x70, 0x6f, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65,
-	0x77, 0x61, 0x72, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x72, 0x65, 0x77, 0x61,
-	0x72, 0x64, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02,
-	0x69, 0x64, 0x22, 0x69, 0x0a, 0x09, 0x47, 0x61, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12,
-	0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
-	0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x04, 0x6d, 0x61, 0x7a,
-	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x08, 0x2e, 0x70, 0x62, 0x2e, 0x4d, 0x61, 0x7a,
-	0x65, 0x52, 0x04, 0x6d, 0x61, 0x7a, 0x65, 0x12, 0x24, 0x0a, 0x07, 0x70, 0x6c, 0x61, 0x79, 0x65,
-	0x72, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x70, 0x62, 0x2e, 0x50, 0x6c,
-	0x61, 0x79, 0x65, 0x72, 0x52, 0x07, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x22, 0x53, 0x0a,
-	0x06, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63,
-	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x64, 0x69, 0x72, 0x65,
-	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1b, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x07, 0x2e, 0x70, 0x62, 0x2e, 0x50, 0x6f, 0x73, 0x52, 0x04, 0x66, 0x72,
-	0x6f, 0x6d, 0x42, 0x06, 0x5a, 0x04, 0x2e, 0x3b, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x52, 0x03, 0x63, 0x6f, 0x6c, 0x22, 0x98, 0x01, 0x0a, 0x06, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72,
+	0x12, 0x19, 0x0a, 0x03, 0x70, 0x6f, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x07, 0x2e,
+	0x70, 0x62, 0x2e, 0x50, 0x6f, 0x73, 0x52, 0x03, 0x70, 0x6f, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x72,
+	0x65, 0x77, 0x61, 0x72, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x72, 0x65, 0x77,
+	0x61, 0x72, 0x64, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x02, 0x69, 0x64, 0x12, 0x26, 0x0a, 0x0f, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x61, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x5f, 0x73, 0x65, 0x71, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x6c, 0x61,
+	0x73, 0x74, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x71, 0x12, 0x23, 0x0a, 0x0d, 0x72,
+	0x61, 0x74, 0x69, 0x6e, 0x67, 0x5f, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x0c, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65,
+	0x22, 0xc1, 0x01, 0x0a, 0x09, 0x47, 0x61, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x18,
+	0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x04, 0x6d, 0x61, 0x7a, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x08, 0x2e, 0x70, 0x62, 0x2e, 0x4d, 0x61, 0x7a, 0x65,
+	0x52, 0x04, 0x6d, 0x61, 0x7a, 0x65, 0x12, 0x24, 0x0a, 0x07, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x70, 0x62, 0x2e, 0x50, 0x6c, 0x61,
+	0x79, 0x65, 0x72, 0x52, 0x07, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x12, 0x1d, 0x0a, 0x0a,
+	0x73, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x65,
+	0x6e, 0x64, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x65,
+	0x6e, 0x64, 0x65, 0x64, 0x41, 0x74, 0x12, 0x1c, 0x0a, 0x0a, 0x66, 0x6f, 0x67, 0x5f, 0x6f, 0x66,
+	0x5f, 0x77, 0x61, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x66, 0x6f, 0x67, 0x4f,
+	0x66, 0x57, 0x61, 0x72, 0x22, 0x65, 0x0a, 0x06, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0e,
+	0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1c,
+	0x0a, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1b, 0x0a, 0x04,
+	0x66, 0x72, 0x6f, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x07, 0x2e, 0x70, 0x62, 0x2e,
+	0x50, 0x6f, 0x73, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x73, 0x65, 0x71, 0x22, 0x84, 0x01, 0x0a, 0x0b,
+	0x52, 0x65, 0x70, 0x6c, 0x61, 0x79, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x72,
+	0x65, 0x63, 0x6f, 0x72, 0x64, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0a, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x65, 0x64, 0x41, 0x74, 0x12, 0x25, 0x0a, 0x05,
+	0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x62,
+	0x2e, 0x47, 0x61, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x48, 0x00, 0x52, 0x05, 0x73, 0x74,
+	0x61, 0x74, 0x65, 0x12, 0x24, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x70, 0x62, 0x2e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x48,
+	0x00, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x07, 0x0a, 0x05, 0x65, 0x6e, 0x74,
+	0x72, 0x79, 0x42, 0x06, 0x5a, 0x04, 0x2e, 0x3b, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x33,
 }
 
@@ -478,28 +632,31 @@ func file_game_proto_rawDescGZIP() []byte {
 	return file_game_proto_rawDescData
 }
 
-var file_game_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_game_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_game_proto_goTypes = []any{
-	(*Cell)(nil),      // 0: pb.Cell
-	(*Maze)(nil),      // 1: pb.Maze
-	(*Pos)(nil),       // 2: pb.Pos
-	(*Player)(nil),    // 3: pb.Player
-	(*GameState)(nil), // 4: pb.GameState
-	(*Action)(nil),    // 5: pb.Action
-	(*Maze_Row)(nil),  // 6: pb.Maze.Row
+	(*Cell)(nil),        // 0: pb.Cell
+	(*Maze)(nil),        // 1: pb.Maze
+	(*Pos)(nil),         // 2: pb.Pos
+	(*Player)(nil),      // 3: pb.Player
+	(*GameState)(nil),   // 4: pb.GameState
+	(*Action)(nil),      // 5: pb.Action
+	(*ReplayFrame)(nil), // 6: pb.ReplayFrame
+	(*Maze_Row)(nil),    // 7: pb.Maze.Row
 }
 var file_game_proto_depIdxs = []int32{
-	6, // 0: pb.Maze.grid:type_name -> pb.Maze.Row
+	7, // 0: pb.Maze.grid:type_name -> pb.Maze.Row
 	2, // 1: pb.Player.pos:type_name -> pb.Pos
 	1, // 2: pb.GameState.maze:type_name -> pb.Maze
 	3, // 3: pb.GameState.players:type_name -> pb.Player
 	2, // 4: pb.Action.from:type_name -> pb.Pos
-	0, // 5: pb.Maze.Row.cells:type_name -> pb.Cell
-	6, // [6:6] is the sub-list for method output_type
-	6, // [6:6] is the sub-list for method input_type
-	6, // [6:6] is the sub-list for extension type_name
-	6, // [6:6] is the sub-list for extension extendee
-	0, // [0:6] is the sub-list for field type_name
+	4, // 5: pb.ReplayFrame.state:type_name -> pb.GameState
+	5, // 6: pb.ReplayFrame.action:type_name -> pb.Action
+	0, // 7: pb.Maze.Row.cells:type_name -> pb.Cell
+	8, // [8:8] is the sub-list for method output_type
+	8, // [8:8] is the sub-list for method input_type
+	8, // [8:8] is the sub-list for extension type_name
+	8, // [8:8] is the sub-list for extension extendee
+	0, // [0:8] is the sub-list for field type_name
 }
 
 func init() { file_game_proto_init() }
@@ -507,13 +664,17 @@ func file_game_proto_init() {
 	if File_game_proto != nil {
 		return
 	}
+	file_game_proto_msgTypes[6].OneofWrappers = []any{
+		(*ReplayFrame_State)(nil),
+		(*ReplayFrame_Action)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_game_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   7,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -1,10 +1,23 @@
 package gamepb
 
-import "github.com/sofc-t/puzzle-client/service/i"
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/sofc-t/puzzle-client/service/i"
+)
 
 var _ i.Maze = &Maze{}
 var _ i.GameState = &GameState{}
 
+var (
+	ErrInvalidDirection         = errors.New("invalid direction")
+	ErrPositionOutOfBound       = errors.New("position is out of the maze bounds")
+	ErrMoveBlockedByWall        = errors.New("move is blocked by a wall")
+	ErrInvalidMove              = errors.New("invalid move")
+	ErrInvalidRewardProbability = errors.New("reward probability must be between 0 and 1")
+)
+
 // Maze-related functions
 
 // Height implements game.Maze.
@@ -14,37 +27,101 @@ func (x *Maze) Height() int {
 
 // Width implements game.Maze.
 func (x *Maze) Width() int {
-	panic("unimplemented")
+	if len(x.Grid) == 0 {
+		return 0
+	}
+	return len(x.Grid[0].Cells)
 }
 
 // GetTotalReward implements game.Maze.
 func (x *Maze) GetTotalReward() int32 {
-	panic("unimplemented")
+	var total int32
+	for _, row := range x.Grid {
+		for _, cell := range row.Cells {
+			total += cell.GetReward()
+		}
+	}
+	return total
 }
 
 // NewValidMove implements game.Maze.
-func (x *Maze) NewValidMove(i.CellPosition, string) (i.Move, error) {
-	panic("unimplemented")
+// It returns the move one cell towards direction, which is one of "North", "South", "East" or "West".
+func (x *Maze) NewValidMove(from i.CellPosition, direction string) (i.Move, error) {
+	if from == nil || !x.InBound(int(from.GetRow()), int(from.GetCol())) {
+		return nil, ErrPositionOutOfBound
+	}
+
+	dRow, dCol, ok := directionDelta(direction)
+	if !ok {
+		return nil, ErrInvalidDirection
+	}
+
+	to := &Pos{Row: from.GetRow() + dRow, Col: from.GetCol() + dCol}
+	if !x.InBound(int(to.Row), int(to.Col)) {
+		return nil, ErrPositionOutOfBound
+	}
+
+	move := NewMove(from, to)
+	if !x.IsValidMove(move) {
+		return nil, ErrMoveBlockedByWall
+	}
+	return move, nil
 }
 
 // InBound implements game.Maze.
 func (x *Maze) InBound(row int, col int) bool {
-	panic("unimplemented")
+	return row >= 0 && row < x.Height() && col >= 0 && col < len(x.Grid[row].Cells)
 }
 
 // IsValidMove implements game.Maze.
+// A move is valid when it goes to an adjacent cell inside the maze and no wall stands between the cells.
 func (x *Maze) IsValidMove(move i.Move) bool {
-	panic("unimplemented")
+	if move == nil || move.From() == nil || move.To() == nil {
+		return false
+	}
+
+	from, to := move.From(), move.To()
+	if !x.InBound(int(from.GetRow()), int(from.GetCol())) || !x.InBound(int(to.GetRow()), int(to.GetCol())) {
+		return false
+	}
+
+	fromCell := x.Grid[from.GetRow()].Cells[from.GetCol()]
+	toCell := x.Grid[to.GetRow()].Cells[to.GetCol()]
+	switch [2]int32{to.GetRow() - from.GetRow(), to.GetCol() - from.GetCol()} {
+	case [2]int32{-1, 0}:
+		return !fromCell.HasNorthWall() && !toCell.HasSouthWall()
+	case [2]int32{1, 0}:
+		return !fromCell.HasSouthWall() && !toCell.HasNorthWall()
+	case [2]int32{0, 1}:
+		return !fromCell.HasEastWall() && !toCell.HasWestWall()
+	case [2]int32{0, -1}:
+		return !fromCell.HasWestWall() && !toCell.HasEastWall()
+	default:
+		return false
+	}
 }
 
 // Move implements game.Maze.
+// It collects the reward of the destination cell and returns it.
 func (x *Maze) Move(move i.Move) (int32, error) {
-	panic("unimplemented")
+	if !x.IsValidMove(move) {
+		return 0, ErrInvalidMove
+	}
+
+	to := x.Grid[move.To().GetRow()].Cells[move.To().GetCol()]
+	reward := to.GetReward()
+	to.SetReward(0)
+	return reward, nil
 }
 
 // RemoveReward implements game.Maze.
 func (x *Maze) RemoveReward(pos i.CellPosition) error {
-	panic("unimplemented")
+	if pos == nil || !x.InBound(int(pos.GetRow()), int(pos.GetCol())) {
+		return ErrPositionOutOfBound
+	}
+
+	x.Grid[pos.GetRow()].Cells[pos.GetCol()].SetReward(0)
+	return nil
 }
 
 // RetriveGrid implements game.Maze.
@@ -61,12 +138,31 @@ func (x *Maze) RetriveGrid() [][]i.Cell {
 }
 
 // PopulateReward implements i.Maze.
+// Every cell gets RewardOne with probability RewardTypeProb and RewardTwo otherwise.
 func (x *Maze) PopulateReward(r struct {
 	RewardOne      int32
 	RewardTwo      int32
 	RewardTypeProb float32
 }) error {
-	panic("unimplemented")
+	return x.populateReward(r.RewardOne, r.RewardTwo, r.RewardTypeProb, rand.Float32)
+}
+
+// populateReward fills every cell of the maze with a reward, drawing from rnd.
+func (x *Maze) populateReward(rewardOne, rewardTwo int32, prob float32, rnd func() float32) error {
+	if prob < 0 || prob > 1 {
+		return ErrInvalidRewardProbability
+	}
+
+	for _, row := range x.Grid {
+		for _, cell := range row.Cells {
+			if rnd() < prob {
+				cell.SetReward(rewardOne)
+			} else {
+				cell.SetReward(rewardTwo)
+			}
+		}
+	}
+	return nil
 }
 
 // SetGrid implements game.Maze.
@@ -107,7 +203,7 @@ func (x *GameState) SetMaze(m i.Maze) {
 
 // SetPlayers implements game.GameState.
 func (x *GameState) SetPlayers(p []i.Player) {
-	players := make([]*Player, len(x.Players))
+	players := make([]*Player, 0, len(p))
 	for _, player := range p {
 		players = append(players, playerFromInterface(player))
 	}
@@ -119,8 +215,39 @@ func (x *GameState) SetVersion(v int64) {
 	x.Version = v
 }
 
+// SetStartedAt implements game.GameState.
+func (x *GameState) SetStartedAt(t int64) {
+	x.StartedAt = t
+}
+
+// SetEndedAt implements game.GameState.
+func (x *GameState) SetEndedAt(t int64) {
+	x.EndedAt = t
+}
+
+// SetFogOfWar implements game.GameState.
+func (x *GameState) SetFogOfWar(fog bool) {
+	x.FogOfWar = fog
+}
+
 // Helper functions for converting interfaces
 
+// directionDelta returns the row and column deltas of a direction.
+func directionDelta(direction string) (int32, int32, bool) {
+	switch direction {
+	case i.North:
+		return -1, 0, true
+	case i.South:
+		return 1, 0, true
+	case i.East:
+		return 0, 1, true
+	case i.West:
+		return 0, -1, true
+	default:
+		return 0, 0, false
+	}
+}
+
 // mazeFromInterface converts a game.Maze interface to a *Maze structure.
 func mazeFromInterface(m i.Maze) *Maze {
 	maze := &Maze{}
@@ -135,6 +262,9 @@ func gameStateFromInterface(gs i.GameState) *GameState {
 	gameState.SetVersion(gs.GetVersion())
 	gameState.SetMaze(gs.RetriveMaze())
 	gameState.SetPlayers(gs.RetrivePlayers())
+	gameState.SetStartedAt(gs.GetStartedAt())
+	gameState.SetEndedAt(gs.GetEndedAt())
+	gameState.SetFogOfWar(gs.GetFogOfWar())
 
 	return gameState
 }
@@ -0,0 +1,210 @@
+package gamepb
+
+import (
+	"testing"
+
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// newTestMaze returns a 2x3 maze:
+//
+//	+---+---+---+
+//	| 1   5 | 0 |
+//	+   +---+   +
+//	| 0   1   5 |
+//	+---+---+---+
+func newTestMaze() *Maze {
+	grid := [][]*Cell{
+		{
+			{NorthWall: true, WestWall: true, Reward: 1},
+			{NorthWall: true, SouthWall: true, EastWall: true, Reward: 5},
+			{NorthWall: true, EastWall: true, WestWall: true},
+		},
+		{
+			{SouthWall: true, WestWall: true},
+			{NorthWall: true, SouthWall: true, Reward: 1},
+			{SouthWall: true, EastWall: true, Reward: 5},
+		},
+	}
+
+	maze := &Maze{}
+	for _, row := range grid {
+		maze.Grid = append(maze.Grid, &Maze_Row{Cells: row})
+	}
+	return maze
+}
+
+// TestMaze contains all the tests related to the maze rules.
+func TestMaze(t *testing.T) {
+	t.Run("Maze_Dimensions", testMaze_Dimensions)
+	t.Run("Maze_InBound", testMaze_InBound)
+	t.Run("Maze_NewValidMove", testMaze_NewValidMove)
+	t.Run("Maze_IsValidMove", testMaze_IsValidMove)
+	t.Run("Maze_Move", testMaze_Move)
+	t.Run("Maze_RemoveReward", testMaze_RemoveReward)
+	t.Run("Maze_PopulateReward", testMaze_PopulateReward)
+}
+
+// testMaze_Dimensions tests the height and width of empty and filled mazes.
+func testMaze_Dimensions(t *testing.T) {
+	maze := newTestMaze()
+	if maze.Height() != 2 || maze.Width() != 3 {
+		t.Errorf("Expected 2x3 maze, got %dx%d", maze.Height(), maze.Width())
+	}
+
+	empty := &Maze{}
+	if empty.Height() != 0 || empty.Width() != 0 {
+		t.Errorf("Expected empty maze, got %dx%d", empty.Height(), empty.Width())
+	}
+}
+
+// testMaze_InBound tests positions inside and outside of the maze.
+func testMaze_InBound(t *testing.T) {
+	maze := newTestMaze()
+	cases := []struct {
+		row, col int
+		expected bool
+	}{
+		{0, 0, true},
+		{1, 2, true},
+		{-1, 0, false},
+		{0, -1, false},
+		{2, 0, false},
+		{0, 3, false},
+	}
+
+	for _, c := range cases {
+		if maze.InBound(c.row, c.col) != c.expected {
+			t.Errorf("Expected InBound(%d, %d) to be %t", c.row, c.col, c.expected)
+		}
+	}
+}
+
+// testMaze_NewValidMove tests creating moves in every direction, through walls and out of the maze.
+func testMaze_NewValidMove(t *testing.T) {
+	maze := newTestMaze()
+	cases := []struct {
+		from      *Pos
+		direction string
+		to        *Pos
+		err       error
+	}{
+		{&Pos{Row: 0, Col: 0}, i.East, &Pos{Row: 0, Col: 1}, nil},
+		{&Pos{Row: 0, Col: 0}, i.South, &Pos{Row: 1, Col: 0}, nil},
+		{&Pos{Row: 1, Col: 2}, i.North, &Pos{Row: 0, Col: 2}, nil},
+		{&Pos{Row: 1, Col: 1}, i.West, &Pos{Row: 1, Col: 0}, nil},
+		{&Pos{Row: 0, Col: 1}, i.East, nil, ErrMoveBlockedByWall},
+		{&Pos{Row: 0, Col: 1}, i.South, nil, ErrMoveBlockedByWall},
+		{&Pos{Row: 0, Col: 0}, i.North, nil, ErrPositionOutOfBound},
+		{&Pos{Row: 5, Col: 5}, i.North, nil, ErrPositionOutOfBound},
+		{&Pos{Row: 0, Col: 0}, "Up", nil, ErrInvalidDirection},
+	}
+
+	for _, c := range cases {
+		move, err := maze.NewValidMove(c.from, c.direction)
+		if err != c.err {
+			t.Errorf("Expected error %v moving %s from %v, got: %v", c.err, c.direction, c.from, err)
+			continue
+		}
+
+		if c.to != nil && (move.To().GetRow() != c.to.Row || move.To().GetCol() != c.to.Col) {
+			t.Errorf("Expected move %s from %v to reach %v, got: %v", c.direction, c.from, c.to, move.To())
+		}
+	}
+}
+
+// testMaze_IsValidMove tests moves that skip cells or stand still.
+func testMaze_IsValidMove(t *testing.T) {
+	maze := newTestMaze()
+	cases := []struct {
+		move     i.Move
+		expected bool
+	}{
+		{NewMove(&Pos{Row: 1, Col: 0}, &Pos{Row: 1, Col: 1}), true},
+		{NewMove(&Pos{Row: 1, Col: 0}, &Pos{Row: 1, Col: 2}), false},
+		{NewMove(&Pos{Row: 1, Col: 0}, &Pos{Row: 0, Col: 1}), false},
+		{NewMove(&Pos{Row: 1, Col: 0}, &Pos{Row: 1, Col: 0}), false},
+		{NewMove(&Pos{Row: 1, Col: 0}, &Pos{Row: 2, Col: 0}), false},
+		{nil, false},
+	}
+
+	for _, c := range cases {
+		if maze.IsValidMove(c.move) != c.expected {
+			t.Errorf("Expected IsValidMove(%v) to be %t", c.move, c.expected)
+		}
+	}
+}
+
+// testMaze_Move tests that moving collects the reward of the destination cell once.
+func testMaze_Move(t *testing.T) {
+	maze := newTestMaze()
+	total := maze.GetTotalReward()
+	if total != 12 {
+		t.Errorf("Expected total reward 12, got: %d", total)
+	}
+
+	move, _ := maze.NewValidMove(&Pos{Row: 0, Col: 0}, i.East)
+	reward, err := maze.Move(move)
+	if err != nil || reward != 5 {
+		t.Errorf("Expected reward 5, got: %d, error: %v", reward, err)
+	}
+
+	if maze.GetTotalReward() != total-5 {
+		t.Errorf("Expected total reward %d after move, got: %d", total-5, maze.GetTotalReward())
+	}
+
+	reward, err = maze.Move(move)
+	if err != nil || reward != 0 {
+		t.Errorf("Expected no reward on the second move, got: %d, error: %v", reward, err)
+	}
+
+	_, err = maze.Move(NewMove(&Pos{Row: 0, Col: 1}, &Pos{Row: 0, Col: 2}))
+	if err != ErrInvalidMove {
+		t.Errorf("Expected error %s, got: %v", ErrInvalidMove, err)
+	}
+}
+
+// testMaze_RemoveReward tests removing rewards inside and outside of the maze.
+func testMaze_RemoveReward(t *testing.T) {
+	maze := newTestMaze()
+	if err := maze.RemoveReward(&Pos{Row: 1, Col: 2}); err != nil {
+		t.Errorf("Expected reward to be removed, got error: %s", err)
+	}
+
+	if maze.Grid[1].Cells[2].Reward != 0 || maze.GetTotalReward() != 7 {
+		t.Errorf("Expected reward to be removed, got total: %d", maze.GetTotalReward())
+	}
+
+	if err := maze.RemoveReward(&Pos{Row: 2, Col: 0}); err != ErrPositionOutOfBound {
+		t.Errorf("Expected error %s, got: %v", ErrPositionOutOfBound, err)
+	}
+}
+
+// testMaze_PopulateReward tests that every cell gets one of the two rewards.
+func testMaze_PopulateReward(t *testing.T) {
+	maze := newTestMaze()
+	draws := []float32{0.1, 0.9, 0.1, 0.9, 0.9, 0.1}
+	next := func() float32 {
+		d := draws[0]
+		draws = draws[1:]
+		return d
+	}
+
+	if err := maze.populateReward(1, 5, 0.5, next); err != nil {
+		t.Errorf("Expected rewards to be populated, got error: %s", err)
+		t.FailNow()
+	}
+
+	if maze.GetTotalReward() != 3*1+3*5 {
+		t.Errorf("Expected total reward %d, got: %d", 3*1+3*5, maze.GetTotalReward())
+	}
+
+	err := maze.PopulateReward(struct {
+		RewardOne      int32
+		RewardTwo      int32
+		RewardTypeProb float32
+	}{1, 5, 1.5})
+	if err != ErrInvalidRewardProbability {
+		t.Errorf("Expected error %s, got: %v", ErrInvalidRewardProbability, err)
+	}
+}
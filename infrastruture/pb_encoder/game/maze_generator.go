@@ -0,0 +1,295 @@
+package gamepb
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// MazeAlgorithm selects the algorithm carving the passages of a generated maze.
+type MazeAlgorithm int
+
+const (
+	RecursiveBacktracker MazeAlgorithm = iota // Long winding corridors with few branches.
+	Prim                                      // Many short dead ends branching from the start.
+	Kruskal                                   // Uniform texture without a visible origin.
+)
+
+var (
+	ErrInvalidMazeSize      = errors.New("maze height and width must be positive")
+	ErrUnknownMazeAlgorithm = errors.New("unknown maze algorithm")
+	ErrInvalidBraidFactor   = errors.New("braid factor must be between 0 and 1")
+)
+
+var mazeAlgorithms = map[string]MazeAlgorithm{
+	"backtracker": RecursiveBacktracker,
+	"prim":        Prim,
+	"kruskal":     Kruskal,
+}
+
+// ParseMazeAlgorithm returns the algorithm with the given name: backtracker, prim or kruskal.
+func ParseMazeAlgorithm(name string) (MazeAlgorithm, error) {
+	algorithm, ok := mazeAlgorithms[name]
+	if !ok {
+		return 0, ErrUnknownMazeAlgorithm
+	}
+	return algorithm, nil
+}
+
+type MazeGeneratorOption func(*MazeGenerator)
+
+// MazeGenerator generates perfect mazes, where every cell is reachable by exactly one path,
+// optionally braided into loopy mazes. The same seed always generates the same mazes.
+type MazeGenerator struct {
+	rnd       *rand.Rand
+	algorithm MazeAlgorithm
+	braid     float64 // Braid is the probability of a dead end to be opened into a loop.
+}
+
+// NewMazeGenerator returns a new MazeGenerator seeded with seed, using the recursive backtracker by default.
+func NewMazeGenerator(seed int64, options ...MazeGeneratorOption) *MazeGenerator {
+	g := &MazeGenerator{rnd: rand.New(rand.NewSource(seed))}
+	for _, opt := range options {
+		opt(g)
+	}
+	return g
+}
+
+// cellCoord is a cell of the grid being carved.
+type cellCoord struct{ row, col int }
+
+// passage is the wall between a cell and its neighbour in a direction.
+type passage struct {
+	from      cellCoord
+	direction string
+}
+
+// Generate returns a new maze of the given size.
+func (g *MazeGenerator) Generate(height, width int) (*Maze, error) {
+	if height <= 0 || width <= 0 {
+		return nil, ErrInvalidMazeSize
+	}
+	if g.braid < 0 || g.braid > 1 {
+		return nil, ErrInvalidBraidFactor
+	}
+
+	maze := closedMaze(height, width)
+	switch g.algorithm {
+	case RecursiveBacktracker:
+		g.backtrack(maze)
+	case Prim:
+		g.prim(maze)
+	case Kruskal:
+		g.kruskal(maze)
+	default:
+		return nil, ErrUnknownMazeAlgorithm
+	}
+
+	if g.braid > 0 {
+		g.braidDeadEnds(maze)
+	}
+	return maze, nil
+}
+
+// PopulateReward fills every cell of m like Maze.PopulateReward, drawing from the generator's seeded source.
+func (g *MazeGenerator) PopulateReward(m *Maze, r struct {
+	RewardOne      int32
+	RewardTwo      int32
+	RewardTypeProb float32
+}) error {
+	return m.populateReward(r.RewardOne, r.RewardTwo, r.RewardTypeProb, g.rnd.Float32)
+}
+
+// backtrack carves the maze with a depth-first search, backtracking from dead ends.
+func (g *MazeGenerator) backtrack(m *Maze) {
+	visited := map[cellCoord]bool{{0, 0}: true}
+	stack := []cellCoord{{0, 0}}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+
+		unvisited := make([]passage, 0, 4)
+		for _, p := range m.passages(current) {
+			if !visited[p.to()] {
+				unvisited = append(unvisited, p)
+			}
+		}
+
+		if len(unvisited) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		p := unvisited[g.rnd.Intn(len(unvisited))]
+		m.carve(p)
+		visited[p.to()] = true
+		stack = append(stack, p.to())
+	}
+}
+
+// prim grows the maze from a random cell, carving a random wall of its frontier at every step.
+func (g *MazeGenerator) prim(m *Maze) {
+	start := cellCoord{g.rnd.Intn(m.Height()), g.rnd.Intn(m.Width())}
+	visited := map[cellCoord]bool{start: true}
+	frontier := m.passages(start)
+	for len(frontier) > 0 {
+		n := g.rnd.Intn(len(frontier))
+		p := frontier[n]
+		frontier[n] = frontier[len(frontier)-1]
+		frontier = frontier[:len(frontier)-1]
+
+		if visited[p.to()] {
+			continue
+		}
+
+		m.carve(p)
+		visited[p.to()] = true
+		frontier = append(frontier, m.passages(p.to())...)
+	}
+}
+
+// kruskal carves the walls in random order when they join two unconnected sets of cells.
+func (g *MazeGenerator) kruskal(m *Maze) {
+	parent := make(map[cellCoord]cellCoord)
+	var find func(c cellCoord) cellCoord
+	find = func(c cellCoord) cellCoord {
+		p, ok := parent[c]
+		if !ok || p == c {
+			return c
+		}
+		root := find(p)
+		parent[c] = root
+		return root
+	}
+
+	// Only the east and south walls are listed so every wall appears once.
+	walls := make([]passage, 0, 2*m.Height()*m.Width())
+	for row := 0; row < m.Height(); row++ {
+		for col := 0; col < m.Width(); col++ {
+			for _, p := range m.passages(cellCoord{row, col}) {
+				if p.direction == i.East || p.direction == i.South {
+					walls = append(walls, p)
+				}
+			}
+		}
+	}
+
+	g.rnd.Shuffle(len(walls), func(a, b int) { walls[a], walls[b] = walls[b], walls[a] })
+	for _, p := range walls {
+		from, to := find(p.from), find(p.to())
+		if from == to {
+			continue
+		}
+		m.carve(p)
+		parent[from] = to
+	}
+}
+
+// braidDeadEnds opens each dead end into a loop with the braid probability, preferring walls towards other dead ends.
+func (g *MazeGenerator) braidDeadEnds(m *Maze) {
+	for row := 0; row < m.Height(); row++ {
+		for col := 0; col < m.Width(); col++ {
+			c := cellCoord{row, col}
+			if !m.isDeadEnd(c) || g.rnd.Float64() >= g.braid {
+				continue
+			}
+
+			walls := make([]passage, 0, 3)
+			for _, p := range m.passages(c) {
+				if m.hasWall(p) {
+					walls = append(walls, p)
+				}
+			}
+			if len(walls) == 0 {
+				continue
+			}
+
+			best := walls[g.rnd.Intn(len(walls))]
+			for _, p := range walls {
+				if m.isDeadEnd(p.to()) {
+					best = p
+					break
+				}
+			}
+			m.carve(best)
+		}
+	}
+}
+
+// closedMaze returns a maze of the given size with every wall standing.
+func closedMaze(height, width int) *Maze {
+	maze := &Maze{Grid: make([]*Maze_Row, height)}
+	for row := range maze.Grid {
+		maze.Grid[row] = &Maze_Row{Cells: make([]*Cell, width)}
+		for col := range maze.Grid[row].Cells {
+			maze.Grid[row].Cells[col] = &Cell{NorthWall: true, SouthWall: true, EastWall: true, WestWall: true}
+		}
+	}
+	return maze
+}
+
+// passages returns the passages from c to its neighbours inside the maze, in a fixed order.
+func (x *Maze) passages(c cellCoord) []passage {
+	passages := make([]passage, 0, 4)
+	for _, direction := range []string{i.North, i.East, i.South, i.West} {
+		p := passage{from: c, direction: direction}
+		to := p.to()
+		if x.InBound(to.row, to.col) {
+			passages = append(passages, p)
+		}
+	}
+	return passages
+}
+
+// carve removes the wall of the passage on both sides.
+func (x *Maze) carve(p passage) {
+	from, to := x.Grid[p.from.row].Cells[p.from.col], x.Grid[p.to().row].Cells[p.to().col]
+	switch p.direction {
+	case i.North:
+		from.NorthWall, to.SouthWall = false, false
+	case i.South:
+		from.SouthWall, to.NorthWall = false, false
+	case i.East:
+		from.EastWall, to.WestWall = false, false
+	case i.West:
+		from.WestWall, to.EastWall = false, false
+	}
+}
+
+// hasWall reports whether the passage is blocked.
+func (x *Maze) hasWall(p passage) bool {
+	return !x.IsValidMove(NewMove(&Pos{Row: int32(p.from.row), Col: int32(p.from.col)}, &Pos{Row: int32(p.to().row), Col: int32(p.to().col)}))
+}
+
+// isDeadEnd reports whether c has a single open side.
+func (x *Maze) isDeadEnd(c cellCoord) bool {
+	cell := x.Grid[c.row].Cells[c.col]
+	walls := 0
+	for _, wall := range []bool{cell.NorthWall, cell.SouthWall, cell.EastWall, cell.WestWall} {
+		if wall {
+			walls++
+		}
+	}
+	return walls == 3
+}
+
+// to returns the neighbour the passage leads to.
+func (p passage) to() cellCoord {
+	dRow, dCol, _ := directionDelta(p.direction)
+	return cellCoord{p.from.row + int(dRow), p.from.col + int(dCol)}
+}
+
+// MazeWithAlgorithm sets the algorithm used by the MazeGenerator.
+func MazeWithAlgorithm(a MazeAlgorithm) MazeGeneratorOption {
+	return func(g *MazeGenerator) {
+		g.algorithm = a
+	}
+}
+
+// MazeWithBraid sets the probability, between 0 and 1, of every dead end to be opened into a loop.
+// A braid of 1 removes every dead end; 0, the default, keeps the maze perfect.
+func MazeWithBraid(p float64) MazeGeneratorOption {
+	return func(g *MazeGenerator) {
+		g.braid = p
+	}
+}
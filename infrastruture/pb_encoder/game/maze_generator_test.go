@@ -0,0 +1,178 @@
+package gamepb
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var testAlgorithms = map[string]MazeAlgorithm{
+	"Backtracker": RecursiveBacktracker,
+	"Prim":        Prim,
+	"Kruskal":     Kruskal,
+}
+
+// TestMazeGenerator contains all the tests related to maze generation.
+func TestMazeGenerator(t *testing.T) {
+	for name, algorithm := range testAlgorithms {
+		t.Run("MazeGenerator_Perfect"+name, func(t *testing.T) { testMazeGenerator_Perfect(t, algorithm) })
+		t.Run("MazeGenerator_Seeded"+name, func(t *testing.T) { testMazeGenerator_Seeded(t, algorithm) })
+	}
+	t.Run("MazeGenerator_Braided", testMazeGenerator_Braided)
+	t.Run("MazeGenerator_InvalidSize", testMazeGenerator_InvalidSize)
+	t.Run("MazeGenerator_InvalidBraid", testMazeGenerator_InvalidBraid)
+	t.Run("MazeGenerator_PopulateReward", testMazeGenerator_PopulateReward)
+	t.Run("MazeGenerator_ParseAlgorithm", testMazeGenerator_ParseAlgorithm)
+}
+
+// testMazeGenerator_Perfect tests that every cell is reachable by exactly one path and the border is closed.
+func testMazeGenerator_Perfect(t *testing.T, algorithm MazeAlgorithm) {
+	maze, err := NewMazeGenerator(7, MazeWithAlgorithm(algorithm)).Generate(8, 13)
+	if err != nil {
+		t.Fatalf("Expected a maze, got error: %s", err)
+	}
+
+	if maze.Height() != 8 || maze.Width() != 13 {
+		t.Fatalf("Expected an 8x13 maze, got %dx%d", maze.Height(), maze.Width())
+	}
+
+	assertClosedBorder(t, maze)
+
+	// A connected maze with one passage less than cells has no loop.
+	if reached := reachableCells(maze); reached != 8*13 {
+		t.Errorf("Expected every cell to be reachable, got: %d", reached)
+	}
+	if open := openPassages(maze); open != 8*13-1 {
+		t.Errorf("Expected %d passages in a perfect maze, got: %d", 8*13-1, open)
+	}
+}
+
+// testMazeGenerator_Seeded tests that a seed always generates the same maze.
+func testMazeGenerator_Seeded(t *testing.T, algorithm MazeAlgorithm) {
+	first, _ := NewMazeGenerator(42, MazeWithAlgorithm(algorithm)).Generate(10, 10)
+	second, _ := NewMazeGenerator(42, MazeWithAlgorithm(algorithm)).Generate(10, 10)
+	other, _ := NewMazeGenerator(43, MazeWithAlgorithm(algorithm)).Generate(10, 10)
+
+	if !proto.Equal(first, second) {
+		t.Errorf("Expected the same seed to generate the same maze")
+	}
+	if proto.Equal(first, other) {
+		t.Errorf("Expected another seed to generate another maze")
+	}
+}
+
+// testMazeGenerator_Braided tests that a full braid removes every dead end and keeps the maze connected.
+func testMazeGenerator_Braided(t *testing.T) {
+	maze, err := NewMazeGenerator(3, MazeWithBraid(1)).Generate(9, 9)
+	if err != nil {
+		t.Fatalf("Expected a maze, got error: %s", err)
+	}
+
+	assertClosedBorder(t, maze)
+	for row := 0; row < maze.Height(); row++ {
+		for col := 0; col < maze.Width(); col++ {
+			if maze.isDeadEnd(cellCoord{row, col}) {
+				t.Errorf("Expected no dead end, got one at (%d, %d)", row, col)
+			}
+		}
+	}
+
+	if open := openPassages(maze); open <= 9*9-1 {
+		t.Errorf("Expected loops in a braided maze, got %d passages", open)
+	}
+	if reached := reachableCells(maze); reached != 9*9 {
+		t.Errorf("Expected every cell to be reachable, got: %d", reached)
+	}
+}
+
+// testMazeGenerator_InvalidSize tests that empty mazes are rejected.
+func testMazeGenerator_InvalidSize(t *testing.T) {
+	if _, err := NewMazeGenerator(1).Generate(0, 5); err != ErrInvalidMazeSize {
+		t.Errorf("Expected ErrInvalidMazeSize, got: %v", err)
+	}
+}
+
+// testMazeGenerator_InvalidBraid tests that braid factors outside [0, 1] are rejected.
+func testMazeGenerator_InvalidBraid(t *testing.T) {
+	if _, err := NewMazeGenerator(1, MazeWithBraid(1.5)).Generate(5, 5); err != ErrInvalidBraidFactor {
+		t.Errorf("Expected ErrInvalidBraidFactor, got: %v", err)
+	}
+}
+
+// testMazeGenerator_PopulateReward tests that rewards are drawn from the seeded source.
+func testMazeGenerator_PopulateReward(t *testing.T) {
+	rewards := struct {
+		RewardOne      int32
+		RewardTwo      int32
+		RewardTypeProb float32
+	}{1, 5, 0.5}
+
+	populated := func(seed int64) *Maze {
+		g := NewMazeGenerator(seed)
+		maze, _ := g.Generate(6, 6)
+		if err := g.PopulateReward(maze, rewards); err != nil {
+			t.Fatalf("Expected rewards, got error: %s", err)
+		}
+		return maze
+	}
+
+	first, second := populated(9), populated(9)
+	if !proto.Equal(first, second) || first.GetTotalReward() == 0 {
+		t.Errorf("Expected the same seed to populate the same rewards")
+	}
+}
+
+// testMazeGenerator_ParseAlgorithm tests the algorithm names.
+func testMazeGenerator_ParseAlgorithm(t *testing.T) {
+	if a, err := ParseMazeAlgorithm("kruskal"); err != nil || a != Kruskal {
+		t.Errorf("Expected Kruskal, got: %v, %v", a, err)
+	}
+	if _, err := ParseMazeAlgorithm("eller"); err != ErrUnknownMazeAlgorithm {
+		t.Errorf("Expected ErrUnknownMazeAlgorithm, got: %v", err)
+	}
+}
+
+func assertClosedBorder(t *testing.T, maze *Maze) {
+	t.Helper()
+	for row := 0; row < maze.Height(); row++ {
+		for col := 0; col < maze.Width(); col++ {
+			cell := maze.Grid[row].Cells[col]
+			if (row == 0 && !cell.NorthWall) || (row == maze.Height()-1 && !cell.SouthWall) ||
+				(col == 0 && !cell.WestWall) || (col == maze.Width()-1 && !cell.EastWall) {
+				t.Fatalf("Expected a closed border, got an opening at (%d, %d)", row, col)
+			}
+		}
+	}
+}
+
+// reachableCells counts the cells reachable from the top left cell.
+func reachableCells(maze *Maze) int {
+	visited := map[cellCoord]bool{{0, 0}: true}
+	queue := []cellCoord{{0, 0}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, p := range maze.passages(current) {
+			if !maze.hasWall(p) && !visited[p.to()] {
+				visited[p.to()] = true
+				queue = append(queue, p.to())
+			}
+		}
+	}
+	return len(visited)
+}
+
+// openPassages counts the passages between cells without a wall.
+func openPassages(maze *Maze) int {
+	open := 0
+	for row := 0; row < maze.Height(); row++ {
+		for col := 0; col < maze.Width(); col++ {
+			for _, p := range maze.passages(cellCoord{row, col}) {
+				if !maze.hasWall(p) {
+					open++
+				}
+			}
+		}
+	}
+	return open / 2
+}
@@ -0,0 +1,39 @@
+package gamepb
+
+import "github.com/sofc-t/puzzle-client/service/i"
+
+var _ i.Move = &Move{}
+
+// Move is a move between two cells of the maze.
+type Move struct {
+	from *Pos
+	to   *Pos
+}
+
+// NewMove returns a new Move from one cell position to another.
+func NewMove(from, to i.CellPosition) *Move {
+	return &Move{
+		from: cellPositionInterface(from),
+		to:   cellPositionInterface(to),
+	}
+}
+
+// From implements game.Move.
+func (m *Move) From() i.CellPosition {
+	return m.from
+}
+
+// SetFrom implements game.Move.
+func (m *Move) SetFrom(c i.CellPosition) {
+	m.from = cellPositionInterface(c)
+}
+
+// To implements game.Move.
+func (m *Move) To() i.CellPosition {
+	return m.to
+}
+
+// SetTo implements game.Move.
+func (m *Move) SetTo(c i.CellPosition) {
+	m.to = cellPositionInterface(c)
+}
@@ -9,9 +9,11 @@ var _ i.Player = &Player{}
 
 func playerFromInterface(player i.Player) *Player {
 	return &Player{
-		Pos:    cellPositionInterface(player.RetrivePos()),
-		Reward: player.GetReward(),
-		Id:     player.GetID().String(),
+		Pos:           cellPositionInterface(player.RetrivePos()),
+		Reward:        player.GetReward(),
+		Id:            player.GetID().String(),
+		LastActionSeq: player.GetLastActionSeq(),
+		RatingChange:  player.GetRatingChange(),
 	}
 }
 
@@ -40,3 +42,13 @@ func (x *Player) SetPos(p i.CellPosition) {
 func (x *Player) SetReward(r int32) {
 	x.Reward = r
 }
+
+// SetLastActionSeq implements game.Player.
+func (x *Player) SetLastActionSeq(s int64) {
+	x.LastActionSeq = s
+}
+
+// SetRatingChange implements game.Player.
+func (x *Player) SetRatingChange(r int32) {
+	x.RatingChange = r
+}
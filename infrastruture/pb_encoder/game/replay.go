@@ -0,0 +1,119 @@
+package gamepb
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sofc-t/puzzle-client/service/i"
+	"google.golang.org/protobuf/encoding/protodelim"
+)
+
+var _ i.GameRecorder = &Recorder{}
+var _ i.ReplayFrame = &ReplayFrame{}
+
+type RecorderOption func(*Recorder)
+
+// Recorder writes replay frames to a stream of varint length-prefixed ReplayFrame messages.
+//
+// The first frame is timestamped with now, the next ones with the time elapsed since on the monotonic local clock.
+// A server clock whose estimate changes while recording thus neither reorders the frames nor inserts gaps.
+type Recorder struct {
+	w         *bufio.Writer
+	closer    io.Closer
+	now       func() time.Time // Now timestamps the first frame, the local clock by default.
+	origin    time.Time        // Origin is the timestamp of the first frame.
+	startedAt time.Time        // StartedAt is the local time of the first frame, with its monotonic clock reading.
+	mu        sync.Mutex
+}
+
+// NewRecorder returns a new Recorder writing to w. w is closed by Close when it implements io.Closer.
+func NewRecorder(w io.Writer, options ...RecorderOption) *Recorder {
+	closer, _ := w.(io.Closer)
+	r := &Recorder{
+		w:      bufio.NewWriter(w),
+		closer: closer,
+		now:    time.Now,
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+	return r
+}
+
+// RecorderWithClock timestamps the frames from the server's time, the timebase of the recorded states.
+func RecorderWithClock(clock i.ServerClock) RecorderOption {
+	return func(r *Recorder) {
+		r.now = clock.ServerNow
+	}
+}
+
+// RecordState implements i.GameRecorder.
+func (r *Recorder) RecordState(gs i.GameState) error {
+	return r.write(&ReplayFrame{Entry: &ReplayFrame_State{State: gameStateFromInterface(gs)}})
+}
+
+// RecordAction implements i.GameRecorder.
+func (r *Recorder) RecordAction(a i.Action) error {
+	return r.write(&ReplayFrame{Entry: &ReplayFrame_Action{Action: actionFromInterface(a)}})
+}
+
+// Close implements i.GameRecorder.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.w.Flush(); err != nil {
+		return err
+	}
+	if r.closer != nil {
+		return r.closer.Close()
+	}
+	return nil
+}
+
+func (r *Recorder) write(frame *ReplayFrame) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.startedAt.IsZero() {
+		r.origin, r.startedAt = r.now(), time.Now()
+	}
+	frame.RecordedAt = r.origin.Add(time.Since(r.startedAt)).UnixNano() / int64(time.Millisecond)
+	_, err := protodelim.MarshalTo(r.w, frame)
+	return err
+}
+
+// ReadReplay reads every frame of a replay stream written by Recorder.
+func ReadReplay(r io.Reader) ([]i.ReplayFrame, error) {
+	reader := bufio.NewReader(r)
+	frames := make([]i.ReplayFrame, 0)
+	for {
+		frame := &ReplayFrame{}
+		err := protodelim.UnmarshalFrom(reader, frame)
+		if errors.Is(err, io.EOF) {
+			return frames, nil
+		} else if err != nil {
+			return nil, err
+		}
+		frames = append(frames, frame)
+	}
+}
+
+// RetriveState implements i.ReplayFrame.
+func (x *ReplayFrame) RetriveState() i.GameState {
+	if state := x.GetState(); state != nil {
+		return state
+	}
+	return nil
+}
+
+// RetriveAction implements i.ReplayFrame.
+func (x *ReplayFrame) RetriveAction() i.Action {
+	if action := x.GetAction(); action != nil {
+		return action
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package gamepb
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// TestReplay contains all the tests related to recording and reading replays.
+func TestReplay(t *testing.T) {
+	t.Run("Replay_RoundTrip", testReplay_RoundTrip)
+	t.Run("Replay_Truncated", testReplay_Truncated)
+	t.Run("Replay_Clock", testReplay_Clock)
+	t.Run("Replay_ClockSync", testReplay_ClockSync)
+}
+
+// testReplay_RoundTrip tests that recorded states and actions are read back in order.
+func testReplay_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	recorder := NewRecorder(&buf)
+
+	state := &GameState{Version: 7, Maze: newTestMaze(), Players: []*Player{{Id: uuid.NewString(), Pos: &Pos{Row: 1, Col: 2}, Reward: 6}}, FogOfWar: true}
+	action := &Action{Id: uuid.NewString(), Direction: i.East, From: &Pos{Row: 1, Col: 1}, Seq: 3}
+
+	if err := recorder.RecordState(state); err != nil {
+		t.Fatalf("Expected state to be recorded, got error: %s", err)
+	}
+	if err := recorder.RecordAction(action); err != nil {
+		t.Fatalf("Expected action to be recorded, got error: %s", err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Expected recorder to be closed, got error: %s", err)
+	}
+
+	frames, err := ReadReplay(&buf)
+	if err != nil {
+		t.Fatalf("Expected frames, got error: %s", err)
+	}
+
+	if len(frames) != 2 {
+		t.Fatalf("Expected 2 frames, got: %d", len(frames))
+	}
+
+	if frames[0].RetriveAction() != nil || frames[0].RetriveState().GetVersion() != 7 || frames[0].GetRecordedAt() == 0 {
+		t.Errorf("Expected first frame to be the state of version 7, got: %v", frames[0])
+	}
+
+	if frames[0].RetriveState().RetriveMaze().GetTotalReward() != newTestMaze().GetTotalReward() {
+		t.Errorf("Expected maze to be recorded")
+	}
+
+	if !frames[0].RetriveState().GetFogOfWar() {
+		t.Errorf("Expected the fog of war to be recorded")
+	}
+
+	if frames[1].RetriveState() != nil || frames[1].RetriveAction().GetSeq() != 3 || frames[1].RetriveAction().GetDirection() != i.East {
+		t.Errorf("Expected second frame to be the action 3, got: %v", frames[1])
+	}
+}
+
+// testReplay_Truncated tests reading a replay cut in the middle of a frame.
+func testReplay_Truncated(t *testing.T) {
+	var buf bytes.Buffer
+	recorder := NewRecorder(&buf)
+	_ = recorder.RecordState(&GameState{Version: 1, Maze: newTestMaze()})
+	_ = recorder.Close()
+
+	_, err := ReadReplay(bytes.NewReader(buf.Bytes()[:buf.Len()-3]))
+	if err == nil {
+		t.Errorf("Expected error reading a truncated replay")
+	}
+}
+
+// fixedClock is a server clock stopped at a time.
+type fixedClock time.Time
+
+func (c fixedClock) ServerNow() time.Time {
+	return time.Time(c)
+}
+
+// testReplay_Clock tests that the frames are timestamped with the server clock when one is given.
+func testReplay_Clock(t *testing.T) {
+	var buf bytes.Buffer
+	serverNow := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	recorder := NewRecorder(&buf, RecorderWithClock(fixedClock(serverNow)))
+	_ = recorder.RecordState(&GameState{Version: 1, Maze: newTestMaze()})
+	_ = recorder.Close()
+
+	frames, err := ReadReplay(&buf)
+	if err != nil {
+		t.Fatalf("Expected frames, got error: %s", err)
+	}
+	if len(frames) != 1 || frames[0].GetRecordedAt() != serverNow.UnixMilli() {
+		t.Errorf("Expected the frame to be recorded at %d, got: %v", serverNow.UnixMilli(), frames)
+	}
+}
+
+// syncingClock is a server clock whose estimate is moved by the tests, as the pongs sync it.
+type syncingClock struct {
+	offset time.Duration
+}
+
+func (c *syncingClock) ServerNow() time.Time {
+	return time.Now().Add(c.offset)
+}
+
+// testReplay_ClockSync tests that the frames stay in order and evenly spaced when the server clock is synced
+// while recording, behind and then ahead of the local clock.
+func testReplay_ClockSync(t *testing.T) {
+	var buf bytes.Buffer
+	clock := &syncingClock{}
+	recorder := NewRecorder(&buf, RecorderWithClock(clock))
+
+	start := time.Now()
+	for version, offset := range []time.Duration{0, -2 * time.Second, 5 * time.Second} {
+		clock.offset = offset
+		_ = recorder.RecordState(&GameState{Version: int64(version), Maze: newTestMaze()})
+		time.Sleep(10 * time.Millisecond)
+	}
+	_ = recorder.Close()
+	elapsed := time.Since(start).Milliseconds()
+
+	frames, err := ReadReplay(&buf)
+	if err != nil || len(frames) != 3 {
+		t.Fatalf("Expected 3 frames, got: %v, %v", frames, err)
+	}
+	for n := 1; n < len(frames); n++ {
+		gap := frames[n].GetRecordedAt() - frames[n-1].GetRecordedAt()
+		if gap < 10 || gap > elapsed {
+			t.Errorf("Expected the frames %d and %d 10ms to %dms apart, got: %dms", n-1, n, elapsed, gap)
+		}
+	}
+}
@@ -15,6 +15,11 @@ func (x *Handshake) SetKey(k []byte) {
 	x.Key = k
 }
 
+// SetKeyShare implements udp.HandshakeRecord.
+func (x *Handshake) SetKeyShare(k []byte) {
+	x.KeyShare = k
+}
+
 // SetToken implements udp.HandshakeRecord.
 func (x *Handshake) SetToken(t []byte) {
 	x.Token = t
@@ -29,3 +34,18 @@ func (x *Handshake) SetSessionId(sID []byte) {
 func (x *Handshake) SetTimestamp(t int64) {
 	x.Timestamp = t
 }
+
+// SetTicket implements udp.HandshakeRecord.
+func (x *Handshake) SetTicket(t []byte) {
+	x.Ticket = t
+}
+
+// SetCipherSuites implements udp.HandshakeRecord.
+func (x *Handshake) SetCipherSuites(s []uint32) {
+	x.CipherSuites = s
+}
+
+// SetCipherSuite implements udp.HandshakeRecord.
+func (x *Handshake) SetCipherSuite(s uint32) {
+	x.CipherSuite = s
+}
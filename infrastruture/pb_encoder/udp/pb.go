@@ -27,12 +27,16 @@ func (p *Protobuf) Marshal(msg interface{}) ([]byte, error) {
 // MarshalHandshake implements udp.Encoder.
 func (p *Protobuf) MarshalHandshake(h i.HandshakeRecord) ([]byte, error) {
 	msg := &Handshake{
-		SessionId: h.GetSessionId(),
-		Random:    h.GetRandom(),
-		Cookie:    h.GetCookie(),
-		Token:     h.GetToken(),
-		Key:       h.GetKey(),
-		Timestamp: h.GetTimestamp(),
+		SessionId:    h.GetSessionId(),
+		Random:       h.GetRandom(),
+		Cookie:       h.GetCookie(),
+		Token:        h.GetToken(),
+		Key:          h.GetKey(),
+		KeyShare:     h.GetKeyShare(),
+		Timestamp:    h.GetTimestamp(),
+		Ticket:       h.GetTicket(),
+		CipherSuites: h.GetCipherSuites(),
+		CipherSuite:  h.GetCipherSuite(),
 	}
 	return proto.Marshal(msg)
 }
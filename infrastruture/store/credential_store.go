@@ -0,0 +1,166 @@
+// Package store persists the client's state between launches.
+package store
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/sofc-t/puzzle-client/dmn"
+	"github.com/sofc-t/puzzle-client/infrastruture/crypto"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+const (
+	appDirName          = "puzzle-me"
+	credentialsFileName = "credentials"
+	keyFileName         = "credentials.key"
+	keySize             = 32
+)
+
+// credentialsAD binds the sealed credentials to their purpose and format version.
+var credentialsAD = []byte("puzzle-me credentials v1")
+
+var ErrNoCredentials = errors.New("no credentials stored")
+
+// FileCredentialStore persists the credentials sealed with AES-GCM in a file only readable by the user.
+// The key is kept in another directory, so a copy or a backup of the config dir alone does not reveal the tokens.
+type FileCredentialStore struct {
+	dir    string
+	keyDir string
+	aead   i.AEAD
+}
+
+// NewFileCredentialStore returns a store keeping the credentials in dir and their key in keyDir,
+// both created on the first save.
+func NewFileCredentialStore(dir, keyDir string) *FileCredentialStore {
+	return &FileCredentialStore{dir: dir, keyDir: keyDir, aead: crypto.NewAESGCM()}
+}
+
+// DefaultDir returns the directory of the client in the user's config dir.
+func DefaultDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, appDirName), nil
+}
+
+// DefaultKeyDir returns the directory of the client in the user's cache dir, where the key is kept apart from
+// the credentials. Losing it only makes the player log in again.
+func DefaultKeyDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, appDirName), nil
+}
+
+// Load returns the stored credentials, ErrNoCredentials when none are stored.
+func (s *FileCredentialStore) Load() (*dmn.Credentials, error) {
+	sealed, err := os.ReadFile(filepath.Join(s.dir, credentialsFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNoCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := os.ReadFile(filepath.Join(s.keyDir, keyFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNoCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := s.aead.Open(sealed, key, credentialsAD)
+	if err != nil {
+		return nil, err
+	}
+
+	var credentials dmn.Credentials
+	if err := json.Unmarshal(payload, &credentials); err != nil {
+		return nil, err
+	}
+	return &credentials, nil
+}
+
+// Save replaces the stored credentials, generating the key on the first save.
+func (s *FileCredentialStore) Save(credentials *dmn.Credentials) error {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+
+	key, err := s.key()
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := s.aead.Seal(payload, key, credentialsAD)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(s.dir, credentialsFileName), sealed)
+}
+
+// Clear removes the stored credentials. The key is kept for the next save.
+func (s *FileCredentialStore) Clear() error {
+	err := os.Remove(filepath.Join(s.dir, credentialsFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// key returns the key sealing the credentials, generating it when missing.
+func (s *FileCredentialStore) key() ([]byte, error) {
+	path := filepath.Join(s.keyDir, keyFileName)
+	key, err := os.ReadFile(path)
+	if err == nil && len(key) == keySize {
+		return key, nil
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(s.keyDir, 0o700); err != nil {
+		return nil, err
+	}
+
+	key = make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, writeFile(path, key)
+}
+
+// writeFile replaces the file at path atomically, readable by the user only.
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sofc-t/puzzle-client/dmn"
+)
+
+var testCredentials = &dmn.Credentials{
+	Player:       dmn.Player{ID: uuid.MustParse("7f1b4c9e-3f57-4a51-9d0b-2c9a35c6a001"), Username: "gopher", Rating: 1000},
+	AccessToken:  "access",
+	RefreshToken: "refresh",
+}
+
+// newTestStore returns a store keeping its credentials and its key in two directories of the test.
+func newTestStore(t *testing.T) *FileCredentialStore {
+	return NewFileCredentialStore(filepath.Join(t.TempDir(), "config"), filepath.Join(t.TempDir(), "cache"))
+}
+
+// TestFileCredentialStore contains all the tests related to persisting credentials.
+func TestFileCredentialStore(t *testing.T) {
+	t.Run("FileCredentialStore_RoundTrip", testFileCredentialStore_RoundTrip)
+	t.Run("FileCredentialStore_Sealed", testFileCredentialStore_Sealed)
+	t.Run("FileCredentialStore_Empty", testFileCredentialStore_Empty)
+	t.Run("FileCredentialStore_Tampered", testFileCredentialStore_Tampered)
+	t.Run("FileCredentialStore_MissingKey", testFileCredentialStore_MissingKey)
+	t.Run("FileCredentialStore_Clear", testFileCredentialStore_Clear)
+}
+
+// testFileCredentialStore_RoundTrip tests that saved credentials are loaded back and only readable by the user.
+func testFileCredentialStore_RoundTrip(t *testing.T) {
+	store := newTestStore(t)
+	if err := store.Save(testCredentials); err != nil {
+		t.Fatalf("Expected the credentials to be saved, got error: %s", err)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Expected the credentials to be loaded, got error: %s", err)
+	}
+	if *loaded != *testCredentials {
+		t.Errorf("Expected %+v, got: %+v", testCredentials, loaded)
+	}
+
+	for _, path := range []string{filepath.Join(store.dir, credentialsFileName), filepath.Join(store.keyDir, keyFileName)} {
+		info, _ := os.Stat(path)
+		if info.Mode().Perm() != 0o600 {
+			t.Errorf("Expected %s only readable by the user, got: %s", filepath.Base(path), info.Mode())
+		}
+	}
+	for _, dir := range []string{store.dir, store.keyDir} {
+		info, _ := os.Stat(dir)
+		if info.Mode().Perm() != 0o700 {
+			t.Errorf("Expected %s only listed by the user, got: %s", filepath.Base(dir), info.Mode())
+		}
+	}
+}
+
+// testFileCredentialStore_Sealed tests that the tokens are not stored in clear and that the key is not stored
+// beside the credentials.
+func testFileCredentialStore_Sealed(t *testing.T) {
+	store := newTestStore(t)
+	_ = store.Save(testCredentials)
+
+	sealed, err := os.ReadFile(filepath.Join(store.dir, credentialsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, token := range []string{testCredentials.AccessToken, testCredentials.RefreshToken, testCredentials.Player.Username} {
+		if bytes.Contains(sealed, []byte(token)) {
+			t.Errorf("Expected %q to be sealed, got it in clear", token)
+		}
+	}
+
+	entries, _ := os.ReadDir(store.dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the credentials in their directory, got: %d files", len(entries))
+	}
+}
+
+// testFileCredentialStore_Empty tests that loading from an empty directory reports ErrNoCredentials.
+func testFileCredentialStore_Empty(t *testing.T) {
+	if _, err := newTestStore(t).Load(); err != ErrNoCredentials {
+		t.Errorf("Expected ErrNoCredentials, got: %v", err)
+	}
+}
+
+// testFileCredentialStore_Tampered tests that modified credentials are rejected.
+func testFileCredentialStore_Tampered(t *testing.T) {
+	store := newTestStore(t)
+	_ = store.Save(testCredentials)
+
+	path := filepath.Join(store.dir, credentialsFileName)
+	sealed, _ := os.ReadFile(path)
+	sealed[len(sealed)-1] ^= 0xff
+	_ = os.WriteFile(path, sealed, 0o600)
+
+	if _, err := store.Load(); err == nil {
+		t.Errorf("Expected tampered credentials to be rejected")
+	}
+}
+
+// testFileCredentialStore_MissingKey tests that credentials whose key is gone are reported as ErrNoCredentials,
+// so the player logs in again.
+func testFileCredentialStore_MissingKey(t *testing.T) {
+	store := newTestStore(t)
+	_ = store.Save(testCredentials)
+	_ = os.Remove(filepath.Join(store.keyDir, keyFileName))
+
+	if _, err := store.Load(); err != ErrNoCredentials {
+		t.Errorf("Expected ErrNoCredentials, got: %v", err)
+	}
+}
+
+// testFileCredentialStore_Clear tests that cleared credentials are gone.
+func testFileCredentialStore_Clear(t *testing.T) {
+	store := newTestStore(t)
+	_ = store.Save(testCredentials)
+
+	if err := store.Clear(); err != nil {
+		t.Fatalf("Expected the credentials to be cleared, got error: %s", err)
+	}
+	if _, err := store.Load(); err != ErrNoCredentials {
+		t.Errorf("Expected ErrNoCredentials after clearing, got: %v", err)
+	}
+	if err := store.Clear(); err != nil {
+		t.Errorf("Expected clearing twice to succeed, got error: %s", err)
+	}
+}
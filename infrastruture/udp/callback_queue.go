@@ -0,0 +1,40 @@
+package udp
+
+import "sync"
+
+// callbackQueue runs callbacks one at a time in the order they are pushed, off the caller's goroutine.
+// Push never blocks, so it can be called while holding a lock the callbacks may need.
+type callbackQueue struct {
+	queue   []func()
+	running bool // Running is set while a goroutine drains the queue.
+	mu      sync.Mutex
+}
+
+// push queues f, starting a goroutine to drain the queue unless one is running.
+func (q *callbackQueue) push(f func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.queue = append(q.queue, f)
+	if !q.running {
+		q.running = true
+		go q.drain()
+	}
+}
+
+// drain runs the queued callbacks until the queue is empty.
+func (q *callbackQueue) drain() {
+	for {
+		q.mu.Lock()
+		if len(q.queue) == 0 {
+			q.running = false
+			q.mu.Unlock()
+			return
+		}
+		f := q.queue[0]
+		q.queue = q.queue[1:]
+		q.mu.Unlock()
+
+		f()
+	}
+}
@@ -2,10 +2,12 @@ package udp
 
 import (
 	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"io"
 	"log"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/sofc-t/puzzle-client/service/i"
@@ -23,6 +25,8 @@ var (
 	ErrMaximumPayloadSizeLimit      = errors.New("maximum payload size limit")
 	ErrClientCookieIsInvalid        = errors.New("client cookie is invalid")
 	ErrInvalidPayloadBodySize       = errors.New("invalid payload body size")
+	ErrNoCipherSuiteAccepted        = errors.New("no offered cipher suite accepted by the server")
+	ErrCipherSuiteMismatch          = errors.New("negotiated cipher suite does not match the sealing suite")
 )
 
 const (
@@ -32,15 +36,19 @@ const (
 	PingRecordType
 	PongRecordType
 
-	defaultReadBufferSize int = 2048
+	defaultReadBufferSize       int = 2048
+	defaultMissedPongWindow     int = 3
+	defaultMaxReconnectAttempts int = 5
 
-	minimumPayloadSize  int = 3
+	recordHeaderSize    int = 9 // Record type (1 byte) followed by the sequence number (8 bytes).
+	minimumPayloadSize  int = recordHeaderSize + 1
 	insecureSymmKeySize int = 32 // A symmetric key smaller than 256 bits is insecure. 256 bits = 32 bytes in size.
 )
 
 // Incoming bytes are parsed into the record struct
 type record struct {
 	Type byte
+	Seq  uint64
 	Body []byte
 }
 
@@ -52,38 +60,57 @@ type rawRecord struct {
 
 // ClientSocketManager manages the client-server connection and related operations.
 type ClientSocketManager struct {
-	conn               *net.UDPConn       // Conn represents the UDP connection to the server.
-	logger             *log.Logger        // Logger is used to log messages and errors.
-	onConnectionSucces func()             // OnConnectionSucces is a callback function executed when the connection succeeds.
-	encoder            i.SocketEncoder    // Encoder is an implementation of Encoder used to encode and decode messages.
-	readBufferSize     int                // Maximum buffer size for incoming bytes.
-	rawRecords         chan rawRecord     // RawRecords is a channel for processing raw records.
-	asymmCrypto        i.Asymmetric       // AsymmCrypto is an implementation of asymmetric encryption.
-	serverAsymmPubKey  []byte             // ServerAsymmPubKey is the server's public key for asymmetric encryption.
-	symmCrypto         i.Symmetric        // SymmCrypto is an implementation of symmetric encryption.
-	clientSymmKey      []byte             // ClientSymmKey is the client's symmetric encryption key.
-	authToken          []byte             // AuthToken is the authentication token used for secure communication.
-	sessionID          []byte             // SessionID is the identifier for the current session.
-	handshakeRandom    []byte             // HandshakeRandom is used during the handshake process.
-	pingTicker         *time.Ticker       // PingTicker schedules periodic ping requests.
-	pingInterval       time.Duration      // PingInterval is the duration between ping requests.
-	pingStopSignal     chan bool          // PingStopSignal stops the ping routine.
-	onPingResult       func(int64)        // PingResultCallback is called upon receiving a ping result.
-	stopSignal         chan bool          // StopSignal stops the ClientSocketManager.
-	onServerResponse   func(byte, []byte) // Callback function to call when server sends message besides handshake and pong.
+	conn               *net.UDPConn         // Conn represents the UDP connection to the server.
+	logger             *log.Logger          // Logger is used to log messages and errors.
+	onConnectionSucces func()               // OnConnectionSucces is a callback function executed when the connection succeeds.
+	encoder            i.SocketEncoder      // Encoder is an implementation of Encoder used to encode and decode messages.
+	readBufferSize     int                  // Maximum buffer size for incoming bytes.
+	rawRecords         chan rawRecord       // RawRecords is a channel for processing raw records.
+	asymmCrypto        i.Asymmetric         // AsymmCrypto is an implementation of asymmetric encryption.
+	serverAsymmPubKey  []byte               // ServerAsymmPubKey is the server's public key for asymmetric encryption.
+	symmCrypto         i.Symmetric          // SymmCrypto is an implementation of symmetric encryption.
+	cipherSuites       []i.AEAD             // CipherSuites are the authenticated encryption suites offered in the handshake.
+	aead               i.AEAD               // AEAD is the cipher suite picked by the server, nil until negotiated.
+	keys               i.KeyManager         // Keys manages the client's symmetric encryption key of the current connection.
+	authToken          []byte               // AuthToken is the authentication token used for secure communication.
+	sessionID          []byte               // SessionID is the identifier for the current session.
+	handshakeRandom    []byte               // HandshakeRandom is used during the handshake process.
+	pingInterval       time.Duration        // PingInterval is the duration between ping requests.
+	pingDone           chan struct{}        // PingDone is closed to stop the ping routine of the current connection.
+	onPingResult       func(i.NetworkStats) // OnPingResult is called with the network stats upon receiving a pong.
+	stopSignal         chan bool            // StopSignal stops the ClientSocketManager.
+	onServerResponse   func(byte, []byte)   // Callback function to call when server sends message besides handshake and pong.
+
+	missedPongWindow     int                     // Number of ping intervals without an answer after which the session is considered dead.
+	maxReconnectAttempts int                     // Number of handshakes attempted before the session is declared lost.
+	unansweredPings      int                     // Pings sent since the last pong was received.
+	handshakeTicks       int                     // Ping intervals elapsed since the last client hello was sent.
+	reconnectAttempts    int                     // Handshakes sent since the session was declared dead.
+	resumptionTicket     []byte                  // Server-issued ticket used to resume the session after a reconnect.
+	state                i.ConnectionState       // State is the current health of the session.
+	onStateChange        func(i.ConnectionState) // Callback function to call when the connection state changes.
+	sendSeq              uint64                  // SendSeq is the sequence number of the next outgoing record.
+	replay               replayWindow            // Replay rejects duplicated and too old incoming records.
+	stats                i.RecordStats           // Stats counts the records sent and received for diagnostics.
+	pings                pingTracker             // Pings measures the latency and the losses from the pings and pongs.
+	callbacks            callbackQueue           // Callbacks delivers the state changes and the ping results in the order they happen.
+	mu                   sync.Mutex              // Mu guards the session fields shared between the read, handler and ping routines.
 }
 
 // ClientConfig defines the configuration settings required for a client to connect to a server.
 type ClientConfig struct {
-	ServerAddr         *net.UDPAddr       // ServerAddr is the UDP address of the server.
-	Encoder            i.SocketEncoder    // Encoder is an implementation of Encoder to encode and decode messages.
-	AsymmCrypto        i.Asymmetric       // AsymmCrypto is an implementation of asymmetric encryption.
-	ServerAsymmPubKey  []byte             // ServerAsymmPubKey is the server's public key for asymmetric encryption.
-	SymmCrypto         i.Symmetric        // SymmCrypto is an implementation of symmetric encryption.
-	ClientSymmKey      []byte             // ClientSymmKey is the client's symmetric encryption key.
-	OnConnectionSucces func()             // OnConnectionSucces is a callback function executed when the connection succeeds.
-	OnServerResponse   func(byte, []byte) // Callback function to call when server sends message besides handshake and pong.
-	OnPingResult       func(int64)        // PingResultCallback is called upon receiving a ping result.
+	ServerAddr         *net.UDPAddr         // ServerAddr is the UDP address of the server.
+	Encoder            i.SocketEncoder      // Encoder is an implementation of Encoder to encode and decode messages.
+	AsymmCrypto        i.Asymmetric         // AsymmCrypto is an implementation of asymmetric encryption.
+	ServerAsymmPubKey  []byte               // ServerAsymmPubKey is the server's public key for asymmetric encryption.
+	SymmCrypto         i.Symmetric          // SymmCrypto is an optional legacy symmetric encryption used when no cipher suite is negotiated.
+	CipherSuites       []i.AEAD             // CipherSuites are the authenticated encryption suites offered in the handshake, in order of preference.
+	Keys               i.KeyManager         // Keys manages the client's symmetric encryption key, rotated on every handshake.
+	OnConnectionSucces func()               // OnConnectionSucces is a callback function executed when the connection succeeds.
+	OnServerResponse   func(byte, []byte)   // Callback function to call when server sends message besides handshake and pong.
+	OnPingResult       func(i.NetworkStats) // OnPingResult is called with the network stats upon receiving a pong.
+
+	OnConnectionStateChange func(i.ConnectionState) // Callback function to call when the connection state changes.
 }
 
 // NewClientServerManager creates a new instance of ClientServerManager.
@@ -99,13 +126,14 @@ func NewClientServerManager(c ClientConfig, options ...ClientOption) (*ClientSoc
 		asymmCrypto:        c.AsymmCrypto,
 		serverAsymmPubKey:  c.ServerAsymmPubKey,
 		symmCrypto:         c.SymmCrypto,
-		clientSymmKey:      c.ClientSymmKey,
+		cipherSuites:       c.CipherSuites,
+		keys:               c.Keys,
 		rawRecords:         make(chan rawRecord),
 		onConnectionSucces: c.OnConnectionSucces,
 		onPingResult:       c.OnPingResult,
-		pingStopSignal:     make(chan bool, 1),
 		onServerResponse:   c.OnServerResponse,
 		stopSignal:         make(chan bool, 1),
+		onStateChange:      c.OnConnectionStateChange,
 	}
 
 	for _, opt := range options {
@@ -120,6 +148,14 @@ func NewClientServerManager(c ClientConfig, options ...ClientOption) (*ClientSoc
 		manager.pingInterval = time.Second
 	}
 
+	if manager.missedPongWindow == 0 {
+		manager.missedPongWindow = defaultMissedPongWindow
+	}
+
+	if manager.maxReconnectAttempts == 0 {
+		manager.maxReconnectAttempts = defaultMaxReconnectAttempts
+	}
+
 	if manager.logger == nil {
 		// Discard logging if no logger is set
 		manager.logger = log.New(io.Discard, "", 0)
@@ -131,45 +167,39 @@ func NewClientServerManager(c ClientConfig, options ...ClientOption) (*ClientSoc
 // Connect establishes the initial handshake with the server.
 func (c *ClientSocketManager) Connect(authToken []byte) error {
 	// Reset pev connection data.
+	c.mu.Lock()
 	c.sessionID = []byte{}
 	c.authToken = authToken
-
-	// Stop prev ping routine.
-	if c.pingTicker != nil {
-		c.pingStopSignal <- true
-		c.pingTicker.Stop()
-	}
-
-	c.pingTicker = time.NewTicker(c.pingInterval)
-	go c.requestPing()
-
-	_ = c.conn.SetDeadline(time.Time{})
-
-	clientHello := c.encoder.NewHandshakeRecord()
-	random := make([]byte, 32)
-	_, err := rand.Read(random)
+	c.resumptionTicket = nil
+	c.aead = nil
+	c.unansweredPings = 0
+	c.handshakeTicks = 0
+	c.reconnectAttempts = 0
+	c.pings.reset()
+	c.setState(i.ConnectionReconnecting) // No session is established until the server hello arrives.
+	err := c.keys.Rotate()
+	if err == nil && len(c.keys.Key()) < insecureSymmKeySize {
+		err = ErrInsecureEncryptionKeySize
+	}
+	c.mu.Unlock()
 	if err != nil {
 		return err
 	}
 
-	c.handshakeRandom = random
-	clientHello.SetRandom(random)
-	clientHello.SetKey(c.clientSymmKey)
-
-	clientHelloPayload, err := c.encoder.MarshalHandshake(clientHello)
-	if err != nil {
-		return err
-	}
+	// Stop prev ping routine.
+	c.mu.Lock()
+	c.stopPinging()
+	done := make(chan struct{})
+	c.pingDone = done
+	c.mu.Unlock()
+	go c.requestPing(done)
 
-	clientHelloPayload, err = c.asymmCrypto.Encrypt(clientHelloPayload, c.serverAsymmPubKey)
-	if err != nil {
-		return err
-	}
+	_ = c.conn.SetDeadline(time.Time{})
 
-	clientHelloMessage := append([]byte{ClientHelloRecordType}, clientHelloPayload...)
-	_, err = c.conn.Write(clientHelloMessage)
+	c.mu.Lock()
+	err = c.sendClientHello()
+	c.mu.Unlock()
 	if err != nil {
-		c.logger.Printf("error while encoding client hello record: %s", err)
 		return err
 	}
 
@@ -180,7 +210,8 @@ func (c *ClientSocketManager) Connect(authToken []byte) error {
 	for {
 		select {
 		case <-c.stopSignal:
-			break
+			close(c.rawRecords)
+			return nil
 
 		default:
 			buf := make([]byte, c.readBufferSize+1) // Intentionally create more space than allowed for checking
@@ -204,16 +235,60 @@ func (c *ClientSocketManager) Connect(authToken []byte) error {
 	}
 }
 
+// sendClientHello starts a new handshake with the server.
+// A resumption ticket is attached when a previous session issued one. The caller must hold c.mu.
+func (c *ClientSocketManager) sendClientHello() error {
+	clientHello := c.encoder.NewHandshakeRecord()
+	random := make([]byte, 32)
+	_, err := rand.Read(random)
+	if err != nil {
+		return err
+	}
+
+	c.handshakeRandom = random
+	c.handshakeTicks = 0
+
+	// Every handshake starts a new epoch with a new key, so sequence numbers start over.
+	c.sendSeq = 0
+	c.replay.reset()
+	clientHello.SetRandom(random)
+	c.setKeyMaterial(clientHello)
+	clientHello.SetTicket(c.resumptionTicket)
+	clientHello.SetCipherSuites(c.offeredSuites())
+
+	clientHelloPayload, err := c.encoder.MarshalHandshake(clientHello)
+	if err != nil {
+		return err
+	}
+
+	clientHelloPayload, err = c.asymmCrypto.Encrypt(clientHelloPayload, c.serverAsymmPubKey)
+	if err != nil {
+		return err
+	}
+
+	err = c.writeRecord(ClientHelloRecordType, c.nextSeq(), clientHelloPayload)
+	if err != nil {
+		c.logger.Printf("error while encoding client hello record: %s", err)
+		return err
+	}
+	return nil
+}
+
+// Disconnect closes the connection and cleans up resources.
 func (c *ClientSocketManager) Disconnect() {
 	defer c.logger.Println("disconnected")
 	c.logger.Println("disconnecting...")
 
 	_ = c.conn.SetReadDeadline(time.Unix(0, 1))
 	c.stopSignal <- true
-	c.pingStopSignal <- true
-	c.pingTicker.Stop()
+
+	c.mu.Lock()
+	c.stopPinging()
 	c.sessionID = []byte{}
-	close(c.rawRecords)
+	c.resumptionTicket = nil
+	c.aead = nil
+	c.keys.Zero()
+	c.mu.Unlock()
 }
 
 func (c *ClientSocketManager) handleRawRecords() {
@@ -225,16 +300,31 @@ func (c *ClientSocketManager) handleRawRecords() {
 // handleRawRecord processes incoming raw records and takes action based on their type.
 func (c *ClientSocketManager) handleRawRecord(payload []byte) {
 	if len(payload) < minimumPayloadSize {
+		c.dropRecord()
 		c.logger.Println(ErrMinimumPayloadSizeLimit)
 		return
 	}
 
 	record, err := parseRecord(payload)
 	if err != nil {
+		c.dropRecord()
 		c.logger.Printf("error while parsing record: %s", err)
 		return
 	}
 
+	c.mu.Lock()
+	err = c.replay.check(record.Seq)
+	if errors.Is(err, ErrDuplicateRecord) {
+		c.stats.Duplicates++
+	} else if err != nil {
+		c.stats.Dropped++
+	}
+	c.mu.Unlock()
+	if err != nil {
+		c.logger.Printf("error while checking record %d: %s", record.Seq, err)
+		return
+	}
+
 	switch record.Type {
 	case HelloVerifyRecordType:
 		c.handleHelloVerifyRecord(record)
@@ -249,24 +339,27 @@ func (c *ClientSocketManager) handleRawRecord(payload []byte) {
 
 // handleHelloVerifyRecord processes a "HelloVerify" record in the DTLS handshake process.
 func (c *ClientSocketManager) handleHelloVerifyRecord(record *record) {
-	payload, err := c.symmCrypto.Decrypt(record.Body, c.clientSymmKey)
+	helloVerify, err := c.openHandshake(record)
 	if err != nil {
-		c.logger.Printf("error while decrypting hello verify record: %s", err)
+		c.logger.Printf("error while opening hello verify record: %s", err)
 		return
 	}
 
-	helloVerify, err := c.encoder.UnmarshalHandshake(payload)
-	if err != nil {
-		c.logger.Printf("error while decoding hello verify record: %s", err)
-		return
-	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	clientHello := c.encoder.NewHandshakeRecord()
 	clientHello.SetCookie(helloVerify.GetCookie())
 	clientHello.SetRandom(c.handshakeRandom)
-	clientHello.SetKey(c.clientSymmKey)
+	c.setKeyMaterial(clientHello)
 	clientHello.SetTimestamp(time.Now().UnixNano() / int64(time.Millisecond))
-	encryptedToken, err := c.symmCrypto.Encrypt(c.authToken, c.clientSymmKey)
+	clientHello.SetTicket(c.resumptionTicket)
+	clientHello.SetCipherSuites(c.offeredSuites())
+	if c.aead != nil {
+		clientHello.SetCipherSuite(c.aead.Suite())
+	}
+	seq := c.nextSeq()
+	encryptedToken, err := c.encrypt(ClientHelloRecordType, seq, nil, c.authToken)
 	if err != nil {
 		c.logger.Printf("error while encrypting auth token: %s", err)
 		return
@@ -284,8 +377,7 @@ func (c *ClientSocketManager) handleHelloVerifyRecord(record *record) {
 		return
 	}
 
-	clientHelloMessage := append([]byte{ClientHelloRecordType}, clientHelloPayload...)
-	_, err = c.conn.Write(clientHelloMessage)
+	err = c.writeRecord(ClientHelloRecordType, seq, clientHelloPayload)
 	if err != nil {
 		c.logger.Printf("error while encoding client hello record: %s", err)
 		return
@@ -294,24 +386,28 @@ func (c *ClientSocketManager) handleHelloVerifyRecord(record *record) {
 
 // handleHelloVerifyRecord processes a "ServerHello" record in the DTLS handshake process.
 func (c *ClientSocketManager) handleServerHelloRecord(record *record) {
-	payload, err := c.symmCrypto.Decrypt(record.Body, c.clientSymmKey)
+	serverHello, err := c.openHandshake(record)
 	if err != nil {
-		c.logger.Printf("error while decrypting hello verify record: %s", err)
+		c.logger.Printf("error while opening server hello record: %s", err)
 		return
 	}
 
-	serverHello, err := c.encoder.UnmarshalHandshake(payload)
-	if err != nil {
-		c.logger.Printf("error while decoding hello verify record: %s", err)
-		return
+	c.mu.Lock()
+	c.sessionID = serverHello.GetSessionId()
+	if ticket := serverHello.GetTicket(); len(ticket) > 0 {
+		c.resumptionTicket = ticket
 	}
+	c.unansweredPings = 0
+	c.handshakeTicks = 0
+	c.reconnectAttempts = 0
+	c.setState(i.ConnectionConnected)
+	c.mu.Unlock()
 
-	c.sessionID = serverHello.GetSessionId()
 	c.onConnectionSucces()
 }
 
 func (c *ClientSocketManager) handlePongRecord(record *record) {
-	payload, err := c.symmCrypto.Decrypt(record.Body, c.clientSymmKey)
+	payload, err := c.decrypt(record)
 	if err != nil {
 		c.logger.Printf("error while decrypting hello verify record: %s", err)
 		return
@@ -322,12 +418,23 @@ func (c *ClientSocketManager) handlePongRecord(record *record) {
 		c.logger.Printf("error while decoding hello verify record: %s", err)
 		return
 	}
+	receivedAt := time.Now().UnixNano() / int64(time.Millisecond)
 
-	go c.onPingResult(pong.GetReceivedAt() - pong.GetPingSentAt())
+	c.mu.Lock()
+	c.unansweredPings = 0
+	if c.state == i.ConnectionDegraded {
+		c.setState(i.ConnectionConnected)
+	}
+	// The stats are delivered in the order of the pongs, after the state change they may have caused.
+	if onPingResult := c.onPingResult; c.pings.answer(pong, receivedAt) && onPingResult != nil {
+		stats := c.pings.stats()
+		c.callbacks.push(func() { onPingResult(stats) })
+	}
+	c.mu.Unlock()
 }
 
 func (c *ClientSocketManager) handleCustomRecord(r *record) {
-	payload, err := c.symmCrypto.Decrypt(r.Body, c.clientSymmKey)
+	payload, err := c.decrypt(r)
 	if err != nil {
 		c.logger.Printf("error while decrypting custom record: %s", err)
 		return
@@ -336,71 +443,339 @@ func (c *ClientSocketManager) handleCustomRecord(r *record) {
 	go c.onServerResponse(r.Type, payload)
 }
 
-func (c *ClientSocketManager) requestPing() {
+// requestPing sends a ping on every interval until done is closed.
+// A failed ping is only logged, as the pings also drive the detection of a dead session.
+func (c *ClientSocketManager) requestPing(done <-chan struct{}) {
+	ticker := time.NewTicker(c.pingInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
-		case <-c.pingStopSignal:
-			break
-		case <-c.pingTicker.C:
-			if len(c.sessionID) == 0 { // If no connection has been setup yet.
+		case <-done:
+			return
+		case <-ticker.C:
+			if !c.checkSession() { // If no connection has been setup yet.
 				continue
 			}
 
+			sentAt := time.Now().UnixNano() / int64(time.Millisecond)
 			ping := c.encoder.NewPingRecord()
-			ping.SetSentAt(time.Now().UnixNano() / int64(time.Millisecond))
+			ping.SetSentAt(sentAt)
 
 			pingMessage, err := c.encoder.MarshalPing(ping)
 			if err != nil {
 				c.logger.Printf("error while marshaling ping record: %s", err)
-				return
+				continue
 			}
 
+			c.mu.Lock()
+			c.pings.sent(sentAt)
+			c.mu.Unlock()
+
 			err = c.SendToServer(PingRecordType, pingMessage)
 			if err != nil {
 				c.logger.Printf("error while sending to server: %s", err)
-				return
+				continue
 			}
 		}
 	}
 }
 
+// stopPinging stops the ping routine of the current connection, if any. The caller must hold c.mu.
+func (c *ClientSocketManager) stopPinging() {
+	if c.pingDone != nil {
+		close(c.pingDone)
+		c.pingDone = nil
+	}
+}
+
+// checkSession is called on every ping tick and drives the session through the
+// connected, degraded, reconnecting and lost states based on the unanswered pings.
+// It reports whether the session is established and a ping should be sent.
+func (c *ClientSocketManager) checkSession() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state == i.ConnectionLost {
+		return false
+	}
+
+	if len(c.sessionID) == 0 { // Handshake in progress.
+		c.handshakeTicks++
+		if c.handshakeTicks < c.missedPongWindow {
+			return false
+		}
+
+		if c.reconnectAttempts >= c.maxReconnectAttempts {
+			c.setState(i.ConnectionLost)
+			return false
+		}
+
+		c.reconnectAttempts++
+		c.restartHandshake()
+		return false
+	}
+
+	if c.unansweredPings >= c.missedPongWindow {
+		c.logger.Printf("no pong received in %d ping intervals, reconnecting", c.unansweredPings)
+		c.sessionID = []byte{}
+		c.unansweredPings = 0
+		c.reconnectAttempts = 1
+		c.setState(i.ConnectionReconnecting)
+		c.restartHandshake()
+		return false
+	}
+
+	if c.unansweredPings > 0 {
+		c.setState(i.ConnectionDegraded)
+	}
+
+	c.unansweredPings++
+	return true
+}
+
+// restartHandshake sends a new client hello with a new key. The sequence numbers start over with the
+// handshake, rotating the key ensures they are never reused under the same key. The caller must hold c.mu.
+func (c *ClientSocketManager) restartHandshake() {
+	c.aead = nil
+	if err := c.keys.Rotate(); err != nil {
+		c.logger.Printf("error while rotating session key: %s", err)
+		return
+	}
+	if err := c.sendClientHello(); err != nil {
+		c.logger.Printf("error while sending client hello record: %s", err)
+	}
+}
+
+// setState updates the connection state and notifies onStateChange when it changed, in order. The caller must hold c.mu.
+func (c *ClientSocketManager) setState(s i.ConnectionState) {
+	if c.state == s {
+		return
+	}
+
+	c.logger.Printf("connection state changed from %s to %s", c.state, s)
+	c.state = s
+	if onStateChange := c.onStateChange; onStateChange != nil {
+		c.callbacks.push(func() { onStateChange(s) })
+	}
+}
+
 // SendToServer Encrypts and sendes message of type t to server.
 func (c *ClientSocketManager) SendToServer(t byte, message []byte) error {
-	messageToSend := c.sessionID
-	messageToSend = append(messageToSend, message...)
-	messageToSend, err := c.symmCrypto.Encrypt(messageToSend, c.clientSymmKey)
+	c.mu.Lock()
+	sessionID := append([]byte{}, c.sessionID...)
+	seq := c.nextSeq()
+	messageToSend := append(append([]byte{}, sessionID...), message...)
+	messageToSend, err := c.encrypt(t, seq, sessionID, messageToSend)
+	c.mu.Unlock()
 	if err != nil {
 		return err
 	}
 
-	messageToSend = append([]byte{t}, messageToSend...)
-	_, err = c.conn.Write(messageToSend)
+	return c.writeRecord(t, seq, messageToSend)
+}
+
+// Stats returns a snapshot of the record counters.
+func (c *ClientSocketManager) Stats() i.RecordStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// NetworkStats returns a snapshot of the latency and the losses measured by the pings.
+func (c *ClientSocketManager) NetworkStats() i.NetworkStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pings.stats()
+}
+
+// nextSeq returns the sequence number of the next outgoing record. The caller must hold c.mu.
+func (c *ClientSocketManager) nextSeq() uint64 {
+	seq := c.sendSeq
+	c.sendSeq++
+	c.stats.Sent++
+	return seq
+}
+
+// writeRecord writes a record in the [type, seq (8 bytes), body] format to the server.
+func (c *ClientSocketManager) writeRecord(t byte, seq uint64, body []byte) error {
+	_, err := c.conn.Write(append(recordHeader(t, seq), body...))
 	return err
 }
 
+// acceptRecord marks an authenticated record as seen in the replay window.
+func (c *ClientSocketManager) acceptRecord(r *record) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.replay.accept(r.Seq) {
+		c.stats.OutOfOrder++
+	}
+	c.stats.Received++
+}
+
+// dropRecord counts a record that was discarded before it could be authenticated.
+func (c *ClientSocketManager) dropRecord() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Dropped++
+}
+
+// setKeyMaterial attaches the key share to the client hello, or the key itself when the key manager does not use a key exchange.
+func (c *ClientSocketManager) setKeyMaterial(h i.HandshakeRecord) {
+	if share := c.keys.KeyShare(); share != nil {
+		h.SetKeyShare(share)
+		return
+	}
+	h.SetKey(c.keys.Key())
+}
+
+// offeredSuites returns the identifiers of the cipher suites offered to the server.
+func (c *ClientSocketManager) offeredSuites() []uint32 {
+	suites := make([]uint32, 0, len(c.cipherSuites))
+	for _, suite := range c.cipherSuites {
+		suites = append(suites, suite.Suite())
+	}
+	return suites
+}
+
+// openHandshake decrypts and decodes a "HelloVerify" or "ServerHello" record.
+//
+// Until a cipher suite is negotiated every offered suite is tried in order. Authentication guarantees
+// that only the suite the server sealed the record with succeeds, and the suite announced inside the
+// record has to match it. Servers that do not support negotiation fall back to the legacy SymmCrypto.
+func (c *ClientSocketManager) openHandshake(r *record) (i.HandshakeRecord, error) {
+	c.mu.Lock()
+	suite, payload, err := c.openHandshakeBody(r)
+	c.mu.Unlock()
+	if err != nil {
+		c.dropRecord()
+		return nil, err
+	}
+	c.acceptRecord(r)
+
+	handshake, err := c.encoder.UnmarshalHandshake(payload)
+	if err != nil || suite == nil {
+		return handshake, err
+	}
+
+	if handshake.GetCipherSuite() != suite.Suite() {
+		return nil, ErrCipherSuiteMismatch
+	}
+
+	c.mu.Lock()
+	c.aead = suite
+	c.mu.Unlock()
+	return handshake, nil
+}
+
+// openHandshakeBody decrypts the body of a handshake record with the first candidate suite authenticating it,
+// the suite is nil when the legacy SymmCrypto is used. The caller must hold c.mu, so the key is not rotated meanwhile.
+func (c *ClientSocketManager) openHandshakeBody(r *record) (i.AEAD, []byte, error) {
+	candidates := c.cipherSuites
+	if c.aead != nil {
+		candidates = []i.AEAD{c.aead}
+	}
+
+	for _, suite := range candidates {
+		payload, err := suite.Open(r.Body, c.keys.Key(), associatedData(r.Type, r.Seq, nil))
+		if err == nil {
+			return suite, payload, nil
+		}
+	}
+
+	if c.symmCrypto == nil {
+		return nil, nil, ErrNoCipherSuiteAccepted
+	}
+
+	payload, err := c.symmCrypto.Decrypt(r.Body, c.keys.Key())
+	return nil, payload, err
+}
+
+// encrypt encrypts p for a record of type t. When a cipher suite is negotiated the record header
+// and session ID are bound to the cipher as associated data. The caller must hold c.mu.
+func (c *ClientSocketManager) encrypt(t byte, seq uint64, sessionID []byte, p []byte) ([]byte, error) {
+	if c.aead != nil {
+		return c.aead.Seal(p, c.keys.Key(), associatedData(t, seq, sessionID))
+	}
+
+	if c.symmCrypto == nil {
+		return nil, ErrNoCipherSuiteAccepted
+	}
+	return c.symmCrypto.Encrypt(p, c.keys.Key())
+}
+
+// decrypt decrypts the body of a record received after the handshake and marks it as seen.
+//
+// With the legacy SymmCrypto the sequence number is not authenticated, replay protection
+// against an active attacker requires a negotiated cipher suite.
+func (c *ClientSocketManager) decrypt(r *record) ([]byte, error) {
+	// The lock is held while decrypting so the key is not rotated meanwhile.
+	var payload []byte
+	var err error
+	c.mu.Lock()
+	switch {
+	case c.aead != nil:
+		payload, err = c.aead.Open(r.Body, c.keys.Key(), associatedData(r.Type, r.Seq, c.sessionID))
+	case c.symmCrypto != nil:
+		payload, err = c.symmCrypto.Decrypt(r.Body, c.keys.Key())
+	default:
+		err = ErrNoCipherSuiteAccepted
+	}
+	c.mu.Unlock()
+
+	if err != nil {
+		c.dropRecord()
+		return nil, err
+	}
+
+	c.acceptRecord(r)
+	return payload, nil
+}
+
+// recordHeader builds the header of a record: [type, seq (8 bytes)].
+func recordHeader(t byte, seq uint64) []byte {
+	header := make([]byte, recordHeaderSize)
+	header[0] = t
+	binary.BigEndian.PutUint64(header[1:], seq)
+	return header
+}
+
+// associatedData builds the data authenticated alongside a record: [type, seq (8 bytes), sessionID].
+func associatedData(t byte, seq uint64, sessionID []byte) []byte {
+	return append(recordHeader(t, seq), sessionID...)
+}
+
 // SetOnServerResponse updates onServerResponse func.
 func (c *ClientSocketManager) SetOnServerResponse(f func(byte, []byte)) {
 	c.onServerResponse = f
 }
 
-// SetOnPingResult updates onServerResponse func.
-func (c *ClientSocketManager) SetOnPingResult(f func(int64)) {
+// SetOnPingResult updates onPingResult func.
+func (c *ClientSocketManager) SetOnPingResult(f func(i.NetworkStats)) {
 	c.onPingResult = f
 }
 
+// SetOnConnectionStateChange updates onStateChange func.
+func (c *ClientSocketManager) SetOnConnectionStateChange(f func(i.ConnectionState)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onStateChange = f
+}
+
 // parseRecord parses a byte slice into a record struct.
 //
-// The input format depends on the record type:
-//   - For most records: [type, body]
-//   - For specific types (e.g., HandshakeClientHelloRecordType): [type, bodysize (2 bytes), body, extra]
+// The input format is [type, seq (8 bytes), body]. The sequence number increases
+// monotonically per direction and starts over with every handshake.
 func parseRecord(r []byte) (*record, error) {
-	if len(r) < 2 {
+	if len(r) < recordHeaderSize+1 {
 		return nil, ErrInvalidPayloadBodySize
 	}
 
 	return &record{
 		Type: r[0],
-		Body: r[1:],
+		Seq:  binary.BigEndian.Uint64(r[1:recordHeaderSize]),
+		Body: r[recordHeaderSize:],
 	}, nil
 }
 
@@ -424,3 +799,18 @@ func ClientWithLogger(l *log.Logger) ClientOption {
 		c.logger = l
 	}
 }
+
+// ClientWithMissedPongWindow sets the number of ping intervals without a pong
+// after which the session is considered dead and a reconnect is started.
+func ClientWithMissedPongWindow(n int) ClientOption {
+	return func(c *ClientSocketManager) {
+		c.missedPongWindow = n
+	}
+}
+
+// ClientWithMaxReconnectAttempts sets the number of handshakes attempted before the session is declared lost.
+func ClientWithMaxReconnectAttempts(n int) ClientOption {
+	return func(c *ClientSocketManager) {
+		c.maxReconnectAttempts = n
+	}
+}
@@ -0,0 +1,299 @@
+package udp
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sofc-t/puzzle-client/infrastruture/crypto"
+	udppb "github.com/sofc-t/puzzle-client/infrastruture/pb_encoder/udp"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+const testPingInterval = 20 * time.Millisecond
+
+var errExpiredToken = errors.New("expired token")
+
+// testServerKey is the RSA key of the test servers, generated once as it is slow.
+var testServerKey = sync.OnceValues(func() (*rsa.PrivateKey, error) {
+	return rsa.GenerateKey(rand.Reader, 2048)
+})
+
+// lossyProxy relays the datagrams between a client and a server, dropping every one of them while it is cut.
+type lossyProxy struct {
+	conn   *net.UDPConn
+	server *net.UDPAddr
+	client atomic.Pointer[net.UDPAddr]
+	cut    atomic.Bool
+}
+
+func newLossyProxy(t *testing.T, server *net.UDPAddr) *lossyProxy {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	p := &lossyProxy{conn: conn, server: server}
+	go p.relay()
+	return p
+}
+
+func (p *lossyProxy) addr() *net.UDPAddr {
+	return p.conn.LocalAddr().(*net.UDPAddr)
+}
+
+// relay forwards the datagrams of the server to the client and the others to the server, until the proxy is closed.
+func (p *lossyProxy) relay() {
+	buf := make([]byte, defaultReadBufferSize)
+	for {
+		n, addr, err := p.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+
+		to := p.server
+		if addr.String() == p.server.String() {
+			to = p.client.Load()
+		} else {
+			p.client.Store(addr)
+		}
+		if to != nil && !p.cut.Load() {
+			_, _ = p.conn.WriteToUDP(buf[:n], to)
+		}
+	}
+}
+
+// rotationCounter counts the key rotations of a KeyManager.
+type rotationCounter struct {
+	i.KeyManager
+	rotations atomic.Int32
+}
+
+func (r *rotationCounter) Rotate() error {
+	r.rotations.Add(1)
+	return r.KeyManager.Rotate()
+}
+
+// testSession is a client connected to a server through a lossy proxy.
+type testSession struct {
+	client *ClientSocketManager
+	server *ServerSocketManager
+	proxy  *lossyProxy
+	keys   *rotationCounter
+	states chan i.ConnectionState
+
+	expiredTokens   atomic.Bool  // ExpiredTokens makes the server reject the auth tokens.
+	authentications atomic.Int32 // Authentications counts the auth tokens accepted by the server.
+}
+
+// newTestSession sets up a client, a server and the proxy between them, the client is not connected yet.
+func newTestSession(t *testing.T, options ...ServerOption) *testSession {
+	key, err := testServerKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &testSession{states: make(chan i.ConnectionState, 32)}
+	s.server, err = NewServerSocketManager(ServerConfig{
+		Encoder:      &udppb.Protobuf{},
+		AsymmCrypto:  crypto.NewRSA(key),
+		CipherSuites: []i.AEAD{crypto.NewChaCha20Poly1305()},
+		Authenticate: func(token []byte) (string, error) {
+			if s.expiredTokens.Load() {
+				return "", errExpiredToken
+			}
+			s.authentications.Add(1)
+			return string(token), nil
+		},
+	}, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = s.server.Serve() }()
+	t.Cleanup(func() { _ = s.server.Close() })
+
+	s.proxy = newLossyProxy(t, s.server.Addr())
+	s.keys = &rotationCounter{KeyManager: crypto.NewEphemeralKeys()}
+	s.client, err = NewClientServerManager(ClientConfig{
+		ServerAddr:              s.proxy.addr(),
+		Encoder:                 &udppb.Protobuf{},
+		AsymmCrypto:             crypto.NewRSA(&rsa.PrivateKey{}),
+		ServerAsymmPubKey:       crypto.NewRSA(key).GetPublicKey(),
+		CipherSuites:            []i.AEAD{crypto.NewChaCha20Poly1305()},
+		Keys:                    s.keys,
+		OnConnectionSucces:      func() {},
+		OnConnectionStateChange: func(state i.ConnectionState) { s.states <- state },
+	}, ClientWithPingInterval(testPingInterval), ClientWithMissedPongWindow(5), ClientWithMaxReconnectAttempts(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// connect connects the client in the background, it is disconnected at the end of the test.
+func (s *testSession) connect(t *testing.T) {
+	go func() { _ = s.client.Connect([]byte("gopher")) }()
+	t.Cleanup(s.client.Disconnect)
+}
+
+// expectStates waits for the client to go through the expected states, in order.
+func (s *testSession) expectStates(t *testing.T, expected ...i.ConnectionState) {
+	t.Helper()
+	for _, want := range expected {
+		select {
+		case got := <-s.states:
+			if got != want {
+				t.Fatalf("Expected the connection to be %s, got: %s", want, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected the connection to be %s, got no state change", want)
+		}
+	}
+}
+
+// TestClientSocket contains all the tests related to the health of the client session.
+func TestClientSocket(t *testing.T) {
+	t.Run("ClientSocket_Lost", testClientSocket_Lost)
+	t.Run("ClientSocket_RecoverDegraded", testClientSocket_RecoverDegraded)
+	t.Run("ClientSocket_RecoverReconnecting", testClientSocket_RecoverReconnecting)
+	t.Run("ClientSocket_HandshakeRetry", testClientSocket_HandshakeRetry)
+	t.Run("ClientSocket_Resumption", testClientSocket_Resumption)
+	t.Run("ClientSocket_ExpiredTicket", testClientSocket_ExpiredTicket)
+	t.Run("ClientSocket_PongsInOrder", testClientSocket_PongsInOrder)
+	t.Run("ClientSocket_Reconnect", testClientSocket_Reconnect)
+}
+
+// testClientSocket_Lost tests that a session whose path drops goes from connected to degraded,
+// reconnecting and lost once every handshake attempt failed.
+func testClientSocket_Lost(t *testing.T) {
+	s := newTestSession(t)
+	s.connect(t)
+	s.expectStates(t, i.ConnectionReconnecting, i.ConnectionConnected)
+
+	s.proxy.cut.Store(true)
+	s.expectStates(t, i.ConnectionDegraded, i.ConnectionReconnecting, i.ConnectionLost)
+}
+
+// testClientSocket_RecoverDegraded tests that a degraded session is connected again by the next pong.
+func testClientSocket_RecoverDegraded(t *testing.T) {
+	s := newTestSession(t)
+	s.connect(t)
+	s.expectStates(t, i.ConnectionReconnecting, i.ConnectionConnected)
+
+	s.proxy.cut.Store(true)
+	s.expectStates(t, i.ConnectionDegraded)
+	s.proxy.cut.Store(false)
+	s.expectStates(t, i.ConnectionConnected)
+}
+
+// testClientSocket_RecoverReconnecting tests that a dead session is established again by a new handshake
+// once the path is back.
+func testClientSocket_RecoverReconnecting(t *testing.T) {
+	s := newTestSession(t)
+	s.connect(t)
+	s.expectStates(t, i.ConnectionReconnecting, i.ConnectionConnected)
+
+	s.proxy.cut.Store(true)
+	s.expectStates(t, i.ConnectionDegraded, i.ConnectionReconnecting)
+	s.proxy.cut.Store(false)
+	s.expectStates(t, i.ConnectionConnected)
+
+	if err := s.client.SendToServer(PingRecordType, nil); err != nil {
+		t.Errorf("Expected the new session to be usable, got error: %s", err)
+	}
+}
+
+// testClientSocket_HandshakeRetry tests that every handshake attempt rotates the key, so the sequence numbers
+// starting over with the handshake are never reused under the same key.
+func testClientSocket_HandshakeRetry(t *testing.T) {
+	s := newTestSession(t)
+	s.proxy.cut.Store(true)
+	s.connect(t)
+	s.expectStates(t, i.ConnectionReconnecting, i.ConnectionLost)
+
+	// The first handshake and the two retries.
+	if rotations := s.keys.rotations.Load(); rotations != 3 {
+		t.Errorf("Expected 3 key rotations, got: %d", rotations)
+	}
+}
+
+// testClientSocket_Resumption tests that a dead session is resumed with the ticket of the server,
+// without authenticating the token again.
+func testClientSocket_Resumption(t *testing.T) {
+	s := newTestSession(t)
+	s.connect(t)
+	s.expectStates(t, i.ConnectionReconnecting, i.ConnectionConnected)
+
+	s.expiredTokens.Store(true)
+	s.proxy.cut.Store(true)
+	s.expectStates(t, i.ConnectionDegraded, i.ConnectionReconnecting)
+	s.proxy.cut.Store(false)
+	s.expectStates(t, i.ConnectionConnected)
+
+	if authentications := s.authentications.Load(); authentications != 1 {
+		t.Errorf("Expected the token to be authenticated once, got: %d", authentications)
+	}
+}
+
+// testClientSocket_ExpiredTicket tests that an expired ticket does not resume the session, the token being
+// authenticated instead.
+func testClientSocket_ExpiredTicket(t *testing.T) {
+	s := newTestSession(t, ServerWithTicketLifetime(time.Nanosecond))
+	s.connect(t)
+	s.expectStates(t, i.ConnectionReconnecting, i.ConnectionConnected)
+
+	s.expiredTokens.Store(true)
+	s.proxy.cut.Store(true)
+	s.expectStates(t, i.ConnectionDegraded, i.ConnectionReconnecting)
+	s.proxy.cut.Store(false)
+	s.expectStates(t, i.ConnectionLost)
+}
+
+// testClientSocket_PongsInOrder tests that the ping results are delivered one at a time in the order of the pongs,
+// as the clock sync expects them.
+func testClientSocket_PongsInOrder(t *testing.T) {
+	s := newTestSession(t)
+	results := make(chan i.NetworkStats, 64)
+	s.client.SetOnPingResult(func(stats i.NetworkStats) { results <- stats })
+	s.connect(t)
+
+	var last time.Time
+	for n := 0; n < 10; n++ {
+		select {
+		case stats := <-results:
+			if !stats.MeasuredAt.After(last) {
+				t.Fatalf("Expected the pong measured at %s after the one at %s", stats.MeasuredAt, last)
+			}
+			last = stats.MeasuredAt
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected 10 ping results, got: %d", n)
+		}
+	}
+}
+
+// testClientSocket_Reconnect tests that a client connected again after a disconnect keeps pinging,
+// so a dead session is still detected.
+func testClientSocket_Reconnect(t *testing.T) {
+	s := newTestSession(t)
+	stopped := make(chan error)
+	go func() { stopped <- s.client.Connect([]byte("gopher")) }()
+	s.expectStates(t, i.ConnectionReconnecting, i.ConnectionConnected)
+
+	s.client.Disconnect()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected the connection to stop")
+	}
+
+	s.connect(t)
+	s.expectStates(t, i.ConnectionReconnecting, i.ConnectionConnected)
+	s.proxy.cut.Store(true)
+	s.expectStates(t, i.ConnectionDegraded)
+}
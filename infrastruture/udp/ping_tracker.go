@@ -0,0 +1,111 @@
+package udp
+
+import (
+	"time"
+
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+const (
+	pingHistorySize = 30 // Pings kept for the loss and the RTT history.
+	jitterGain      = 16 // JitterGain smooths the jitter over about that many pongs, as in RFC 3550.
+)
+
+// pingSample is a ping sent to the server, timestamped in milliseconds on the local clock.
+type pingSample struct {
+	sentAt   int64
+	answered bool
+}
+
+// pingTracker measures the RTT, the clock offset, the jitter and the loss from the pings and their pongs.
+//
+// A pong carries the time its ping was sent (t1), the server times it was received (t2) and answered (t3),
+// and it is received at t4. The RTT is (t4 - t1) - (t3 - t2), the offset ((t2 - t1) + (t3 - t4)) / 2.
+type pingTracker struct {
+	pings   []pingSample    // Pings holds the last pingHistorySize pings, oldest first.
+	history []time.Duration // History holds the RTT of the last pingHistorySize answered pings, oldest first.
+	rtt     time.Duration
+	offset  time.Duration
+	jitter  time.Duration
+	at      time.Time // At is the local time the last pong was received.
+}
+
+// sent records a ping sent at sentAt.
+func (p *pingTracker) sent(sentAt int64) {
+	p.pings = append(p.pings, pingSample{sentAt: sentAt})
+	if len(p.pings) > pingHistorySize {
+		p.pings = p.pings[1:]
+	}
+}
+
+// answer records the pong received at receivedAt. It reports false, leaving the stats as they are,
+// when the pong does not answer a recent ping or answers one already answered.
+func (p *pingTracker) answer(pong i.PongRecord, receivedAt int64) bool {
+	sample := p.ping(pong.GetPingSentAt())
+	if sample == nil || sample.answered {
+		return false
+	}
+	sample.answered = true
+
+	t1, t2, t3, t4 := pong.GetPingSentAt(), pong.GetReceivedAt(), pong.GetSentAt(), receivedAt
+	rtt := msDuration(max((t4-t1)-(t3-t2), 0))
+	p.offset = msDuration(((t2 - t1) + (t3 - t4)) / 2)
+
+	if len(p.history) > 0 {
+		delta := rtt - p.rtt
+		if delta < 0 {
+			delta = -delta
+		}
+		p.jitter += (delta - p.jitter) / jitterGain
+	}
+	p.rtt = rtt
+	p.at = time.UnixMilli(receivedAt)
+
+	p.history = append(p.history, rtt)
+	if len(p.history) > pingHistorySize {
+		p.history = p.history[1:]
+	}
+	return true
+}
+
+// stats returns a snapshot of the measures. The last ping is left out of the loss, its pong may still be coming.
+func (p *pingTracker) stats() i.NetworkStats {
+	lost, sent := 0, len(p.pings)-1
+	for _, sample := range p.pings[:max(sent, 0)] {
+		if !sample.answered {
+			lost++
+		}
+	}
+
+	stats := i.NetworkStats{
+		RTT:        p.rtt,
+		Offset:     p.offset,
+		Jitter:     p.jitter,
+		History:    append([]time.Duration{}, p.history...),
+		MeasuredAt: p.at,
+	}
+	if sent > 0 {
+		stats.Loss = float64(lost) / float64(sent)
+	}
+	return stats
+}
+
+// reset forgets every ping, for a new connection.
+func (p *pingTracker) reset() {
+	*p = pingTracker{}
+}
+
+// ping returns the recent ping sent at sentAt, nil if there is none.
+func (p *pingTracker) ping(sentAt int64) *pingSample {
+	for n := range p.pings {
+		if p.pings[n].sentAt == sentAt {
+			return &p.pings[n]
+		}
+	}
+	return nil
+}
+
+// msDuration converts milliseconds to a time.Duration.
+func msDuration(ms int64) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
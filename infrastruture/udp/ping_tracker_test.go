@@ -0,0 +1,108 @@
+package udp
+
+import (
+	"testing"
+	"time"
+
+	udppb "github.com/sofc-t/puzzle-client/infrastruture/pb_encoder/udp"
+)
+
+// TestPingTracker contains all the tests related to the network measures of the pings.
+func TestPingTracker(t *testing.T) {
+	t.Run("PingTracker_RTTAndOffset", testPingTracker_RTTAndOffset)
+	t.Run("PingTracker_Jitter", testPingTracker_Jitter)
+	t.Run("PingTracker_Loss", testPingTracker_Loss)
+	t.Run("PingTracker_UnknownPong", testPingTracker_UnknownPong)
+	t.Run("PingTracker_History", testPingTracker_History)
+}
+
+// testPingTracker_RTTAndOffset tests that the RTT is measured on the local clock and the offset NTP-style,
+// with a server clock 500ms ahead, 20ms away each way and answering in 5ms.
+func testPingTracker_RTTAndOffset(t *testing.T) {
+	p := &pingTracker{}
+	p.sent(1000)
+	if !p.answer(&udppb.Pong{PingSentAt: 1000, ReceivedAt: 1520, SentAt: 1525}, 1045) {
+		t.Fatalf("Expected the pong to answer the ping")
+	}
+
+	stats := p.stats()
+	if stats.RTT != 40*time.Millisecond {
+		t.Errorf("Expected a 40ms RTT, got: %s", stats.RTT)
+	}
+	if stats.Offset != 500*time.Millisecond {
+		t.Errorf("Expected a 500ms offset, got: %s", stats.Offset)
+	}
+	if !stats.MeasuredAt.Equal(time.UnixMilli(1045)) {
+		t.Errorf("Expected the pong to be measured at 1045ms, got: %s", stats.MeasuredAt)
+	}
+}
+
+// testPingTracker_Jitter tests that the jitter follows the variations of the RTT and not the RTT itself.
+func testPingTracker_Jitter(t *testing.T) {
+	p := &pingTracker{}
+	for n := int64(0); n < 10; n++ {
+		sentAt := n * 1000
+		p.sent(sentAt)
+		p.answer(&udppb.Pong{PingSentAt: sentAt, ReceivedAt: sentAt, SentAt: sentAt}, sentAt+100)
+	}
+	if jitter := p.stats().Jitter; jitter != 0 {
+		t.Fatalf("Expected no jitter with a steady RTT, got: %s", jitter)
+	}
+
+	for n := int64(10); n < 20; n++ {
+		sentAt := n * 1000
+		p.sent(sentAt)
+		p.answer(&udppb.Pong{PingSentAt: sentAt, ReceivedAt: sentAt, SentAt: sentAt}, sentAt+100+(n%2)*40)
+	}
+	if jitter := p.stats().Jitter; jitter <= 0 || jitter >= 40*time.Millisecond {
+		t.Errorf("Expected a jitter between 0 and 40ms, got: %s", jitter)
+	}
+}
+
+// testPingTracker_Loss tests that the unanswered pings count as lost, but the last one.
+func testPingTracker_Loss(t *testing.T) {
+	p := &pingTracker{}
+	for n := int64(0); n < 5; n++ {
+		p.sent(n * 1000)
+	}
+	for _, sentAt := range []int64{0, 2000, 3000} {
+		p.answer(&udppb.Pong{PingSentAt: sentAt}, sentAt+10)
+	}
+
+	if loss := p.stats().Loss; loss != 0.25 {
+		t.Errorf("Expected a loss of 1 ping out of 4, got: %g", loss)
+	}
+}
+
+// testPingTracker_UnknownPong tests that a pong answering no recent ping, or answering one twice, is ignored.
+func testPingTracker_UnknownPong(t *testing.T) {
+	p := &pingTracker{}
+	p.sent(1000)
+
+	if p.answer(&udppb.Pong{PingSentAt: 500}, 1010) {
+		t.Errorf("Expected a pong of an unknown ping to be ignored")
+	}
+	if !p.answer(&udppb.Pong{PingSentAt: 1000}, 1010) || p.answer(&udppb.Pong{PingSentAt: 1000}, 1500) {
+		t.Errorf("Expected only the first pong of a ping to be counted")
+	}
+	if stats := p.stats(); stats.RTT != 10*time.Millisecond || len(stats.History) != 1 {
+		t.Errorf("Expected the duplicated pong to leave the stats alone, got: %+v", stats)
+	}
+}
+
+// testPingTracker_History tests that only the last pingHistorySize RTTs are kept, oldest first.
+func testPingTracker_History(t *testing.T) {
+	p := &pingTracker{}
+	for n := int64(0); n < pingHistorySize+5; n++ {
+		p.sent(n * 1000)
+		p.answer(&udppb.Pong{PingSentAt: n * 1000}, n*1000+n)
+	}
+
+	history := p.stats().History
+	if len(history) != pingHistorySize {
+		t.Fatalf("Expected %d RTTs, got: %d", pingHistorySize, len(history))
+	}
+	if history[0] != 5*time.Millisecond || history[len(history)-1] != (pingHistorySize+4)*time.Millisecond {
+		t.Errorf("Expected the oldest RTTs to be dropped, got: %v", history)
+	}
+}
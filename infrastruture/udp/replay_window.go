@@ -0,0 +1,69 @@
+package udp
+
+import "errors"
+
+var (
+	ErrDuplicateRecord = errors.New("duplicate record")
+	ErrRecordTooOld    = errors.New("record is older than the replay window")
+)
+
+const replayWindowSize uint64 = 64
+
+// replayWindow is a DTLS style sliding anti-replay window (RFC 6347 section 4.1.2.6).
+//
+// It tracks the highest sequence number accepted so far and a bitmap of the replayWindowSize
+// sequence numbers below it. Records are checked before decryption and only marked as seen
+// once they are authenticated, so forged records cannot move the window.
+type replayWindow struct {
+	highest uint64 // Highest sequence number accepted.
+	bitmap  uint64 // Bit n is set when highest-n was accepted.
+	started bool   // Started is false until the first record is accepted.
+}
+
+// check reports whether seq may be accepted without marking it as seen.
+func (w *replayWindow) check(seq uint64) error {
+	if !w.started || seq > w.highest {
+		return nil
+	}
+
+	diff := w.highest - seq
+	if diff >= replayWindowSize {
+		return ErrRecordTooOld
+	}
+
+	if w.bitmap&(1<<diff) != 0 {
+		return ErrDuplicateRecord
+	}
+	return nil
+}
+
+// accept marks seq as seen and slides the window when seq is the new highest sequence number.
+// It reports whether the record arrived out of order. check must have been called first.
+func (w *replayWindow) accept(seq uint64) bool {
+	if !w.started {
+		w.started = true
+		w.highest = seq
+		w.bitmap = 1
+		return false
+	}
+
+	if seq > w.highest {
+		shift := seq - w.highest
+		if shift >= replayWindowSize {
+			w.bitmap = 0
+		} else {
+			w.bitmap <<= shift
+		}
+		w.bitmap |= 1
+		w.highest = seq
+		return false
+	}
+
+	w.bitmap |= 1 << (w.highest - seq)
+	return true
+}
+
+// reset forgets every seen sequence number. It is called when a new handshake starts a new epoch.
+func (w *replayWindow) reset() {
+	*w = replayWindow{}
+}
@@ -0,0 +1,109 @@
+package udp
+
+import "testing"
+
+// TestReplayWindow contains all the tests related to the anti-replay window.
+func TestReplayWindow(t *testing.T) {
+	t.Run("ReplayWindow_InOrder", testReplayWindow_InOrder)
+	t.Run("ReplayWindow_Duplicate", testReplayWindow_Duplicate)
+	t.Run("ReplayWindow_OutOfOrder", testReplayWindow_OutOfOrder)
+	t.Run("ReplayWindow_TooOld", testReplayWindow_TooOld)
+	t.Run("ReplayWindow_LargeJump", testReplayWindow_LargeJump)
+	t.Run("ReplayWindow_CheckDoesNotMark", testReplayWindow_CheckDoesNotMark)
+	t.Run("ReplayWindow_Reset", testReplayWindow_Reset)
+}
+
+// testReplayWindow_InOrder tests that increasing sequence numbers are accepted in order.
+func testReplayWindow_InOrder(t *testing.T) {
+	w := &replayWindow{}
+	for seq := uint64(0); seq < 200; seq++ {
+		if err := w.check(seq); err != nil {
+			t.Fatalf("Expected sequence %d to be accepted, got error: %s", seq, err)
+		}
+		if w.accept(seq) {
+			t.Fatalf("Expected sequence %d to be in order", seq)
+		}
+	}
+}
+
+// testReplayWindow_Duplicate tests that an accepted sequence number is rejected the second time.
+func testReplayWindow_Duplicate(t *testing.T) {
+	w := &replayWindow{}
+	for _, seq := range []uint64{1, 2, 3} {
+		_ = w.check(seq)
+		w.accept(seq)
+	}
+
+	for _, seq := range []uint64{1, 2, 3} {
+		if err := w.check(seq); err != ErrDuplicateRecord {
+			t.Errorf("Expected error %s for sequence %d, got: %v", ErrDuplicateRecord, seq, err)
+		}
+	}
+}
+
+// testReplayWindow_OutOfOrder tests that a late record inside the window is accepted once.
+func testReplayWindow_OutOfOrder(t *testing.T) {
+	w := &replayWindow{}
+	w.accept(10)
+	w.accept(12)
+
+	if err := w.check(11); err != nil {
+		t.Fatalf("Expected late sequence to be accepted, got error: %s", err)
+	}
+	if !w.accept(11) {
+		t.Errorf("Expected sequence 11 to be reported as out of order")
+	}
+	if err := w.check(11); err != ErrDuplicateRecord {
+		t.Errorf("Expected error %s, got: %v", ErrDuplicateRecord, err)
+	}
+}
+
+// testReplayWindow_TooOld tests that records behind the window are rejected.
+func testReplayWindow_TooOld(t *testing.T) {
+	w := &replayWindow{}
+	w.accept(100)
+
+	if err := w.check(100 - replayWindowSize); err != ErrRecordTooOld {
+		t.Errorf("Expected error %s, got: %v", ErrRecordTooOld, err)
+	}
+	if err := w.check(100 - replayWindowSize + 1); err != nil {
+		t.Errorf("Expected the oldest sequence inside the window to be accepted, got error: %s", err)
+	}
+}
+
+// testReplayWindow_LargeJump tests that a jump larger than the window clears the bitmap.
+func testReplayWindow_LargeJump(t *testing.T) {
+	w := &replayWindow{}
+	w.accept(1)
+	w.accept(1 + 2*replayWindowSize)
+
+	if w.bitmap != 1 {
+		t.Errorf("Expected bitmap to only hold the highest sequence, got: %b", w.bitmap)
+	}
+	if err := w.check(1 + replayWindowSize + 5); err != nil {
+		t.Errorf("Expected unseen sequence inside the window to be accepted, got error: %s", err)
+	}
+}
+
+// testReplayWindow_CheckDoesNotMark tests that checking alone does not consume a sequence number.
+func testReplayWindow_CheckDoesNotMark(t *testing.T) {
+	w := &replayWindow{}
+	w.accept(5)
+	_ = w.check(4)
+
+	if err := w.check(4); err != nil {
+		t.Errorf("Expected unaccepted sequence to pass the check again, got error: %s", err)
+	}
+}
+
+// testReplayWindow_Reset tests that a reset window accepts sequence numbers of a new epoch.
+func testReplayWindow_Reset(t *testing.T) {
+	w := &replayWindow{}
+	w.accept(0)
+	w.accept(1)
+	w.reset()
+
+	if err := w.check(0); err != nil {
+		t.Errorf("Expected sequence to be accepted after reset, got error: %s", err)
+	}
+}
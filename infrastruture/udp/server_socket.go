@@ -0,0 +1,535 @@
+package udp
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+type ServerOption func(*ServerSocketManager)
+
+var (
+	ErrInvalidSessionID   = errors.New("invalid session id")
+	ErrMissingKeyMaterial = errors.New("client hello carries no usable key")
+)
+
+const (
+	sessionIDSize int = 16
+	cookieSize    int = 32
+	ticketSize    int = 32
+
+	defaultTicketLifetime = 10 * time.Minute
+)
+
+// resumption is the session a resumption ticket resumes.
+type resumption struct {
+	clientID  string
+	expiresAt time.Time
+}
+
+// serverSession holds the handshake and session state of a single client address.
+type serverSession struct {
+	addr      *net.UDPAddr
+	key       []byte       // Key is the symmetric key of the client.
+	aead      i.AEAD       // AEAD is the negotiated cipher suite, nil when the legacy SymmCrypto is used.
+	sessionID []byte       // SessionID is set once the handshake is completed.
+	clientID  string       // ClientID is the identity returned by Authenticate.
+	sendSeq   uint64       // SendSeq is the sequence number of the next outgoing record.
+	replay    replayWindow // Replay rejects duplicated and too old incoming records.
+}
+
+// ServerSocketManager is the server side of the UDP protocol spoken by ClientSocketManager.
+// It performs the HelloVerify/ServerHello handshake, answers pings and hands every other record to onClientRecord.
+type ServerSocketManager struct {
+	conn            *net.UDPConn                 // Conn is the UDP socket the server listens on.
+	logger          *log.Logger                  // Logger is used to log messages and errors.
+	encoder         i.SocketEncoder              // Encoder is an implementation of Encoder used to encode and decode messages.
+	readBufferSize  int                          // Maximum buffer size for incoming bytes.
+	asymmCrypto     i.Asymmetric                 // AsymmCrypto decrypts the client hello records.
+	symmCrypto      i.Symmetric                  // SymmCrypto is the legacy symmetric encryption used when no cipher suite is offered.
+	cipherSuites    []i.AEAD                     // CipherSuites are the supported suites in order of preference.
+	keyExchange     func([]byte) ([]byte, error) // KeyExchange derives the session key from the client's key share.
+	authenticate    func([]byte) (string, error) // Authenticate maps the client's auth token to its identity.
+	onClientConnect func(string)                 // Callback function to call when a client completes the handshake.
+	onClientRecord  func(string, byte, []byte)   // Callback function to call when a client sends a message besides handshake and ping.
+	cookieSecret    []byte                       // CookieSecret signs the HelloVerify cookies.
+	sessions        map[string]*serverSession    // Sessions are keyed by the client address.
+	tickets         map[string]resumption        // Tickets are the resumption tickets issued in the ServerHello records.
+	ticketLifetime  time.Duration                // TicketLifetime is the time a ticket can resume its session for.
+	mu              sync.Mutex                   // Mu guards the sessions and the tickets.
+	stopSignal      chan struct{}                // StopSignal stops the read routine.
+}
+
+// ServerConfig defines the configuration settings required to serve clients.
+type ServerConfig struct {
+	ListenAddr      *net.UDPAddr                 // ListenAddr is the UDP address to listen on, a random port is picked when it is zero.
+	Encoder         i.SocketEncoder              // Encoder is an implementation of Encoder to encode and decode messages.
+	AsymmCrypto     i.Asymmetric                 // AsymmCrypto holds the server's private key the client hello records are encrypted with.
+	SymmCrypto      i.Symmetric                  // SymmCrypto is an optional legacy symmetric encryption for clients offering no cipher suite.
+	CipherSuites    []i.AEAD                     // CipherSuites are the supported suites in order of preference.
+	KeyExchange     func([]byte) ([]byte, error) // KeyExchange is optional, client hellos with a key share are rejected without it.
+	Authenticate    func([]byte) (string, error) // Authenticate maps the auth token of the client hello to the client identity.
+	OnClientConnect func(string)                 // Callback function to call when a client completes the handshake.
+	OnClientRecord  func(string, byte, []byte)   // Callback function to call when a client sends a message besides handshake and ping.
+}
+
+// NewServerSocketManager creates a new instance of ServerSocketManager listening on the configured address.
+func NewServerSocketManager(c ServerConfig, options ...ServerOption) (*ServerSocketManager, error) {
+	addr := c.ListenAddr
+	if addr == nil {
+		addr = &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	}
+
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	manager := &ServerSocketManager{
+		conn:            conn,
+		encoder:         c.Encoder,
+		asymmCrypto:     c.AsymmCrypto,
+		symmCrypto:      c.SymmCrypto,
+		cipherSuites:    c.CipherSuites,
+		keyExchange:     c.KeyExchange,
+		authenticate:    c.Authenticate,
+		onClientConnect: c.OnClientConnect,
+		onClientRecord:  c.OnClientRecord,
+		cookieSecret:    secret,
+		sessions:        make(map[string]*serverSession),
+		tickets:         make(map[string]resumption),
+		stopSignal:      make(chan struct{}),
+	}
+
+	for _, opt := range options {
+		opt(manager)
+	}
+
+	if manager.readBufferSize == 0 {
+		manager.readBufferSize = defaultReadBufferSize
+	}
+
+	if manager.ticketLifetime == 0 {
+		manager.ticketLifetime = defaultTicketLifetime
+	}
+
+	if manager.logger == nil {
+		// Discard logging if no logger is set
+		manager.logger = log.New(io.Discard, "", 0)
+	}
+
+	return manager, nil
+}
+
+// Addr returns the address the server listens on.
+func (s *ServerSocketManager) Addr() *net.UDPAddr {
+	return s.conn.LocalAddr().(*net.UDPAddr)
+}
+
+// Serve reads and handles records until Close is called.
+func (s *ServerSocketManager) Serve() error {
+	for {
+		buf := make([]byte, s.readBufferSize+1) // Intentionally create more space than allowed for checking
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-s.stopSignal:
+				return nil
+			default:
+			}
+
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
+			s.logger.Printf("error while reading from udp: %s", err)
+			continue
+		} else if n > s.readBufferSize {
+			s.logger.Println(ErrMaximumPayloadSizeLimit)
+			continue
+		}
+
+		s.handleRawRecord(buf[:n], addr)
+	}
+}
+
+// Close stops serving and closes the socket.
+func (s *ServerSocketManager) Close() error {
+	close(s.stopSignal)
+	return s.conn.Close()
+}
+
+// SendToClient encrypts and sends a message of type t to the client with the given identity.
+func (s *ServerSocketManager) SendToClient(clientID string, t byte, message []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, session := range s.sessions {
+		if session.clientID == clientID && len(session.sessionID) > 0 {
+			return s.sendRecord(session, t, message)
+		}
+	}
+	return ErrClientNotFound
+}
+
+// Broadcast encrypts and sends a message of type t to every client that completed the handshake.
+func (s *ServerSocketManager) Broadcast(t byte, message []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, session := range s.sessions {
+		if len(session.sessionID) == 0 {
+			continue
+		}
+		if err := s.sendRecord(session, t, message); err != nil {
+			s.logger.Printf("error while sending record to %s: %s", session.addr, err)
+		}
+	}
+}
+
+// handleRawRecord processes an incoming raw record based on its type.
+func (s *ServerSocketManager) handleRawRecord(payload []byte, addr *net.UDPAddr) {
+	if len(payload) < minimumPayloadSize {
+		s.logger.Println(ErrMinimumPayloadSizeLimit)
+		return
+	}
+
+	record, err := parseRecord(payload)
+	if err != nil {
+		s.logger.Printf("error while parsing record: %s", err)
+		return
+	}
+
+	if record.Type == ClientHelloRecordType {
+		err = s.handleClientHelloRecord(record, addr)
+	} else {
+		err = s.handleSessionRecord(record, addr)
+	}
+
+	if err != nil {
+		s.logger.Printf("error while handling record %d from %s: %s", record.Type, addr, err)
+	}
+}
+
+// handleClientHelloRecord answers a client hello without cookie with a HelloVerify carrying one,
+// and a client hello with a valid cookie and auth token with a ServerHello establishing the session.
+// A valid resumption ticket resumes the session of its client without authenticating the token, which may
+// have expired since. Every ServerHello issues a new ticket.
+func (s *ServerSocketManager) handleClientHelloRecord(r *record, addr *net.UDPAddr) error {
+	payload, err := s.asymmCrypto.Decrypt(r.Body)
+	if err != nil {
+		return err
+	}
+
+	hello, err := s.encoder.UnmarshalHandshake(payload)
+	if err != nil {
+		return err
+	}
+
+	key, err := s.clientKey(hello)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(hello.GetCookie()) == 0 {
+		// Every handshake starts a new epoch with a new key, so sequence numbers start over.
+		session := &serverSession{
+			addr: addr,
+			key:  key,
+			aead: s.pickSuite(hello.GetCipherSuites()),
+		}
+		session.replay.accept(r.Seq)
+		s.sessions[addr.String()] = session
+
+		verify := s.encoder.NewHandshakeRecord()
+		verify.SetCookie(s.cookie(addr, hello.GetRandom()))
+		return s.sendHandshake(session, HelloVerifyRecordType, verify)
+	}
+
+	session, ok := s.sessions[addr.String()]
+	if !ok {
+		return ErrClientAddressIsNotRegistered
+	}
+
+	if err := session.replay.check(r.Seq); err != nil {
+		return err
+	}
+
+	if !hmac.Equal(hello.GetCookie(), s.cookie(addr, hello.GetRandom())) || !bytes.Equal(key, session.key) {
+		return ErrClientCookieIsInvalid
+	}
+
+	token, err := s.open(session, r.Type, r.Seq, nil, hello.GetToken())
+	if err != nil {
+		return err
+	}
+	session.replay.accept(r.Seq)
+
+	clientID, resumed := s.resume(hello.GetTicket())
+	if !resumed {
+		clientID, err = s.authenticate(token)
+		if err != nil {
+			return err
+		}
+	}
+
+	ticket, err := s.issueTicket(clientID)
+	if err != nil {
+		return err
+	}
+
+	sessionID := make([]byte, sessionIDSize)
+	if _, err := rand.Read(sessionID); err != nil {
+		return err
+	}
+	session.sessionID = sessionID
+	session.clientID = clientID
+
+	// A client reconnecting from a new address replaces its previous session.
+	for key, other := range s.sessions {
+		if other != session && other.clientID == clientID {
+			delete(s.sessions, key)
+		}
+	}
+
+	serverHello := s.encoder.NewHandshakeRecord()
+	serverHello.SetSessionId(sessionID)
+	serverHello.SetTicket(ticket)
+	serverHello.SetTimestamp(time.Now().UnixNano() / int64(time.Millisecond))
+	if err := s.sendHandshake(session, ServerHelloRecordType, serverHello); err != nil {
+		return err
+	}
+
+	if s.onClientConnect != nil {
+		go s.onClientConnect(clientID)
+	}
+	return nil
+}
+
+// handleSessionRecord authenticates a record of an established session and answers pings.
+// Other records are handed to onClientRecord in the order they are received.
+func (s *ServerSocketManager) handleSessionRecord(r *record, addr *net.UDPAddr) error {
+	clientID, payload, err := s.openSessionRecord(r, addr)
+	if err != nil {
+		return err
+	}
+
+	if r.Type != PingRecordType {
+		if s.onClientRecord != nil {
+			s.onClientRecord(clientID, r.Type, payload)
+		}
+		return nil
+	}
+
+	ping, err := s.encoder.UnmarshalPing(payload)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	pong := s.encoder.NewPongRecord()
+	pong.SetPingSentAt(ping.GetSentAt())
+	pong.SetReceivedAt(now)
+	pong.SetSentAt(now)
+
+	pongPayload, err := s.encoder.MarshalPong(pong)
+	if err != nil {
+		return err
+	}
+	return s.SendToClient(clientID, PongRecordType, pongPayload)
+}
+
+// openSessionRecord decrypts a record of an established session and marks it as seen.
+// The body is prefixed with the session ID inside the encryption, it is returned without it.
+func (s *ServerSocketManager) openSessionRecord(r *record, addr *net.UDPAddr) (string, []byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[addr.String()]
+	if !ok {
+		return "", nil, ErrClientAddressIsNotRegistered
+	}
+	if len(session.sessionID) == 0 {
+		return "", nil, ErrClientSessionNotFound
+	}
+
+	if err := session.replay.check(r.Seq); err != nil {
+		return "", nil, err
+	}
+
+	payload, err := s.open(session, r.Type, r.Seq, session.sessionID, r.Body)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !bytes.HasPrefix(payload, session.sessionID) {
+		return "", nil, ErrInvalidSessionID
+	}
+	session.replay.accept(r.Seq)
+	return session.clientID, payload[len(session.sessionID):], nil
+}
+
+// resume returns the client of a resumption ticket, it reports false when the ticket is unknown or expired.
+// A ticket resumes a single session. The caller must hold s.mu.
+func (s *ServerSocketManager) resume(ticket []byte) (string, bool) {
+	if len(ticket) == 0 {
+		return "", false
+	}
+
+	r, ok := s.tickets[string(ticket)]
+	delete(s.tickets, string(ticket))
+	if !ok || time.Now().After(r.expiresAt) {
+		return "", false
+	}
+	return r.clientID, true
+}
+
+// issueTicket returns a new resumption ticket for the client, replacing the previous one. The caller must hold s.mu.
+func (s *ServerSocketManager) issueTicket(clientID string) ([]byte, error) {
+	ticket := make([]byte, ticketSize)
+	if _, err := rand.Read(ticket); err != nil {
+		return nil, err
+	}
+
+	for key, r := range s.tickets {
+		if r.clientID == clientID {
+			delete(s.tickets, key)
+		}
+	}
+	s.tickets[string(ticket)] = resumption{clientID: clientID, expiresAt: time.Now().Add(s.ticketLifetime)}
+	return ticket, nil
+}
+
+// clientKey returns the symmetric key of the client hello, derived from the key share when one is sent.
+func (s *ServerSocketManager) clientKey(hello i.HandshakeRecord) ([]byte, error) {
+	if share := hello.GetKeyShare(); len(share) > 0 {
+		if s.keyExchange == nil {
+			return nil, ErrMissingKeyMaterial
+		}
+		return s.keyExchange(share)
+	}
+
+	if len(hello.GetKey()) < insecureSymmKeySize {
+		return nil, ErrInsecureEncryptionKeySize
+	}
+	return hello.GetKey(), nil
+}
+
+// pickSuite returns the first supported suite offered by the client, nil when the legacy SymmCrypto has to be used.
+func (s *ServerSocketManager) pickSuite(offered []uint32) i.AEAD {
+	for _, suite := range s.cipherSuites {
+		for _, id := range offered {
+			if suite.Suite() == id {
+				return suite
+			}
+		}
+	}
+	return nil
+}
+
+// cookie binds a HelloVerify cookie to the client address and the random of its hello.
+func (s *ServerSocketManager) cookie(addr *net.UDPAddr, random []byte) []byte {
+	mac := hmac.New(sha256.New, s.cookieSecret)
+	mac.Write([]byte(addr.String()))
+	mac.Write(random)
+	return mac.Sum(nil)[:cookieSize]
+}
+
+// sendHandshake seals a HelloVerify or ServerHello record announcing the negotiated suite. The caller must hold s.mu.
+func (s *ServerSocketManager) sendHandshake(session *serverSession, t byte, h i.HandshakeRecord) error {
+	if session.aead != nil {
+		h.SetCipherSuite(session.aead.Suite())
+	}
+
+	payload, err := s.encoder.MarshalHandshake(h)
+	if err != nil {
+		return err
+	}
+
+	seq := session.sendSeq
+	session.sendSeq++
+	body, err := s.seal(session, t, seq, nil, payload)
+	if err != nil {
+		return err
+	}
+	return s.writeRecord(session.addr, t, seq, body)
+}
+
+// sendRecord seals and sends a record of an established session. The caller must hold s.mu.
+func (s *ServerSocketManager) sendRecord(session *serverSession, t byte, message []byte) error {
+	seq := session.sendSeq
+	session.sendSeq++
+	body, err := s.seal(session, t, seq, session.sessionID, message)
+	if err != nil {
+		return err
+	}
+	return s.writeRecord(session.addr, t, seq, body)
+}
+
+// seal encrypts p with the session's suite, binding the record header and session ID as associated data.
+func (s *ServerSocketManager) seal(session *serverSession, t byte, seq uint64, sessionID []byte, p []byte) ([]byte, error) {
+	if session.aead != nil {
+		return session.aead.Seal(p, session.key, associatedData(t, seq, sessionID))
+	}
+
+	if s.symmCrypto == nil {
+		return nil, ErrNoCipherSuiteAccepted
+	}
+	return s.symmCrypto.Encrypt(p, session.key)
+}
+
+// open decrypts c with the session's suite, checking the record header and session ID as associated data.
+func (s *ServerSocketManager) open(session *serverSession, t byte, seq uint64, sessionID []byte, c []byte) ([]byte, error) {
+	if session.aead != nil {
+		return session.aead.Open(c, session.key, associatedData(t, seq, sessionID))
+	}
+
+	if s.symmCrypto == nil {
+		return nil, ErrNoCipherSuiteAccepted
+	}
+	return s.symmCrypto.Decrypt(c, session.key)
+}
+
+// writeRecord writes a record in the [type, seq (8 bytes), body] format to addr.
+func (s *ServerSocketManager) writeRecord(addr *net.UDPAddr, t byte, seq uint64, body []byte) error {
+	_, err := s.conn.WriteToUDP(append(recordHeader(t, seq), body...), addr)
+	return err
+}
+
+// ServerWithReadBufferSize sets the read buffer size for the ServerSocketManager.
+func ServerWithReadBufferSize(bs int) ServerOption {
+	return func(s *ServerSocketManager) {
+		s.readBufferSize = bs
+	}
+}
+
+// ServerWithLogger sets the logger for the ServerSocketManager.
+func ServerWithLogger(l *log.Logger) ServerOption {
+	return func(s *ServerSocketManager) {
+		s.logger = l
+	}
+}
+
+// ServerWithTicketLifetime sets the time a resumption ticket can resume its session for, 10 minutes by default.
+func ServerWithTicketLifetime(d time.Duration) ServerOption {
+	return func(s *ServerSocketManager) {
+		s.ticketLifetime = d
+	}
+}
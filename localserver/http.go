@@ -0,0 +1,337 @@
+package localserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sofc-t/puzzle-client/service"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// Error codes of the JSON error bodies.
+const (
+	codeBadRequest         = "bad_request"
+	codeInternal           = "internal"
+	codeForbidden          = "forbidden"
+	codeUsernameTaken      = "username_taken"
+	codeInvalidCredentials = "invalid_credentials"
+	codeInvalidToken       = "invalid_token"
+	codeExpiredToken       = "expired_token"
+	codeNotQueued          = "not_queued"
+	codeAlreadyMatched     = "already_matched"
+)
+
+const (
+	matchEventName         = "match"          // Match events carry a service.MatchInfoResponse.
+	eventKeepAliveInterval = 15 * time.Second // Comments keep idle event streams open through proxies.
+)
+
+// routes registers the auth and match endpoints.
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+RegisterURI, s.handleRegister)
+	mux.HandleFunc("POST "+LoginURI, s.handleLogin)
+	mux.HandleFunc("POST "+RefreshURI, s.handleRefresh)
+	mux.HandleFunc("POST "+LogoutURI, s.withAuth(s.handleLogout))
+	mux.HandleFunc("POST "+MatchURI, s.withAuth(s.handleMatchRequest))
+	mux.HandleFunc("GET "+MatchURI+"/{id}", s.withAuth(s.handleMatchInfo))
+	mux.HandleFunc("GET "+MatchURI+"/{id}/events", s.withAuth(s.handleMatchEvents))
+	mux.HandleFunc("DELETE "+MatchURI+"/{id}", s.withAuth(s.handleMatchCancel))
+	return mux
+}
+
+// handleRegister creates an account.
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var req service.AuthRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Username == "" || req.Password == "" {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "username and password are required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[req.Username]; ok {
+		writeError(w, http.StatusConflict, codeUsernameTaken, "username is taken")
+		return
+	}
+	s.register(req.Username, req.Password)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// handleLogin issues an auth token. Unknown usernames are registered on the fly so offline play needs no account.
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var req service.AuthRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Username == "" || req.Password == "" {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "username and password are required")
+		return
+	}
+
+	s.mu.Lock()
+	u, ok := s.users[req.Username]
+	if !ok {
+		u = s.register(req.Username, req.Password)
+	} else if u.password != req.Password {
+		s.mu.Unlock()
+		writeError(w, http.StatusUnauthorized, codeInvalidCredentials, "invalid username or password")
+		return
+	}
+	response, err := s.issueSession(u)
+	s.mu.Unlock()
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, codeInternal, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleRefresh issues a new auth token for a refresh token. The refresh token is replaced by a new one.
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	var req service.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "refresh token is required")
+		return
+	}
+
+	s.mu.Lock()
+	u, ok := s.refreshTokens[req.RefreshToken]
+	if !ok {
+		s.mu.Unlock()
+		writeError(w, http.StatusUnauthorized, codeInvalidToken, ErrInvalidToken.Error())
+		return
+	}
+	delete(s.refreshTokens, req.RefreshToken)
+	response, err := s.issueSession(u)
+	s.mu.Unlock()
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, codeInternal, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleLogout revokes the auth token of the request and its refresh token.
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request, _ *user) {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	s.mu.Lock()
+	if sess, ok := s.tokens[token]; ok {
+		delete(s.refreshTokens, sess.refreshToken)
+		delete(s.tokens, token)
+	}
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleMatchRequest queues the player for a match.
+func (s *Server) handleMatchRequest(w http.ResponseWriter, r *http.Request, u *user) {
+	var req service.MatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "invalid match request")
+		return
+	}
+
+	if req.ID != u.id {
+		writeError(w, http.StatusForbidden, codeForbidden, "match request for another player")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.rooms[u.id]; !ok && !s.queued(u.id) {
+		s.enqueue(u.id)
+		s.startMatches()
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleMatchInfo returns the socket of the player's match, its place in the queue while it waits, or 404 when it is neither.
+func (s *Server) handleMatchInfo(w http.ResponseWriter, r *http.Request, u *user) {
+	if !ownMatch(w, r, u) {
+		return
+	}
+
+	s.mu.Lock()
+	info, matched, queued := s.matchInfo(u.id)
+	s.mu.Unlock()
+
+	switch {
+	case matched:
+		writeJSON(w, http.StatusOK, info)
+	case queued:
+		writeJSON(w, http.StatusAccepted, info)
+	default:
+		writeError(w, http.StatusNotFound, codeNotQueued, "player is not queued")
+	}
+}
+
+// handleMatchEvents streams the match info of the player as Server-Sent Events, on every change of the queue
+// until the player is matched or leaves the queue.
+func (s *Server) handleMatchEvents(w http.ResponseWriter, r *http.Request, u *user) {
+	if !ownMatch(w, r, u) {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, codeInternal, "streaming is not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		s.mu.Lock()
+		info, matched, queued := s.matchInfo(u.id)
+		changed := s.queueChanged
+		s.mu.Unlock()
+
+		if !matched && !queued {
+			return
+		}
+
+		payload, err := json.Marshal(info)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", matchEventName, payload)
+		flusher.Flush()
+		if matched {
+			return
+		}
+
+	wait:
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+				flusher.Flush()
+			case <-changed:
+				break wait
+			}
+		}
+	}
+}
+
+// handleMatchCancel removes the player from the queue. A player already matched has to play.
+func (s *Server) handleMatchCancel(w http.ResponseWriter, r *http.Request, u *user) {
+	if !ownMatch(w, r, u) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.rooms[u.id]; ok {
+		writeError(w, http.StatusConflict, codeAlreadyMatched, "player is already in a match")
+		return
+	}
+	s.dequeue(u.id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// matchInfo returns the socket of the player's match or its place in the queue. The caller must hold s.mu.
+func (s *Server) matchInfo(playerID uuid.UUID) (info service.MatchInfoResponse, matched, queued bool) {
+	if _, ok := s.rooms[playerID]; ok {
+		return service.MatchInfoResponse{
+			SocketPubKey:   s.rsa.GetPublicKey(),
+			SocketAddr:     s.UDPAddr(),
+			SocketKeyShare: s.keyShare.PublicKey().Bytes(),
+		}, true, false
+	}
+
+	position := s.queuePosition(playerID)
+	if position == 0 {
+		return service.MatchInfoResponse{}, false, false
+	}
+	return service.MatchInfoResponse{
+		QueuePosition: position,
+		EstimatedWait: s.estimatedWait(playerID).Milliseconds(),
+	}, false, true
+}
+
+// ownMatch rejects the requests about the match of another player.
+func ownMatch(w http.ResponseWriter, r *http.Request, u *user) bool {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil || id != u.id {
+		writeError(w, http.StatusForbidden, codeForbidden, "match of another player")
+		return false
+	}
+	return true
+}
+
+// withAuth resolves the bearer token of the request to its user, rejecting expired tokens.
+func (s *Server) withAuth(next func(http.ResponseWriter, *http.Request, *user)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		s.mu.Lock()
+		sess := s.tokens[token]
+		expired := sess != nil && time.Now().After(sess.expiresAt)
+		if expired {
+			delete(s.tokens, token)
+		}
+		s.mu.Unlock()
+
+		if !ok || sess == nil {
+			writeError(w, http.StatusUnauthorized, codeInvalidToken, ErrInvalidToken.Error())
+			return
+		}
+		if expired {
+			writeError(w, http.StatusUnauthorized, codeExpiredToken, ErrExpiredToken.Error())
+			return
+		}
+		next(w, r, sess.user)
+	}
+}
+
+// register creates an account. The caller must hold s.mu.
+func (s *Server) register(username, password string) *user {
+	u := &user{id: uuid.New(), username: username, password: password, rating: defaultRating}
+	s.users[username] = u
+	s.ratings[u.id] = u
+	return u
+}
+
+// queued reports whether the player waits for a match. The caller must hold s.mu.
+func (s *Server) queued(playerID uuid.UUID) bool {
+	return s.queuePosition(playerID) > 0
+}
+
+// queuePosition returns the place of the player in the queue, starting at 1, or 0 when it is not queued.
+// The caller must hold s.mu.
+func (s *Server) queuePosition(playerID uuid.UUID) int {
+	for n, id := range s.queue {
+		if id == playerID {
+			return n + 1
+		}
+	}
+	return 0
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeError answers with a JSON error body, read by the client as an i.APIError.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, i.APIError{Code: code, Message: message})
+}
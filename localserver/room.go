@@ -0,0 +1,255 @@
+package localserver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// Record types of the game protocol, they match the ones of service.GameServer.
+const (
+	moveActionType         byte = 3
+	stateRequestActionType byte = 6
+	gameStateRecordType    byte = 10
+	gameEndedRecordType    byte = 11
+)
+
+const (
+	stateBroadcastInterval = time.Second // States are repeated so a lost record does not stall a client.
+	gameEndedRepeats       = 3           // The game ended record is repeated as it is not acknowledged.
+	ratingPerOpponent      = 10          // Rating won for every opponent beaten and lost for every opponent that won.
+)
+
+type roomConfig struct {
+	players  []uuid.UUID
+	maze     i.Maze // Maze is played as is, rewards included.
+	encoder  i.GameEncoder
+	duration time.Duration
+	fogOfWar bool
+	send     func(uuid.UUID, byte, []byte)
+	onEnd    func(*room, i.GameState)
+}
+
+// room runs a single match, applying the players' actions to the authoritative state.
+type room struct {
+	encoder  i.GameEncoder
+	state    i.GameState
+	duration time.Duration
+	send     func(uuid.UUID, byte, []byte)
+	onEnd    func(*room, i.GameState)
+	ended    bool
+	stopChan chan struct{}
+	mu       sync.Mutex
+}
+
+func newRoom(cfg roomConfig) *room {
+	maze := cfg.maze
+	players := make([]i.Player, 0, len(cfg.players))
+	for n, playerID := range cfg.players {
+		pos := startPosition(cfg.encoder, n, maze.Height(), maze.Width())
+		_ = maze.RemoveReward(pos)
+
+		player := cfg.encoder.NewPlayer()
+		player.SetID(playerID)
+		player.SetPos(pos)
+		players = append(players, player)
+	}
+
+	state := cfg.encoder.NewGameState()
+	state.SetMaze(maze)
+	state.SetPlayers(players)
+	state.SetFogOfWar(cfg.fogOfWar)
+
+	return &room{
+		encoder:  cfg.encoder,
+		state:    state,
+		duration: cfg.duration,
+		send:     cfg.send,
+		onEnd:    cfg.onEnd,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// start starts the match clock and the periodic state broadcast.
+func (r *room) start() {
+	r.mu.Lock()
+	r.state.SetVersion(1)
+	r.state.SetStartedAt(time.Now().UnixNano() / int64(time.Millisecond))
+	r.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(stateBroadcastInterval)
+		defer ticker.Stop()
+		deadline := time.NewTimer(r.duration)
+		defer deadline.Stop()
+
+		for {
+			select {
+			case <-r.stopChan:
+				return
+			case <-deadline.C:
+				r.end()
+				return
+			case <-ticker.C:
+				r.broadcast(gameStateRecordType)
+			}
+		}
+	}()
+}
+
+// stop stops the match without ending it.
+func (r *room) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.ended {
+		r.ended = true
+		close(r.stopChan)
+	}
+}
+
+// handleRecord applies a record sent by a player.
+func (r *room) handleRecord(playerID uuid.UUID, t byte, payload []byte) {
+	switch t {
+	case moveActionType:
+		action, err := r.encoder.UnmarshalAction(payload)
+		if err != nil || action.GetID() != playerID {
+			return
+		}
+		if r.applyAction(action) {
+			r.broadcast(gameStateRecordType)
+		}
+		if r.rewardsLeft() == 0 {
+			r.end()
+		}
+	case stateRequestActionType:
+		r.sendState(playerID)
+	}
+}
+
+// applyAction moves the player from its authoritative position, ignoring the position the client assumed.
+// Actions with a sequence already applied are ignored. It reports whether the state changed.
+func (r *room) applyAction(action i.Action) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ended {
+		return false
+	}
+
+	player := r.player(action.GetID())
+	if player == nil || action.GetSeq() <= player.GetLastActionSeq() {
+		return false
+	}
+	player.SetLastActionSeq(action.GetSeq())
+
+	maze := r.state.RetriveMaze()
+	if move, err := maze.NewValidMove(player.RetrivePos(), action.GetDirection()); err == nil {
+		reward, err := maze.Move(move)
+		if err == nil {
+			player.SetPos(move.To())
+			player.SetReward(player.GetReward() + reward)
+		}
+	}
+
+	// The version changes even for a rejected move, so the client learns the action was processed.
+	r.state.SetVersion(r.state.GetVersion() + 1)
+	return true
+}
+
+// end finishes the match once, ranks the players and notifies them.
+func (r *room) end() {
+	r.mu.Lock()
+	if r.ended {
+		r.mu.Unlock()
+		return
+	}
+	r.ended = true
+	close(r.stopChan)
+
+	r.state.SetEndedAt(time.Now().UnixNano() / int64(time.Millisecond))
+	r.state.SetVersion(r.state.GetVersion() + 1)
+	players := r.state.RetrivePlayers()
+	for _, player := range players {
+		player.SetRatingChange(ratingChange(player, players))
+	}
+	r.mu.Unlock()
+
+	for n := 0; n < gameEndedRepeats; n++ {
+		r.broadcast(gameEndedRecordType)
+	}
+	r.onEnd(r, r.state)
+}
+
+// sendState sends the current state to a single player.
+func (r *room) sendState(playerID uuid.UUID) {
+	payload, err := r.marshalState()
+	if err != nil {
+		return
+	}
+	r.send(playerID, gameStateRecordType, payload)
+}
+
+// broadcast sends the current state to every player as a record of type t.
+func (r *room) broadcast(t byte) {
+	payload, err := r.marshalState()
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	players := r.state.RetrivePlayers()
+	r.mu.Unlock()
+
+	for _, player := range players {
+		r.send(player.GetID(), t, payload)
+	}
+}
+
+func (r *room) marshalState() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.encoder.MarshalGameState(r.state)
+}
+
+func (r *room) rewardsLeft() int32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state.RetriveMaze().GetTotalReward()
+}
+
+// player returns the player with the given ID. The caller must hold r.mu.
+func (r *room) player(playerID uuid.UUID) i.Player {
+	for _, player := range r.state.RetrivePlayers() {
+		if player.GetID() == playerID {
+			return player
+		}
+	}
+	return nil
+}
+
+// ratingChange awards ratingPerOpponent for every opponent with less rewards and takes it for every opponent with more.
+func ratingChange(player i.Player, players []i.Player) int32 {
+	change := int32(0)
+	for _, other := range players {
+		if other.GetReward() < player.GetReward() {
+			change += ratingPerOpponent
+		} else if other.GetReward() > player.GetReward() {
+			change -= ratingPerOpponent
+		}
+	}
+	return change
+}
+
+// startPosition spreads the players over the corners of the maze.
+func startPosition(encoder i.GameEncoder, n, height, width int) i.CellPosition {
+	corners := [][2]int{{0, 0}, {height - 1, width - 1}, {0, width - 1}, {height - 1, 0}}
+	corner := corners[n%len(corners)]
+
+	pos := encoder.NewCellPosition()
+	pos.SetRow(int32(corner[0]))
+	pos.SetCol(int32(corner[1]))
+	return pos
+}
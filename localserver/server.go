@@ -0,0 +1,401 @@
+// Package localserver is an in-process stand-in for the game backend.
+//
+// It serves the HTTP auth and match endpoints and the UDP game protocol with the same
+// records as the real server, so the client can be played offline and exercised by tests.
+package localserver
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sofc-t/puzzle-client/infrastruture/crypto"
+	gamepb "github.com/sofc-t/puzzle-client/infrastruture/pb_encoder/game"
+	udppb "github.com/sofc-t/puzzle-client/infrastruture/pb_encoder/udp"
+	"github.com/sofc-t/puzzle-client/infrastruture/udp"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// Paths of the HTTP endpoints, to be used as the client's login, register, match, refresh and logout URIs.
+const (
+	LoginURI    = "/auth/login"
+	RegisterURI = "/auth/register"
+	MatchURI    = "/match"
+	RefreshURI  = "/auth/refresh"
+	LogoutURI   = "/auth/logout"
+)
+
+const (
+	defaultPlayersPerMatch = 1
+	defaultMatchDuration   = 2 * time.Minute
+	defaultMazeHeight      = 10
+	defaultMazeWidth       = 10
+	defaultRating          = 1000
+	defaultTokenTTL        = 15 * time.Minute
+
+	rsaKeySize = 2048
+
+	waitSmoothing = 4 // The average wait moves by a quarter of the difference with every new wait.
+
+	rewardOne      int32   = 1
+	rewardTwo      int32   = 5
+	rewardTypeProb float32 = 0.85
+)
+
+var (
+	ErrInvalidToken    = errors.New("invalid auth token")
+	ErrExpiredToken    = errors.New("auth token expired")
+	ErrPlayerNotInGame = errors.New("player is not in a game")
+)
+
+// Config defines the configuration settings of the local server.
+type Config struct {
+	HTTPAddr        string               // HTTPAddr is the TCP address of the HTTP endpoints, a random local port when empty.
+	UDPAddr         string               // UDPAddr is the UDP address of the game socket, a random local port when empty.
+	PlayersPerMatch int                  // PlayersPerMatch is the number of queued players a match starts with, defaults to 1.
+	MatchDuration   time.Duration        // MatchDuration ends a match that still has rewards, defaults to 2 minutes.
+	MazeHeight      int                  // MazeHeight is the number of rows of the maze, defaults to 10.
+	MazeWidth       int                  // MazeWidth is the number of columns of the maze, defaults to 10.
+	MazeAlgorithm   gamepb.MazeAlgorithm // MazeAlgorithm defaults to the recursive backtracker.
+	MazeBraid       float64              // MazeBraid is the probability of a dead end to be opened into a loop.
+	Seed            int64                // Seed makes the generated mazes reproducible, random when 0.
+	FogOfWar        bool                 // FogOfWar asks the clients to hide the cells their player did not explore.
+	TokenTTL        time.Duration        // TokenTTL is the lifetime of the issued auth tokens, defaults to 15 minutes.
+	Logger          *log.Logger          // Logger is optional, nothing is logged without it.
+}
+
+// user is a registered account.
+type user struct {
+	id       uuid.UUID
+	username string
+	password string
+	rating   int
+}
+
+// Server is the local stand-in for the game backend.
+type Server struct {
+	cfg           Config
+	logger        *log.Logger
+	encoder       i.GameEncoder
+	generator     *gamepb.MazeGenerator
+	rsa           *crypto.RSA
+	keyShare      *ecdh.PrivateKey
+	socket        *udp.ServerSocketManager
+	listener      net.Listener
+	httpServer    *http.Server
+	users         map[string]*user        // Users are keyed by username.
+	tokens        map[string]*session     // Tokens map the issued auth tokens to their session.
+	refreshTokens map[string]*user        // RefreshTokens map the refresh tokens to their user.
+	tokenSecret   []byte                  // TokenSecret signs the issued auth tokens.
+	queue         []uuid.UUID             // Queue holds the players waiting for a match in arrival order.
+	queuedAt      map[uuid.UUID]time.Time // QueuedAt holds when the queued players joined the queue.
+	avgWait       time.Duration           // AvgWait is the moving average of the time players waited for a match.
+	queueChanged  chan struct{}           // QueueChanged is closed and replaced whenever a player joins or leaves the queue.
+	rooms         map[uuid.UUID]*room     // Rooms map the players to the match they play in.
+	ratings       map[uuid.UUID]*user     // Ratings map the player IDs to their account.
+	mu            sync.Mutex
+}
+
+// New creates a local server listening on the configured addresses. Start serves the requests.
+func New(cfg Config) (*Server, error) {
+	if cfg.PlayersPerMatch <= 0 {
+		cfg.PlayersPerMatch = defaultPlayersPerMatch
+	}
+	if cfg.MatchDuration <= 0 {
+		cfg.MatchDuration = defaultMatchDuration
+	}
+	if cfg.MazeHeight <= 0 {
+		cfg.MazeHeight = defaultMazeHeight
+	}
+	if cfg.MazeWidth <= 0 {
+		cfg.MazeWidth = defaultMazeWidth
+	}
+	if cfg.TokenTTL <= 0 {
+		cfg.TokenTTL = defaultTokenTTL
+	}
+	if cfg.HTTPAddr == "" {
+		cfg.HTTPAddr = "127.0.0.1:0"
+	}
+	if cfg.UDPAddr == "" {
+		cfg.UDPAddr = "127.0.0.1:0"
+	}
+
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+
+	privKey, err := rsa.GenerateKey(rand.Reader, rsaKeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	keyShare, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenSecret := make([]byte, tokenSecretSize)
+	if _, err := rand.Read(tokenSecret); err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		cfg:     cfg,
+		logger:  logger,
+		encoder: &gamepb.Protobuf{},
+		generator: gamepb.NewMazeGenerator(cfg.Seed,
+			gamepb.MazeWithAlgorithm(cfg.MazeAlgorithm), gamepb.MazeWithBraid(cfg.MazeBraid)),
+		rsa:           crypto.NewRSA(privKey),
+		keyShare:      keyShare,
+		users:         make(map[string]*user),
+		tokens:        make(map[string]*session),
+		refreshTokens: make(map[string]*user),
+		tokenSecret:   tokenSecret,
+		queuedAt:      make(map[uuid.UUID]time.Time),
+		queueChanged:  make(chan struct{}),
+		rooms:         make(map[uuid.UUID]*room),
+		ratings:       make(map[uuid.UUID]*user),
+	}
+
+	udpAddr, err := net.ResolveUDPAddr("udp", cfg.UDPAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	s.socket, err = udp.NewServerSocketManager(udp.ServerConfig{
+		ListenAddr:      udpAddr,
+		Encoder:         &udppb.Protobuf{},
+		AsymmCrypto:     s.rsa,
+		SymmCrypto:      crypto.NewAESCBC(),
+		CipherSuites:    []i.AEAD{crypto.NewAESGCM(), crypto.NewChaCha20Poly1305()},
+		KeyExchange:     s.deriveKey,
+		Authenticate:    s.authenticate,
+		OnClientConnect: s.handleClientConnect,
+		OnClientRecord:  s.handleClientRecord,
+	}, udp.ServerWithLogger(logger))
+	if err != nil {
+		return nil, err
+	}
+
+	s.listener, err = net.Listen("tcp", cfg.HTTPAddr)
+	if err != nil {
+		s.socket.Close()
+		return nil, err
+	}
+	s.httpServer = &http.Server{Handler: s.routes()}
+
+	return s, nil
+}
+
+// Start serves the HTTP and UDP endpoints in the background.
+func (s *Server) Start() {
+	go func() {
+		if err := s.httpServer.Serve(s.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Printf("http server stopped: %s", err)
+		}
+	}()
+
+	go func() {
+		if err := s.socket.Serve(); err != nil {
+			s.logger.Printf("udp server stopped: %s", err)
+		}
+	}()
+}
+
+// Close stops the running matches and both endpoints.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	for _, r := range s.rooms {
+		r.stop()
+	}
+	s.mu.Unlock()
+
+	err := s.httpServer.Close()
+	if sockErr := s.socket.Close(); err == nil {
+		err = sockErr
+	}
+	return err
+}
+
+// URL returns the base URL of the HTTP endpoints.
+func (s *Server) URL() string {
+	return "http://" + s.listener.Addr().String()
+}
+
+// UDPAddr returns the address of the game socket.
+func (s *Server) UDPAddr() string {
+	return s.socket.Addr().String()
+}
+
+// deriveKey derives the session key of a client from its X25519 key share.
+func (s *Server) deriveKey(clientShare []byte) ([]byte, error) {
+	peer, err := ecdh.X25519().NewPublicKey(clientShare)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.DeriveX25519SessionKey(s.keyShare, peer, clientShare)
+}
+
+// authenticate maps the auth token of a client hello, the player ID, to a player in a running match.
+func (s *Server) authenticate(token []byte) (string, error) {
+	playerID, err := uuid.FromBytes(token)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.rooms[playerID]; !ok {
+		return "", ErrPlayerNotInGame
+	}
+	return playerID.String(), nil
+}
+
+// handleClientConnect sends the current state of the match to a player that completed the handshake.
+func (s *Server) handleClientConnect(clientID string) {
+	if r := s.roomOf(clientID); r != nil {
+		r.sendState(uuid.MustParse(clientID))
+	}
+}
+
+// handleClientRecord hands a game record of a player to its match.
+func (s *Server) handleClientRecord(clientID string, t byte, payload []byte) {
+	if r := s.roomOf(clientID); r != nil {
+		r.handleRecord(uuid.MustParse(clientID), t, payload)
+	}
+}
+
+func (s *Server) roomOf(clientID string) *room {
+	playerID, err := uuid.Parse(clientID)
+	if err != nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rooms[playerID]
+}
+
+// enqueue adds the player at the end of the queue. The caller must hold s.mu.
+func (s *Server) enqueue(playerID uuid.UUID) {
+	s.queue = append(s.queue, playerID)
+	s.queuedAt[playerID] = time.Now()
+	s.notifyQueueChange()
+}
+
+// dequeue removes the player from the queue. The caller must hold s.mu.
+func (s *Server) dequeue(playerID uuid.UUID) {
+	if n := s.queuePosition(playerID) - 1; n >= 0 {
+		s.queue = append(s.queue[:n:n], s.queue[n+1:]...)
+		s.notifyQueueChange()
+	}
+	delete(s.queuedAt, playerID)
+}
+
+// notifyQueueChange wakes up the match event streams. The caller must hold s.mu.
+func (s *Server) notifyQueueChange() {
+	close(s.queueChanged)
+	s.queueChanged = make(chan struct{})
+}
+
+// estimatedWait estimates the time left before the queued player is matched from the average wait of the previous
+// matches, 0 when unknown. The caller must hold s.mu.
+func (s *Server) estimatedWait(playerID uuid.UUID) time.Duration {
+	position := s.queuePosition(playerID)
+	if position == 0 || s.avgWait == 0 {
+		return 0
+	}
+
+	groups := (position-1)/s.cfg.PlayersPerMatch + 1
+	return max(time.Duration(groups)*s.avgWait-time.Since(s.queuedAt[playerID]), 0)
+}
+
+// startMatches starts a match for every full group of queued players. The caller must hold s.mu.
+func (s *Server) startMatches() {
+	for len(s.queue) >= s.cfg.PlayersPerMatch {
+		players := s.queue[:s.cfg.PlayersPerMatch]
+		s.queue = append([]uuid.UUID{}, s.queue[s.cfg.PlayersPerMatch:]...)
+		for _, playerID := range players {
+			wait := time.Since(s.queuedAt[playerID])
+			s.avgWait += (wait - s.avgWait) / waitSmoothing
+			delete(s.queuedAt, playerID)
+		}
+		s.notifyQueueChange()
+
+		maze, err := s.newMaze()
+		if err != nil {
+			s.logger.Printf("error while generating maze: %s", err)
+			return
+		}
+
+		r := newRoom(roomConfig{
+			players:  players,
+			maze:     maze,
+			encoder:  s.encoder,
+			duration: s.cfg.MatchDuration,
+			fogOfWar: s.cfg.FogOfWar,
+			send:     s.send,
+			onEnd:    s.handleMatchEnd,
+		})
+
+		for _, playerID := range players {
+			s.rooms[playerID] = r
+		}
+		r.start()
+		s.logger.Printf("match started with %d players", len(players))
+	}
+}
+
+// newMaze generates the maze of a new match with its rewards. The caller must hold s.mu.
+func (s *Server) newMaze() (i.Maze, error) {
+	maze, err := s.generator.Generate(s.cfg.MazeHeight, s.cfg.MazeWidth)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.generator.PopulateReward(maze, struct {
+		RewardOne      int32
+		RewardTwo      int32
+		RewardTypeProb float32
+	}{rewardOne, rewardTwo, rewardTypeProb})
+	if err != nil {
+		return nil, err
+	}
+	return maze, nil
+}
+
+// send delivers a game record to a connected player.
+func (s *Server) send(playerID uuid.UUID, t byte, payload []byte) {
+	if err := s.socket.SendToClient(playerID.String(), t, payload); err != nil && !errors.Is(err, udp.ErrClientNotFound) {
+		s.logger.Printf("error while sending record to %s: %s", playerID, err)
+	}
+}
+
+// handleMatchEnd applies the rating changes and releases the players so they can queue again.
+func (s *Server) handleMatchEnd(r *room, gs i.GameState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, player := range gs.RetrivePlayers() {
+		if u, ok := s.ratings[player.GetID()]; ok {
+			u.rating += int(player.GetRatingChange())
+		}
+		if s.rooms[player.GetID()] == r {
+			delete(s.rooms, player.GetID())
+		}
+	}
+	s.logger.Printf("match ended at version %d", gs.GetVersion())
+}
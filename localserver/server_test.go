@@ -0,0 +1,325 @@
+package localserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sofc-t/puzzle-client/dmn"
+	"github.com/sofc-t/puzzle-client/infrastruture/crypto"
+	"github.com/sofc-t/puzzle-client/infrastruture/http"
+	gamepb "github.com/sofc-t/puzzle-client/infrastruture/pb_encoder/game"
+	udppb "github.com/sofc-t/puzzle-client/infrastruture/pb_encoder/udp"
+	"github.com/sofc-t/puzzle-client/infrastruture/store"
+	"github.com/sofc-t/puzzle-client/infrastruture/udp"
+	"github.com/sofc-t/puzzle-client/service"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+func newTestServer(t *testing.T, cfg Config) *Server {
+	server, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Start()
+	t.Cleanup(func() { _ = server.Close() })
+	return server
+}
+
+// gameServerFactory connects a player to the local server like the client does, with the given key management
+// and cipher suites. The legacy AES-CBC is used when no suite is offered.
+func gameServerFactory(newKeys func(*dmn.Match) (i.KeyManager, error), cipherSuites ...i.AEAD) service.GameServerFactory {
+	return func(match *dmn.Match, player *dmn.Player) (i.GameServer, error) {
+		serverAddr, err := net.ResolveUDPAddr("udp", match.SocketAddr)
+		if err != nil {
+			return nil, err
+		}
+
+		keys, err := newKeys(match)
+		if err != nil {
+			return nil, err
+		}
+
+		conn, err := udp.NewClientServerManager(udp.ClientConfig{
+			ServerAddr:         serverAddr,
+			Encoder:            &udppb.Protobuf{},
+			AsymmCrypto:        crypto.NewRSA(&rsa.PrivateKey{}),
+			ServerAsymmPubKey:  match.SocketPubKey,
+			SymmCrypto:         crypto.NewAESCBC(),
+			CipherSuites:       cipherSuites,
+			Keys:               keys,
+			OnConnectionSucces: func() {},
+		}, udp.ClientWithPingInterval(50*time.Millisecond))
+		if err != nil {
+			return nil, err
+		}
+
+		return service.NewGameServer(&service.GameServerConfig{
+			ServerConnection: conn,
+			Encoder:          &gamepb.Protobuf{},
+			PlayerID:         player.ID,
+		})
+	}
+}
+
+// TestLocalServer contains the integration tests of the client against the local server.
+func TestLocalServer(t *testing.T) {
+	t.Run("LocalServer_Auth", testLocalServer_Auth)
+	t.Run("LocalServer_TokenRefresh", testLocalServer_TokenRefresh)
+	t.Run("LocalServer_RememberAndLogout", testLocalServer_RememberAndLogout)
+	t.Run("LocalServer_CancelMatch", testLocalServer_CancelMatch)
+	t.Run("LocalServer_MatchEvents", testLocalServer_MatchEvents)
+	t.Run("LocalServer_BotPlaysX25519", func(t *testing.T) {
+		testLocalServer_BotPlays(t, gameServerFactory(func(m *dmn.Match) (i.KeyManager, error) {
+			return crypto.NewX25519Keys(m.SocketKeyShare)
+		}, crypto.NewChaCha20Poly1305()))
+	})
+	t.Run("LocalServer_BotPlaysEphemeralKeys", func(t *testing.T) {
+		testLocalServer_BotPlays(t, gameServerFactory(ephemeralKeys, crypto.NewChaCha20Poly1305()))
+	})
+	t.Run("LocalServer_BotPlaysLegacyCipher", func(t *testing.T) {
+		testLocalServer_BotPlays(t, gameServerFactory(ephemeralKeys))
+	})
+}
+
+// testLocalServer_Auth tests registering and logging in through service.Auth.
+func testLocalServer_Auth(t *testing.T) {
+	server := newTestServer(t, Config{})
+	auth, _ := service.NewAuth(http.NewHttpClient(server.URL()), LoginURI, RegisterURI)
+
+	if err := auth.Register("gopher", "dance"); err != nil {
+		t.Fatalf("Expected registration, got error: %s", err)
+	}
+
+	var apiErr *i.APIError
+	if err := auth.Register("gopher", "dance"); !errors.As(err, &apiErr) || apiErr.Code != codeUsernameTaken {
+		t.Errorf("Expected a taken username to be rejected with its code, got: %v", err)
+	}
+
+	player, token, err := auth.Login("gopher", "dance")
+	if err != nil {
+		t.Fatalf("Expected login, got error: %s", err)
+	}
+
+	if player.Username != "gopher" || player.Rating != defaultRating || token == "" {
+		t.Errorf("Expected gopher with the default rating and a token, got: %+v", player)
+	}
+
+	if _, _, err := auth.Login("gopher", "sleep"); !errors.Is(err, i.ErrUnauthorized) {
+		t.Errorf("Expected a wrong password to be rejected with ErrUnauthorized, got: %v", err)
+	}
+}
+
+// testLocalServer_TokenRefresh tests that an expiring token is refreshed before it is rejected by the server.
+func testLocalServer_TokenRefresh(t *testing.T) {
+	server := newTestServer(t, Config{TokenTTL: time.Second})
+	httpClient := http.NewHttpClient(server.URL())
+	auth, _ := service.NewAuth(httpClient, LoginURI, RegisterURI, service.AuthWithRefreshUri(RefreshURI))
+
+	player, loginToken, err := auth.Login("gopher", "dance")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Withdrawing from the queue succeeds whether the player is queued or not, only the token is checked.
+	if _, err := httpClient.Delete(context.Background(), MatchURI+"/"+player.ID.String(), loginToken); err != nil {
+		t.Fatalf("Expected the fresh token to be accepted, got error: %s", err)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	if _, err := httpClient.Delete(context.Background(), MatchURI+"/"+player.ID.String(), loginToken); err == nil {
+		t.Fatalf("Expected the expired token to be rejected")
+	}
+
+	token, err := auth.Token()
+	if err != nil {
+		t.Fatalf("Expected a refreshed token, got error: %s", err)
+	}
+	if token == loginToken {
+		t.Errorf("Expected a new token")
+	}
+
+	payload, _ := json.Marshal(service.MatchRequest{ID: player.ID})
+	if _, err := httpClient.Post(context.Background(), MatchURI, bytes.NewReader(payload), token); err != nil {
+		t.Errorf("Expected the refreshed token to be accepted, got error: %s", err)
+	}
+}
+
+// testLocalServer_RememberAndLogout tests that a remembered session is resumed by the next launch until logout.
+func testLocalServer_RememberAndLogout(t *testing.T) {
+	server := newTestServer(t, Config{})
+	httpClient := http.NewHttpClient(server.URL())
+	credentials := store.NewFileCredentialStore(t.TempDir(), t.TempDir())
+	newAuth := func() i.AuthServer {
+		auth, _ := service.NewAuth(httpClient, LoginURI, RegisterURI, service.AuthWithRefreshUri(RefreshURI),
+			service.AuthWithLogoutUri(LogoutURI), service.AuthWithStore(credentials))
+		return auth
+	}
+
+	auth := newAuth()
+	player, token, err := auth.Login("gopher", "dance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := auth.Remember(); err != nil {
+		t.Fatalf("Expected the session to be remembered, got error: %s", err)
+	}
+
+	relaunched := newAuth()
+	resumed, resumedToken, err := relaunched.Resume()
+	if err != nil {
+		t.Fatalf("Expected the session to be resumed, got error: %s", err)
+	}
+	if resumed.ID != player.ID || resumedToken != token {
+		t.Errorf("Expected the session of %s, got: %+v", player.Username, resumed)
+	}
+
+	if err := relaunched.Logout(); err != nil {
+		t.Fatalf("Expected the logout to succeed, got error: %s", err)
+	}
+	if _, err := httpClient.Delete(context.Background(), MatchURI+"/"+player.ID.String(), token); err == nil {
+		t.Errorf("Expected the token to be revoked")
+	}
+	if _, err := relaunched.Token(); err != service.ErrNotLoggedIn {
+		t.Errorf("Expected ErrNotLoggedIn after logout, got: %v", err)
+	}
+	if _, _, err := newAuth().Resume(); err == nil {
+		t.Errorf("Expected no session to resume after logout")
+	}
+}
+
+// testLocalServer_CancelMatch tests that a player cancelling the search while queued leaves the queue.
+func testLocalServer_CancelMatch(t *testing.T) {
+	server := newTestServer(t, Config{PlayersPerMatch: 2})
+
+	httpClient := http.NewHttpClient(server.URL())
+	auth, _ := service.NewAuth(httpClient, LoginURI, RegisterURI)
+	matchMaker, _ := service.NewMatchMaking(service.MatchMakingConfig{
+		HttpClient:   httpClient,
+		MatchUri:     MatchURI,
+		PollInterval: 10 * time.Millisecond,
+	})
+
+	player, token, err := auth.Login("alone", "wait")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var position int
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err = matchMaker.Match(ctx, player.ID, token, func(p dmn.MatchProgress) {
+		position = p.QueuePosition
+		cancel()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got: %v", err)
+	}
+
+	if position != 1 {
+		t.Errorf("Expected the player first in the queue, got position: %d", position)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.queued(player.ID) {
+		t.Errorf("Expected the player to leave the queue")
+	}
+}
+
+// testLocalServer_MatchEvents tests that a queued player is pushed its place in the queue, then its match.
+func testLocalServer_MatchEvents(t *testing.T) {
+	server := newTestServer(t, Config{PlayersPerMatch: 2})
+
+	httpClient := http.NewHttpClient(server.URL())
+	auth, _ := service.NewAuth(httpClient, LoginURI, RegisterURI)
+	matchMaker, _ := service.NewMatchMaking(service.MatchMakingConfig{HttpClient: httpClient, MatchUri: MatchURI})
+
+	first, firstToken, _ := auth.Login("first", "in")
+	second, secondToken, _ := auth.Login("second", "in")
+
+	payload, _ := json.Marshal(service.MatchRequest{ID: first.ID})
+	if _, err := httpClient.Post(context.Background(), MatchURI, bytes.NewReader(payload), firstToken); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events, err := httpClient.Subscribe(ctx, MatchURI+"/"+first.ID.String()+"/events", firstToken)
+	if err != nil {
+		t.Fatalf("Expected a match event stream, got error: %s", err)
+	}
+
+	var queued service.MatchInfoResponse
+	if event := <-events; json.Unmarshal([]byte(event.Data), &queued) != nil || queued.QueuePosition != 1 {
+		t.Fatalf("Expected the first place in the queue to be pushed, got: %+v", event)
+	}
+
+	if _, err := matchMaker.Match(ctx, second.ID, secondToken, nil); err != nil {
+		t.Fatalf("Expected the second player to be matched, got error: %s", err)
+	}
+
+	var matched service.MatchInfoResponse
+	if event := <-events; json.Unmarshal([]byte(event.Data), &matched) != nil || matched.SocketAddr != server.UDPAddr() {
+		t.Errorf("Expected the match to be pushed, got: %+v", event)
+	}
+}
+
+// ephemeralKeys sends a random key in the client hello, as the client does when the server offers no key share.
+func ephemeralKeys(*dmn.Match) (i.KeyManager, error) {
+	return crypto.NewEphemeralKeys(), nil
+}
+
+// testLocalServer_BotPlays tests a whole match: login, matchmaking, UDP handshake, moves and game end.
+func testLocalServer_BotPlays(t *testing.T, newGameServer service.GameServerFactory) {
+	server := newTestServer(t, Config{MazeHeight: 3, MazeWidth: 3, MatchDuration: 10 * time.Second})
+	httpClient := http.NewHttpClient(server.URL())
+	auth, _ := service.NewAuth(httpClient, LoginURI, RegisterURI)
+	matchMaker, _ := service.NewMatchMaking(service.MatchMakingConfig{HttpClient: httpClient, MatchUri: MatchURI})
+
+	bot, err := service.NewBot(service.BotConfig{
+		Auth:          auth,
+		MatchMaker:    matchMaker,
+		NewGameServer: newGameServer,
+		Strategy:      service.NewShortestPath(),
+		Username:      "bot",
+		Password:      "beep",
+		MoveInterval:  10 * time.Millisecond,
+		Logger:        log.New(io.Discard, "", 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := make(chan error, 1)
+	var final i.GameState
+	go func() {
+		var err error
+		final, err = bot.Play(context.Background())
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("Expected the match to be played, got error: %s", err)
+		}
+	case <-time.After(8 * time.Second):
+		t.Fatalf("Expected the bot to collect every reward before the timeout")
+	}
+
+	if final.RetriveMaze().GetTotalReward() != 0 || final.GetEndedAt() == 0 {
+		t.Errorf("Expected every reward collected and the end time set, got %d left", final.RetriveMaze().GetTotalReward())
+	}
+
+	if reward := final.RetrivePlayers()[0].GetReward(); reward < 8 {
+		t.Errorf("Expected the bot to collect the 8 rewarded cells, got a reward of: %d", reward)
+	}
+}
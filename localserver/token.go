@@ -0,0 +1,71 @@
+package localserver
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/sofc-t/puzzle-client/service"
+)
+
+const (
+	refreshTokenSize = 32
+	tokenSecretSize  = 32
+)
+
+// jwtHeader is the encoded header of the issued auth tokens, signed with HMAC-SHA256.
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// session is an issued auth token.
+type session struct {
+	user         *user
+	refreshToken string // RefreshToken is revoked with the auth token on logout.
+	expiresAt    time.Time
+}
+
+// issueSession issues an auth token and the refresh token renewing it. The caller must hold s.mu.
+func (s *Server) issueSession(u *user) (service.AuthResponse, error) {
+	expiresAt := time.Now().Add(s.cfg.TokenTTL)
+	token, err := s.newJWT(u, expiresAt)
+	if err != nil {
+		return service.AuthResponse{}, err
+	}
+
+	refreshToken, err := randomToken(refreshTokenSize)
+	if err != nil {
+		return service.AuthResponse{}, err
+	}
+
+	s.tokens[token] = &session{user: u, refreshToken: refreshToken, expiresAt: expiresAt}
+	s.refreshTokens[refreshToken] = u
+	return service.AuthResponse{ID: u.id, Username: u.username, Rating: u.rating, Token: token, RefreshToken: refreshToken}, nil
+}
+
+// newJWT returns a JWT naming the player and its expiry. The tokens are also looked up on every request,
+// the signature only makes them tamper-evident to clients decoding them.
+func (s *Server) newJWT(u *user, expiresAt time.Time) (string, error) {
+	claims, err := json.Marshal(struct {
+		Sub string `json:"sub"`
+		Exp int64  `json:"exp"`
+	}{u.id.String(), expiresAt.Unix()})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(claims)
+	mac := hmac.New(sha256.New, s.tokenSecret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func randomToken(size int) (string, error) {
+	token := make([]byte, size)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
@@ -1,11 +1,19 @@
 package main
 
 import (
+	"context"
 	"crypto/rsa"
+	"flag"
 	"fmt"
+	"log"
 	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/rivo/tview"
 	"github.com/sofc-t/puzzle-client/config"
 	"github.com/sofc-t/puzzle-client/controller"
@@ -14,88 +22,506 @@ import (
 	"github.com/sofc-t/puzzle-client/infrastruture/http"
 	gamepb "github.com/sofc-t/puzzle-client/infrastruture/pb_encoder/game"
 	udppb "github.com/sofc-t/puzzle-client/infrastruture/pb_encoder/udp"
+	"github.com/sofc-t/puzzle-client/infrastruture/store"
 	"github.com/sofc-t/puzzle-client/infrastruture/udp"
+	"github.com/sofc-t/puzzle-client/localserver"
 	"github.com/sofc-t/puzzle-client/service"
+	"github.com/sofc-t/puzzle-client/service/i"
 )
 
 var player *dmn.Player
+var authService i.AuthServer
 var app *tview.Application
+var recordDir string
+var keymap *controller.Keymap
+var theme *controller.Theme
+
+// serverClock follows the backend's clock from the pongs of the matches. The match requests, the states
+// and the recorded frames share its timebase.
+var serverClock = service.NewClockSync()
+
+// loader loads the configuration and saves the settings changed in the app.
+var loader = config.Loader{EnvFile: ".env"}
+
+// userAgent identifies the client in the requests to the backend.
+const userAgent = "puzzle-me-client"
 
 func main() {
-	httpClient := http.NewHttpClient(config.Envs.ServerAddr)
-	authService, err := service.NewAuth(httpClient, config.Envs.LoginUri, config.Envs.RegisterUri)
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		if err := serve(os.Args[2:]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	replayFile := flag.String("replay", "", "play back a recorded match file instead of connecting to the server")
+	flag.StringVar(&recordDir, "record", "", "directory where every match is recorded")
+	botStrategy := flag.String("bot", "", "play headless with a strategy: random, greedy or bfs")
+	botUsername := flag.String("username", "", "username of the bot")
+	botPassword := flag.String("password", "", "password of the bot")
+	botGames := flag.Int("games", 1, "number of matches played by the bot")
+	offline := flag.Bool("offline", false, "play a single-player match locally against a timer")
+	offlineCfg := offlineConfig{}
+	flag.StringVar(&offlineCfg.algorithm, "algorithm", "backtracker", "maze algorithm of the offline mode: backtracker, prim or kruskal")
+	flag.Int64Var(&offlineCfg.seed, "seed", 0, "seed of the offline maze, random when 0")
+	flag.IntVar(&offlineCfg.size, "size", 12, "number of rows and columns of the offline maze")
+	flag.Float64Var(&offlineCfg.braid, "braid", 0, "probability between 0 and 1 of turning a dead end of the offline maze into a loop")
+	flag.DurationVar(&offlineCfg.duration, "time", 90*time.Second, "time limit of the offline mode")
+	flag.BoolVar(&offlineCfg.fogOfWar, "fog", false, "hide the cells of the offline maze that were not explored")
+	loader.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	cfg, err := loader.Merge()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	keymap, err = controller.NewKeymap(cfg.Keys.Preset, cfg.Keys.Bindings)
+	if err != nil {
+		fmt.Println("keys:", err)
+		os.Exit(1)
+	}
+	theme, err = controller.ThemeByName(cfg.Theme)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	app = tview.NewApplication()
+	if *replayFile != "" {
+		if err := startReplay(*replayFile); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *offline {
+		if err := playOffline(offlineCfg); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *botStrategy != "" {
+		if err := runBot(cfg, *botStrategy, *botUsername, *botPassword, *botGames); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	httpClient, err := newHttpClient(cfg, nil)
 	if err != nil {
 		panic(err)
 	}
-	matchService, _ := service.NewMatchMaking(service.MatchMakingConfig{
+	authOptions := []service.AuthOption{
+		service.AuthWithRefreshUri(cfg.RefreshUri),
+		service.AuthWithLogoutUri(cfg.LogoutUri),
+	}
+	dir, dirErr := store.DefaultDir()
+	keyDir, keyDirErr := store.DefaultKeyDir()
+	if dirErr == nil && keyDirErr == nil {
+		authOptions = append(authOptions, service.AuthWithStore(store.NewFileCredentialStore(dir, keyDir)))
+	}
+
+	authService, err = service.NewAuth(httpClient, cfg.LoginUri, cfg.RegisterUri, authOptions...)
+	if err != nil {
+		panic(err)
+	}
+	matchService, err := service.NewMatchMaking(service.MatchMakingConfig{
 		HttpClient: httpClient,
-		MatchUri:   config.Envs.MatchUri,
+		MatchUri:   cfg.MatchUri,
+		Clock:      serverClock,
 	})
-
-	app = tview.NewApplication()
-	matchPage, err := controller.NewMatchingRoomPage(matchService, startGame)
 	if err != nil {
 		panic(err)
 	}
 
-	authPage, err := controller.NewAuthPage(authService, func(p *dmn.Player, token string) {
+	nav := controller.NewNavigator(app)
+	var authPage *controller.AuthPage
+	authPage, err = controller.NewAuthPage(authService, func(p *dmn.Player, token string) {
 		player = p
-		err := matchPage.Start(app, player.ID, token)
+		var matchPage *controller.MatchingRoomPage
+		matchPage, err := controller.NewMatchingRoomPage(matchService, authService, player.ID,
+			func(match *dmn.Match) error { return startGame(nav, matchPage, match) },
+			func() { nav.Reset(authPage) },
+		)
 		if err != nil {
 			panic(err)
 		}
+		nav.Reset(matchPage)
 	})
 	if err != nil {
-		fmt.Println(err)
 		panic(err)
+	}
 
+	// A remembered session goes straight to the matching room.
+	nav.Push(authPage)
+	authPage.Resume()
+	if err := nav.Run(); err != nil {
+		panic(err)
 	}
+}
 
-	err = authPage.Start(app)
+// startGame joins the match and shows the game page over the matching room, then the results in place of the game.
+func startGame(nav *controller.Navigator, matchPage *controller.MatchingRoomPage, match *dmn.Match) error {
+	gameService, err := newGameServer(match, player)
 	if err != nil {
-		fmt.Println(err)
-		panic(err)
+		return err
+	}
+
+	gamePage, err := controller.NewGame(gameService, player.ID, player.ID[:], func(gs i.GameState) {
+		resultsPage, err := controller.NewResultsPage(player.ID, gs,
+			func() { matchPage.Requeue(); nav.Pop() },
+			func() { nav.Pop() },
+		)
+		if err != nil {
+			panic(err)
+		}
+		nav.Replace(resultsPage)
+	}, gameOptions()...)
+	if err != nil {
+		return err
+	}
+
+	nav.Push(gamePage)
+	return nil
+}
+
+// gameOptions returns the options of the game pages: the configured keys and the current theme, saved when switched.
+func gameOptions() []controller.GameOption {
+	return []controller.GameOption{
+		controller.GameWithKeymap(keymap),
+		controller.GameWithTheme(theme, func(t *controller.Theme) {
+			theme = t
+			// The theme still applies to this session when it cannot be saved.
+			_ = loader.SaveSetting("theme", t.Name)
+		}),
+	}
+}
+
+// newHttpClient returns the client of the backend endpoints, logging the requests to logger when not nil.
+func newHttpClient(cfg config.Config, logger *log.Logger) (*http.HttpClient, error) {
+	middlewares := []http.Middleware{http.UserAgentMiddleware(userAgent), http.RequestIDMiddleware()}
+	if logger != nil {
+		middlewares = append(middlewares, http.LoggingMiddleware(logger))
 	}
+	options := []http.ClientOption{http.ClientWithMiddleware(middlewares...)}
+
+	if tlsCfg := cfg.TLS; tlsCfg.Enabled() {
+		tlsConfig, err := http.NewTLSConfig(http.TLSOptions{
+			CAFile:         tlsCfg.CAFile,
+			Pins:           tlsCfg.Pins,
+			ClientCertFile: tlsCfg.ClientCertFile,
+			ClientKeyFile:  tlsCfg.ClientKeyFile,
+			MinVersion:     tlsCfg.MinVersion,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("TLS options: %w", err)
+		}
+		options = append(options, http.ClientWithTLS(tlsConfig))
+	}
+	return http.NewHttpClient(cfg.ServerAddr, options...), nil
 }
 
-func startGame(key []byte, addr string) {
-	serverAddr, err := net.ResolveUDPAddr("udp", addr)
+// newGameServer connects p to the game server of the match over UDP.
+func newGameServer(match *dmn.Match, p *dmn.Player) (i.GameServer, error) {
+	serverAddr, err := net.ResolveUDPAddr("udp", match.SocketAddr)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	keys, err := sessionKeys(match)
+	if err != nil {
+		return nil, err
 	}
 
-	aesKey := []byte{113, 110, 25, 53, 11, 53, 68, 33, 17, 36, 22, 7, 125, 11, 35, 16, 83, 61, 59, 49, 31, 22, 69, 17, 24, 125, 11, 35, 16, 83, 61, 59}
 	updClient, err := udp.NewClientServerManager(
 		udp.ClientConfig{
 			ServerAddr:         serverAddr,
 			Encoder:            &udppb.Protobuf{},
 			AsymmCrypto:        crypto.NewRSA(&rsa.PrivateKey{}),
-			ServerAsymmPubKey:  key,
-			SymmCrypto:         crypto.NewAESCBC(),
-			ClientSymmKey:      aesKey,
+			ServerAsymmPubKey:  match.SocketPubKey,
+			SymmCrypto:         crypto.NewAESCBC(), // Only used with the servers negotiating none of the cipher suites.
+			CipherSuites:       []i.AEAD{crypto.NewAESGCM(), crypto.NewChaCha20Poly1305()},
+			Keys:               keys,
 			OnConnectionSucces: func() {},
 		},
 		udp.ClientWithPingInterval(2*time.Second),
 	)
 
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	recorder, err := matchRecorder(serverClock)
+	if err != nil {
+		return nil, err
 	}
 
-	gameService, err := service.NewGameServer(&service.GameServerConfig{
+	return service.NewGameServer(&service.GameServerConfig{
 		ServerConnection: updClient,
 		Encoder:          &gamepb.Protobuf{},
-		PlayerID:         player.ID,
+		PlayerID:         p.ID,
+		Recorder:         recorder,
+		Clock:            serverClock,
+	})
+}
+
+// sessionKeys picks the session key management for the match.
+// Keys are derived by an X25519 key exchange when the server offers a key share, otherwise a random key is sent in the client hello.
+func sessionKeys(match *dmn.Match) (i.KeyManager, error) {
+	if len(match.SocketKeyShare) > 0 {
+		return crypto.NewX25519Keys(match.SocketKeyShare)
+	}
+	return crypto.NewEphemeralKeys(), nil
+}
+
+// matchRecorder creates the file recording the next match in the record directory, if any.
+// Its frames are timestamped with the server clock.
+func matchRecorder(clock i.ServerClock) (i.GameRecorder, error) {
+	if recordDir == "" {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(recordDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Create(filepath.Join(recordDir, time.Now().Format("20060102-150405")+".replay"))
+	if err != nil {
+		return nil, err
+	}
+	return gamepb.NewRecorder(file, gamepb.RecorderWithClock(clock)), nil
+}
+
+// startReplay plays a recorded match back in the game page.
+func startReplay(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	frames, err := gamepb.ReadReplay(file)
+	if err != nil {
+		return err
+	}
+
+	replayServer, err := service.NewReplayServer(frames)
+	if err != nil {
+		return err
+	}
+
+	// The local player is unknown in a replay, every player is rendered as an opponent.
+	gamePage, err := controller.NewGame(replayServer, uuid.Nil, nil, func(i.GameState) {}, gameOptions()...)
+	if err != nil {
+		return err
+	}
+
+	nav := controller.NewNavigator(app)
+	nav.Push(gamePage)
+	return nav.Run()
+}
+
+// runBot plays games matches headless with the named strategy, without starting the TUI.
+func runBot(cfg config.Config, strategyName, username, password string, games int) error {
+	var strategy i.Strategy
+	switch strategyName {
+	case "random":
+		strategy = service.NewRandomWalk(time.Now().UnixNano())
+	case "greedy":
+		strategy = service.NewGreedyReward(time.Now().UnixNano())
+	case "bfs":
+		strategy = service.NewShortestPath()
+	default:
+		return fmt.Errorf("unknown bot strategy %q, expected random, greedy or bfs", strategyName)
+	}
+
+	httpClient, err := newHttpClient(cfg, log.Default())
+	if err != nil {
+		return err
+	}
+	authService, err := service.NewAuth(httpClient, cfg.LoginUri, cfg.RegisterUri,
+		service.AuthWithRefreshUri(cfg.RefreshUri))
+	if err != nil {
+		return err
+	}
+	matchService, err := service.NewMatchMaking(service.MatchMakingConfig{
+		HttpClient: httpClient,
+		MatchUri:   cfg.MatchUri,
+		Clock:      serverClock,
 	})
+	if err != nil {
+		return err
+	}
 
+	bot, err := service.NewBot(service.BotConfig{
+		Auth:          authService,
+		MatchMaker:    matchService,
+		NewGameServer: newGameServer,
+		Strategy:      strategy,
+		Username:      username,
+		Password:      password,
+	})
 	if err != nil {
-		panic(err)
+		return err
+	}
+
+	// An interrupt withdraws the bot from the queue instead of leaving it to be matched.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for game := 0; game < games; game++ {
+		if _, err := bot.Play(ctx); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	gamePage, err := controller.NewGame(gameService, player.ID)
+// serve runs the local stand-in for the game backend until interrupted.
+func serve(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	httpAddr := flags.String("http", "127.0.0.1:8080", "address of the HTTP auth and match endpoints")
+	udpAddr := flags.String("udp", "127.0.0.1:9090", "address of the UDP game socket")
+	players := flags.Int("players", 1, "number of players per match")
+	duration := flags.Duration("duration", 2*time.Minute, "maximum duration of a match")
+	height := flags.Int("height", 10, "number of rows of the maze")
+	width := flags.Int("width", 10, "number of columns of the maze")
+	algorithmName := flags.String("algorithm", "backtracker", "maze algorithm: backtracker, prim or kruskal")
+	braid := flags.Float64("braid", 0, "probability between 0 and 1 of turning a dead end into a loop")
+	seed := flags.Int64("seed", 0, "seed of the generated mazes, random when 0")
+	fogOfWar := flags.Bool("fog", false, "hide the cells of the maze the players did not explore")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	algorithm, err := gamepb.ParseMazeAlgorithm(*algorithmName)
 	if err != nil {
-		panic(err)
+		return err
+	}
+
+	server, err := localserver.New(localserver.Config{
+		HTTPAddr:        *httpAddr,
+		UDPAddr:         *udpAddr,
+		PlayersPerMatch: *players,
+		MatchDuration:   *duration,
+		MazeHeight:      *height,
+		MazeWidth:       *width,
+		MazeAlgorithm:   algorithm,
+		MazeBraid:       *braid,
+		Seed:            *seed,
+		FogOfWar:        *fogOfWar,
+		Logger:          log.New(os.Stderr, "[SERVER] ", log.LstdFlags),
+	})
+	if err != nil {
+		return err
+	}
+	server.Start()
+	defer server.Close()
+
+	// The default endpoints of the configuration are the ones of the local server.
+	fmt.Printf("Local server running, start the client with:\n\n  -server %s\n", server.URL())
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	<-interrupt
+	return nil
+}
+
+// offlineConfig holds the settings of the offline single-player mode.
+type offlineConfig struct {
+	algorithm string
+	seed      int64
+	size      int
+	braid     float64
+	duration  time.Duration
+	fogOfWar  bool
+}
+
+// playOffline plays single-player matches on generated mazes until the player quits.
+// Every new match uses the next seed, so a seeded session is reproducible.
+func playOffline(cfg offlineConfig) error {
+	algorithm, err := gamepb.ParseMazeAlgorithm(cfg.algorithm)
+	if err != nil {
+		return err
+	}
+
+	seed := cfg.seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	playerID := uuid.New()
+	nav := controller.NewNavigator(app)
+	var failure error
+
+	// newMatch returns the game page of the match on the maze of seed, its results page plays the next seed.
+	var newMatch func(seed int64) (*controller.Game, error)
+	newMatch = func(seed int64) (*controller.Game, error) {
+		generator := gamepb.NewMazeGenerator(seed, gamepb.MazeWithAlgorithm(algorithm), gamepb.MazeWithBraid(cfg.braid))
+		maze, err := generator.Generate(cfg.size, cfg.size)
+		if err != nil {
+			return nil, err
+		}
+
+		err = generator.PopulateReward(maze, struct {
+			RewardOne      int32
+			RewardTwo      int32
+			RewardTypeProb float32
+		}{1, 5, 0.85})
+		if err != nil {
+			return nil, err
+		}
+
+		offlineServer, err := service.NewOfflineGameServer(service.OfflineGameConfig{
+			Maze:     maze,
+			Encoder:  &gamepb.Protobuf{},
+			PlayerID: playerID,
+			Duration: cfg.duration,
+			FogOfWar: cfg.fogOfWar,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return controller.NewGame(offlineServer, playerID, nil, func(gs i.GameState) {
+			playAgain := func() {
+				next, err := newMatch(seed + 1)
+				if err != nil {
+					failure = err
+					nav.Stop()
+					return
+				}
+				nav.Replace(next)
+			}
+			// There is no lobby offline, leaving the results quits.
+			resultsPage, err := controller.NewResultsPage(playerID, gs, playAgain, nav.Stop)
+			if err != nil {
+				failure = err
+				nav.Stop()
+				return
+			}
+			nav.Replace(resultsPage)
+		}, gameOptions()...)
 	}
 
-	gamePage.Start(app, player.ID[:])
+	gamePage, err := newMatch(seed)
+	if err != nil {
+		return err
+	}
+	nav.Push(gamePage)
+	if err := nav.Run(); err != nil {
+		return err
+	}
+	return failure
 }
@@ -2,28 +2,61 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
+	"sync"
+	"time"
 
 	"github.com/sofc-t/puzzle-client/dmn"
 	"github.com/sofc-t/puzzle-client/service/i"
 )
 
+// tokenRefreshLeeway refreshes the auth token before it expires, so a request is not sent with a token expiring on the way.
+const tokenRefreshLeeway = 30 * time.Second
+
+var (
+	ErrNotLoggedIn       = errors.New("not logged in")
+	ErrSessionExpired    = errors.New("session expired, please log in again")
+	ErrNoCredentialStore = errors.New("no credential store to remember the session")
+)
+
+type AuthOption func(*Auth)
+
+// refreshCall is a refresh of the tokens in flight, shared by the callers needing a fresh token meanwhile.
+type refreshCall struct {
+	done chan struct{} // Done is closed when the refresh ended.
+	err  error
+}
+
+// Auth logs the player in over HTTP. Its requests are bound by the timeout of the requester.
 type Auth struct {
 	httpClient  i.HttpRequester
 	loginUri    string
 	registerUri string
+	refreshUri  string
+	logoutUri   string
+	store       i.CredentialStore
+	session     *dmn.Credentials // Session holds the tokens of the logged in player, nil before the login.
+	remembered  bool             // Remembered sessions are persisted again whenever their token is refreshed.
+	refreshing  *refreshCall     // Refreshing is the refresh in flight, nil when there is none.
+	mu          sync.Mutex
 }
 
-func NewAuth(hr i.HttpRequester, loginUri, registerUri string) (i.AuthServer, error) {
-	return &Auth{
+func NewAuth(hr i.HttpRequester, loginUri, registerUri string, options ...AuthOption) (i.AuthServer, error) {
+	a := &Auth{
 		httpClient:  hr,
 		loginUri:    loginUri,
 		registerUri: registerUri,
-	}, nil
+	}
+	for _, opt := range options {
+		opt(a)
+	}
+	return a, nil
 }
 
-// Login implements i.AuthServer.
+// Login implements i.AuthServer. A remembered session of a previous login is forgotten.
 func (a *Auth) Login(username string, password string) (*dmn.Player, string, error) {
 	body := &AuthRequest{
 		Username: username,
@@ -35,28 +68,27 @@ func (a *Auth) Login(username string, password string) (*dmn.Player, string, err
 		return nil, "", err
 	}
 
-	response, err := a.httpClient.Post(a.loginUri, bytes.NewReader(payload), "")
+	response, err := a.httpClient.Post(context.Background(), a.loginUri, bytes.NewReader(payload), "")
 	if err != nil {
 		return nil, "", err
 	}
 
-	responseBody, err := io.ReadAll(response)
+	loginResponse, err := parseAuthResponse(response)
 	if err != nil {
 		return nil, "", err
 	}
 
-	var loginResponse AuthResponse
-	err = json.Unmarshal(responseBody, &loginResponse)
-	if err != nil {
-		return nil, "", err // Return error if unmarshalling fails
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.session = credentialsOf(loginResponse)
+	a.remembered = false
+	if a.store != nil {
+		_ = a.store.Clear()
 	}
 
-	// Return the player, token, and nil error
-	return &dmn.Player{
-		ID:       loginResponse.ID,
-		Rating:   loginResponse.Rating,
-		Username: loginResponse.Username,
-	}, loginResponse.Token, nil
+	player := a.session.Player
+	return &player, a.session.AccessToken, nil
 }
 
 // Register implements i.AuthServer.
@@ -71,10 +103,217 @@ func (a *Auth) Register(username string, password string) error {
 		return err
 	}
 
-	_, err = a.httpClient.Post(a.registerUri, bytes.NewReader(payload), "")
+	_, err = a.httpClient.Post(context.Background(), a.registerUri, bytes.NewReader(payload), "")
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// Token implements i.AuthServer. A token that fails to refresh is still returned until it expires.
+func (a *Auth) Token() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.session == nil {
+		return "", ErrNotLoggedIn
+	}
+
+	if expiresWithin(a.session.AccessToken, tokenRefreshLeeway) {
+		err := a.refresh()
+		if a.session == nil {
+			return "", ErrNotLoggedIn
+		}
+		if err != nil && expiresWithin(a.session.AccessToken, 0) {
+			return "", err
+		}
+	}
+	return a.session.AccessToken, nil
+}
+
+// Remember implements i.AuthServer.
+func (a *Auth) Remember() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.store == nil {
+		return ErrNoCredentialStore
+	}
+	if a.session == nil {
+		return ErrNotLoggedIn
+	}
+
+	if err := a.store.Save(a.session); err != nil {
+		return err
+	}
+	a.remembered = true
+	return nil
+}
+
+// Resume implements i.AuthServer. A persisted session that can no longer be refreshed is forgotten.
+func (a *Auth) Resume() (*dmn.Player, string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.store == nil {
+		return nil, "", ErrNoCredentialStore
+	}
+
+	credentials, err := a.store.Load()
+	if err != nil {
+		return nil, "", err
+	}
+	a.session, a.remembered = credentials, true
+
+	if expiresWithin(a.session.AccessToken, tokenRefreshLeeway) {
+		if err := a.refresh(); err != nil && (a.session == nil || expiresWithin(a.session.AccessToken, 0)) {
+			a.session, a.remembered = nil, false
+			_ = a.store.Clear()
+			return nil, "", ErrSessionExpired
+		}
+	}
+
+	player := a.session.Player
+	return &player, a.session.AccessToken, nil
+}
+
+// Logout implements i.AuthServer. The session is forgotten locally even when the server fails to revoke it.
+// The session is forgotten before the request, which is sent without holding a.mu.
+func (a *Auth) Logout() error {
+	a.mu.Lock()
+	session := a.session
+	if session == nil {
+		a.mu.Unlock()
+		return ErrNotLoggedIn
+	}
+
+	var err error
+	a.session, a.remembered = nil, false
+	if a.store != nil {
+		err = a.store.Clear()
+	}
+	a.mu.Unlock()
+
+	if a.logoutUri != "" {
+		err = errors.Join(a.postRefreshToken(a.logoutUri, session), err)
+	}
+	return err
+}
+
+// refresh replaces the tokens of the session with new ones. The caller must hold a.mu, which is released during
+// the request so the other methods are not blocked by it, the session may be gone when it returns.
+// Callers needing a fresh token meanwhile wait for the refresh in flight instead of sending their own.
+func (a *Auth) refresh() error {
+	if call := a.refreshing; call != nil {
+		a.mu.Unlock()
+		<-call.done
+		a.mu.Lock()
+		return call.err
+	}
+
+	if a.refreshUri == "" || a.session.RefreshToken == "" {
+		return ErrSessionExpired
+	}
+
+	call := &refreshCall{done: make(chan struct{})}
+	a.refreshing = call
+	session := a.session
+	a.mu.Unlock()
+
+	refreshed, err := a.requestRefresh(session.RefreshToken)
+
+	a.mu.Lock()
+	a.refreshing = nil
+	// A logout or a login meanwhile replaced the session, the refreshed one is obsolete.
+	if err == nil && a.session == session {
+		a.session = refreshed
+		if a.remembered {
+			err = a.store.Save(a.session)
+		}
+	}
+	call.err = err
+	close(call.done)
+	return err
+}
+
+// requestRefresh asks the server for new tokens, the refresh token is kept when the server does not rotate it.
+func (a *Auth) requestRefresh(refreshToken string) (*dmn.Credentials, error) {
+	payload, err := json.Marshal(RefreshRequest{RefreshToken: refreshToken})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := a.httpClient.Post(context.Background(), a.refreshUri, bytes.NewReader(payload), "")
+	if err != nil {
+		return nil, err
+	}
+
+	refreshResponse, err := parseAuthResponse(response)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshed := credentialsOf(refreshResponse)
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = refreshToken
+	}
+	return refreshed, nil
+}
+
+// postRefreshToken sends the refresh token of session to uri, authenticated by its access token.
+func (a *Auth) postRefreshToken(uri string, session *dmn.Credentials) error {
+	payload, err := json.Marshal(RefreshRequest{RefreshToken: session.RefreshToken})
+	if err != nil {
+		return err
+	}
+
+	_, err = a.httpClient.Post(context.Background(), uri, bytes.NewReader(payload), session.AccessToken)
+	return err
+}
+
+func parseAuthResponse(response io.Reader) (*AuthResponse, error) {
+	responseBody, err := io.ReadAll(response)
+	if err != nil {
+		return nil, err
+	}
+
+	var authResponse AuthResponse
+	if err := json.Unmarshal(responseBody, &authResponse); err != nil {
+		return nil, err
+	}
+	return &authResponse, nil
+}
+
+func credentialsOf(response *AuthResponse) *dmn.Credentials {
+	return &dmn.Credentials{
+		Player: dmn.Player{
+			ID:       response.ID,
+			Rating:   response.Rating,
+			Username: response.Username,
+		},
+		AccessToken:  response.Token,
+		RefreshToken: response.RefreshToken,
+	}
+}
+
+// AuthWithRefreshUri sets the URI renewing expired auth tokens. Sessions end with their token without it.
+func AuthWithRefreshUri(uri string) AuthOption {
+	return func(a *Auth) {
+		a.refreshUri = uri
+	}
+}
+
+// AuthWithLogoutUri sets the URI revoking the session on logout. Logout only forgets the session locally without it.
+func AuthWithLogoutUri(uri string) AuthOption {
+	return func(a *Auth) {
+		a.logoutUri = uri
+	}
+}
+
+// AuthWithStore sets the store persisting remembered sessions.
+func AuthWithStore(store i.CredentialStore) AuthOption {
+	return func(a *Auth) {
+		a.store = store
+	}
+}
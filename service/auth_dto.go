@@ -12,4 +12,12 @@ type AuthResponse struct {
 	Username string    `json:"username"`
 	Rating   int       `json:"rating"`
 	Token    string    `json:"auth_token"`
+
+	// RefreshToken renews the auth token once it expires. Servers without refresh leave it empty.
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// RefreshRequest asks for a new auth token, or revokes the refresh token on logout.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
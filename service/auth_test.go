@@ -0,0 +1,150 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sofc-t/puzzle-client/dmn"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// newTestJWT returns an unsigned JWT with the given claims.
+func newTestJWT(claims string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + encode([]byte(claims)) + ".signature"
+}
+
+// TestTokenExpiry contains all the tests related to decoding the expiry of auth tokens.
+func TestTokenExpiry(t *testing.T) {
+	t.Run("TokenExpiry_JWT", testTokenExpiry_JWT)
+	t.Run("TokenExpiry_Unknown", testTokenExpiry_Unknown)
+	t.Run("TokenExpiry_ExpiresWithin", testTokenExpiry_ExpiresWithin)
+}
+
+// testTokenExpiry_JWT tests that the exp claim of a JWT is decoded.
+func testTokenExpiry_JWT(t *testing.T) {
+	expiry, ok := tokenExpiry(newTestJWT(`{"sub":"gopher","exp":1700000000}`))
+	if !ok || !expiry.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("Expected the expiry of the exp claim, got: %s, %v", expiry, ok)
+	}
+}
+
+// testTokenExpiry_Unknown tests that opaque tokens and JWTs without exp have no known expiry.
+func testTokenExpiry_Unknown(t *testing.T) {
+	for _, token := range []string{"5f2b9c", newTestJWT(`{"sub":"gopher"}`), "a.!!!.c"} {
+		if _, ok := tokenExpiry(token); ok {
+			t.Errorf("Expected no expiry for %q", token)
+		}
+	}
+}
+
+// testTokenExpiry_ExpiresWithin tests the refresh decision around the expiry.
+func testTokenExpiry_ExpiresWithin(t *testing.T) {
+	expiring := newTestJWT(`{"exp":` + strconv.FormatInt(time.Now().Add(10*time.Second).Unix(), 10) + `}`)
+	if !expiresWithin(expiring, tokenRefreshLeeway) || expiresWithin(expiring, 0) {
+		t.Errorf("Expected a token expiring in 10s to be refreshed but still valid")
+	}
+
+	if expiresWithin("opaque", time.Hour) {
+		t.Errorf("Expected a token without expiry to never expire")
+	}
+}
+
+// slowRefresher is an i.HttpRequester answering the posted requests, such as the refreshes, once released.
+type slowRefresher struct {
+	refreshes atomic.Int32
+	started   chan struct{} // Started receives a value when a request is posted.
+	release   chan struct{} // Release answers the posted requests once closed.
+}
+
+func (s *slowRefresher) Post(context.Context, string, io.Reader, string) (io.Reader, error) {
+	s.refreshes.Add(1)
+	s.started <- struct{}{}
+	<-s.release
+
+	payload, err := json.Marshal(AuthResponse{Token: "refreshed"})
+	return bytes.NewReader(payload), err
+}
+
+func (s *slowRefresher) Get(context.Context, string, string) (io.Reader, error)    { return nil, nil }
+func (s *slowRefresher) Delete(context.Context, string, string) (io.Reader, error) { return nil, nil }
+func (s *slowRefresher) Subscribe(context.Context, string, string) (<-chan i.ServerEvent, error) {
+	return nil, i.ErrNotEventStream
+}
+
+// TestAuth contains all the tests related to the session of the logged in player.
+func TestAuth(t *testing.T) {
+	t.Run("Auth_RefreshInFlight", testAuth_RefreshInFlight)
+	t.Run("Auth_LogoutInFlight", testAuth_LogoutInFlight)
+}
+
+// testAuth_RefreshInFlight tests that the session is not locked during a refresh, and that the callers needing
+// a fresh token meanwhile share the refresh in flight.
+func testAuth_RefreshInFlight(t *testing.T) {
+	requester := &slowRefresher{started: make(chan struct{}, 3), release: make(chan struct{})}
+	server, _ := NewAuth(requester, "/login", "/register", AuthWithRefreshUri("/refresh"))
+	auth := server.(*Auth)
+	expiring := newTestJWT(`{"exp":` + strconv.FormatInt(time.Now().Add(10*time.Second).Unix(), 10) + `}`)
+	auth.session = &dmn.Credentials{AccessToken: expiring, RefreshToken: "refresh"}
+
+	tokens := make(chan string, 3)
+	for n := 0; n < 3; n++ {
+		go func() {
+			token, _ := auth.Token()
+			tokens <- token
+		}()
+	}
+	<-requester.started
+
+	if err := auth.Remember(); err != ErrNoCredentialStore {
+		t.Errorf("Expected the session to be available during the refresh, got: %v", err)
+	}
+
+	close(requester.release)
+	for n := 0; n < 3; n++ {
+		if token := <-tokens; token != "refreshed" {
+			t.Errorf("Expected the refreshed token, got: %q", token)
+		}
+	}
+	if refreshes := requester.refreshes.Load(); refreshes != 1 {
+		t.Errorf("Expected a single refresh request, got: %d", refreshes)
+	}
+}
+
+// testAuth_LogoutInFlight tests that the session is forgotten before the logout request and not locked during it.
+func testAuth_LogoutInFlight(t *testing.T) {
+	requester := &slowRefresher{started: make(chan struct{}, 1), release: make(chan struct{})}
+	server, _ := NewAuth(requester, "/login", "/register", AuthWithLogoutUri("/logout"))
+	auth := server.(*Auth)
+	auth.session = &dmn.Credentials{AccessToken: newTestJWT(`{}`), RefreshToken: "refresh"}
+
+	done := make(chan error)
+	go func() { done <- auth.Logout() }()
+	<-requester.started
+
+	tokens := make(chan error)
+	go func() {
+		_, err := auth.Token()
+		tokens <- err
+	}()
+	select {
+	case err := <-tokens:
+		if err != ErrNotLoggedIn {
+			t.Errorf("Expected ErrNotLoggedIn during the logout, got: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected the session to be available during the logout")
+	}
+
+	close(requester.release)
+	if err := <-done; err != nil {
+		t.Errorf("Expected the logout to succeed, got error: %s", err)
+	}
+}
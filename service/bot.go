@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/sofc-t/puzzle-client/dmn"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+const defaultBotMoveInterval = 200 * time.Millisecond
+
+var (
+	ErrBotMissingDependency = errors.New("bot requires an auth server, a match maker, a game server factory and a strategy")
+	ErrBotConnectionLost    = errors.New("bot lost the connection to the game server")
+)
+
+// GameServerFactory connects a player to the game server of a match.
+type GameServerFactory func(match *dmn.Match, player *dmn.Player) (i.GameServer, error)
+
+// Bot is a headless client playing matches with a strategy instead of the keyboard.
+type Bot struct {
+	auth          i.AuthServer
+	matchMaker    i.MatchMaker
+	newGameServer GameServerFactory
+	strategy      i.Strategy
+	username      string
+	password      string
+	moveInterval  time.Duration
+	logger        *log.Logger
+	player        *dmn.Player
+}
+
+type BotConfig struct {
+	Auth          i.AuthServer
+	MatchMaker    i.MatchMaker
+	NewGameServer GameServerFactory
+	Strategy      i.Strategy
+	Username      string
+	Password      string
+	MoveInterval  time.Duration // MoveInterval is the delay between two moves, defaults to 200ms.
+	Logger        *log.Logger   // Logger defaults to the standard logger.
+}
+
+func NewBot(cfg BotConfig) (*Bot, error) {
+	if cfg.Auth == nil || cfg.MatchMaker == nil || cfg.NewGameServer == nil || cfg.Strategy == nil {
+		return nil, ErrBotMissingDependency
+	}
+
+	moveInterval := cfg.MoveInterval
+	if moveInterval <= 0 {
+		moveInterval = defaultBotMoveInterval
+	}
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	return &Bot{
+		auth:          cfg.Auth,
+		matchMaker:    cfg.MatchMaker,
+		newGameServer: cfg.NewGameServer,
+		strategy:      cfg.Strategy,
+		username:      cfg.Username,
+		password:      cfg.Password,
+		moveInterval:  moveInterval,
+		logger:        logger,
+	}, nil
+}
+
+// Play logs in on the first call, finds a match and plays it until the game ends.
+// It returns the final game state. Cancelling ctx stops the search for a match.
+func (b *Bot) Play(ctx context.Context) (i.GameState, error) {
+	if b.player == nil {
+		player, _, err := b.auth.Login(b.username, b.password)
+		if err != nil {
+			return nil, err
+		}
+		b.player = player
+		b.logger.Printf("[BOT] [INFO] Logged in as %s (%s)", player.Username, player.ID)
+	}
+
+	// The token is asked for every match, so a long run of matches refreshes it.
+	token, err := b.auth.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	match, err := b.matchMaker.Match(ctx, b.player.ID, token, nil)
+	if err != nil {
+		return nil, err
+	}
+	b.logger.Printf("[BOT] [INFO] Matched on %s", match.SocketAddr)
+
+	gameServer, err := b.newGameServer(match, b.player)
+	if err != nil {
+		return nil, err
+	}
+	return b.playMatch(gameServer)
+}
+
+// playMatch drives gameServer with the strategy, one move per interval on the latest state.
+func (b *Bot) playMatch(gameServer i.GameServer) (i.GameState, error) {
+	var latest i.GameState
+	var mu sync.Mutex
+	ended := make(chan i.GameState, 1)
+	lost := make(chan struct{}, 1)
+
+	gameServer.SetOnStateChange(func(gs i.GameState) {
+		// Only the latest state matters, a state not played yet is replaced.
+		mu.Lock()
+		latest = gs
+		mu.Unlock()
+	})
+	gameServer.SetOnGameEnd(func(gs i.GameState) {
+		ended <- gs
+	})
+	gameServer.SetOnPingResult(func(i.NetworkStats) {})
+	gameServer.SetOnConnectionStateChange(func(s i.ConnectionState) {
+		if s == i.ConnectionLost {
+			select {
+			case lost <- struct{}{}:
+			default:
+			}
+		}
+	})
+
+	// Start blocks reading from the server until the game server is stopped.
+	failed := make(chan error, 1)
+	go func() {
+		if err := gameServer.Start(b.player.ID[:]); err != nil {
+			failed <- err
+		}
+	}()
+
+	ticker := time.NewTicker(b.moveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case gs := <-ended:
+			b.logger.Printf("[BOT] [INFO] Game ended at version %d", gs.GetVersion())
+			return gs, nil
+		case err := <-failed:
+			return nil, err
+		case <-lost:
+			_ = gameServer.Stop()
+			return nil, ErrBotConnectionLost
+		case <-ticker.C:
+			mu.Lock()
+			gs := latest
+			mu.Unlock()
+
+			if gs == nil {
+				continue
+			}
+			if direction, ok := b.strategy.NextMove(gs, b.player.ID); ok {
+				gameServer.Move(direction)
+			}
+		}
+	}
+}
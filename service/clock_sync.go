@@ -0,0 +1,162 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+var _ i.ServerClock = &ClockSync{}
+
+const (
+	clockFilterSize = 8                // Samples the one with the shortest RTT is picked from, as in the NTP clock filter.
+	clockDriftSize  = 16               // Filtered offsets the drift is fitted on.
+	minDriftSpan    = 10 * time.Second // MinDriftSpan is the time the filtered offsets span before a drift is estimated.
+)
+
+type ClockSyncOption func(*ClockSync)
+
+// clockSample is an offset of the server clock measured at a local time, by an exchange taking rtt.
+type clockSample struct {
+	at     time.Time
+	offset time.Duration
+	rtt    time.Duration
+}
+
+// ClockSync estimates the server clock from the ping exchanges.
+//
+// The offset of an exchange is off by up to half its RTT when the network is asymmetric, so the offset is
+// taken from the recent exchange with the shortest RTT. A line fitted on these filtered offsets smooths them,
+// its slope being the drift of the local clock, which extrapolates the offset between two exchanges.
+type ClockSync struct {
+	now        func() time.Time
+	samples    []clockSample // Samples holds the last clockFilterSize exchanges, oldest first.
+	filtered   []clockSample // Filtered holds the last clockDriftSize filtered offsets, oldest first.
+	offset     time.Duration // Offset is the estimated offset at the local time offsetTime.
+	offsetTime time.Time
+	drift      float64 // Drift is the change of the offset in seconds per second of the local clock.
+	mu         sync.Mutex
+}
+
+// NewClockSync creates a ClockSync. Until it is fed with an exchange the server time is the local time.
+func NewClockSync(options ...ClockSyncOption) *ClockSync {
+	c := &ClockSync{now: time.Now}
+	for _, opt := range options {
+		opt(c)
+	}
+	return c
+}
+
+// Observe adds the last exchange of the network stats, if any.
+func (c *ClockSync) Observe(stats i.NetworkStats) {
+	if !stats.MeasuredAt.IsZero() {
+		c.AddSample(stats.MeasuredAt, stats.Offset, stats.RTT)
+	}
+}
+
+// AddSample adds an exchange ending at the local time at, which measured offset and took rtt.
+// An exchange ending before the last one added is ignored, the samples being kept oldest first.
+func (c *ClockSync) AddSample(at time.Time, offset, rtt time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if n := len(c.samples); n > 0 && !at.After(c.samples[n-1].at) {
+		return
+	}
+	c.samples = append(c.samples, clockSample{at: at, offset: offset, rtt: rtt})
+	if len(c.samples) > clockFilterSize {
+		c.samples = c.samples[1:]
+	}
+
+	best := c.samples[0]
+	for _, s := range c.samples[1:] {
+		if s.rtt <= best.rtt {
+			best = s
+		}
+	}
+
+	// The same exchange stays the best until a shorter one arrives or it leaves the filter.
+	if n := len(c.filtered); n > 0 && c.filtered[n-1] == best {
+		return
+	}
+	c.filtered = append(c.filtered, best)
+	if len(c.filtered) > clockDriftSize {
+		c.filtered = c.filtered[1:]
+	}
+
+	c.offset, c.offsetTime, c.drift = best.offset, best.at, 0
+	if best.at.Sub(c.filtered[0].at) >= minDriftSpan {
+		var offset float64
+		c.drift, offset = fitLine(c.filtered)
+		c.offset = time.Duration(offset * float64(time.Second))
+	}
+}
+
+// Offset returns the estimated offset of the server clock from the local one, now.
+func (c *ClockSync) Offset() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.offsetAt(c.now())
+}
+
+// Drift returns the estimated change of the offset in seconds per second, positive when the server clock
+// runs faster than the local one.
+func (c *ClockSync) Drift() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.drift
+}
+
+// Synced reports whether an exchange was added.
+func (c *ClockSync) Synced() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.filtered) > 0
+}
+
+// ServerNow implements i.ServerClock.
+func (c *ClockSync) ServerNow() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	return now.Add(c.offsetAt(now))
+}
+
+// offsetAt returns the offset at the local time t, extrapolated with the drift. The caller must hold c.mu.
+func (c *ClockSync) offsetAt(t time.Time) time.Duration {
+	if c.offsetTime.IsZero() {
+		return 0
+	}
+	return c.offset + time.Duration(c.drift*float64(t.Sub(c.offsetTime)))
+}
+
+// fitLine returns the slope of the least squares line through the offsets, and its value in seconds at the
+// time of the last one.
+func fitLine(samples []clockSample) (slope, last float64) {
+	// Times are taken from the last sample to keep the sums small.
+	origin := samples[len(samples)-1].at
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range samples {
+		x, y := s.at.Sub(origin).Seconds(), s.offset.Seconds()
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	n := float64(len(samples))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, sumY / n
+	}
+	slope = (n*sumXY - sumX*sumY) / denominator
+	return slope, (sumY - slope*sumX) / n
+}
+
+// ClockSyncWithNow sets the local clock, time.Now by default.
+func ClockSyncWithNow(now func() time.Time) ClockSyncOption {
+	return func(c *ClockSync) {
+		c.now = now
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	gamepb "github.com/sofc-t/puzzle-client/infrastruture/pb_encoder/game"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// skewedClock simulates a server clock ahead of the local one by offset, and running faster by drift.
+type skewedClock struct {
+	start  time.Time
+	local  time.Time // Local is the current local time, advanced by the exchanges and the tests.
+	offset time.Duration
+	drift  float64
+}
+
+func newSkewedClock(offset time.Duration, drift float64) *skewedClock {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &skewedClock{start: start, local: start, offset: offset, drift: drift}
+}
+
+func (c *skewedClock) now() time.Time {
+	return c.local
+}
+
+// server returns the server time at the local time t.
+func (c *skewedClock) server(t time.Time) time.Time {
+	return t.Add(c.offset + time.Duration(c.drift*float64(t.Sub(c.start))))
+}
+
+// exchange simulates a ping travelling up to the server and its pong travelling down, answered in a millisecond,
+// and adds the sample measured as the ping tracker does.
+func (c *skewedClock) exchange(clock *ClockSync, up, down time.Duration) {
+	t1 := c.local
+	t2 := c.server(t1.Add(up))
+	t3 := t2.Add(time.Millisecond)
+	t4 := t1.Add(up + time.Millisecond + down)
+
+	rtt := t4.Sub(t1) - t3.Sub(t2)
+	offset := (t2.Sub(t1) + t3.Sub(t4)) / 2
+	clock.AddSample(t4, offset, rtt)
+	c.local = t4
+}
+
+// TestClockSync contains all the tests related to the server clock synchronization.
+func TestClockSync(t *testing.T) {
+	t.Run("ClockSync_NotSynced", testClockSync_NotSynced)
+	t.Run("ClockSync_Offset", testClockSync_Offset)
+	t.Run("ClockSync_AsymmetricDelay", testClockSync_AsymmetricDelay)
+	t.Run("ClockSync_Drift", testClockSync_Drift)
+	t.Run("ClockSync_Observe", testClockSync_Observe)
+	t.Run("ClockSync_OutOfOrder", testClockSync_OutOfOrder)
+	t.Run("ClockSync_GameServer", testClockSync_GameServer)
+}
+
+// testClockSync_NotSynced tests that the server time is the local time before any exchange.
+func testClockSync_NotSynced(t *testing.T) {
+	skewed := newSkewedClock(2*time.Second, 0)
+	clock := NewClockSync(ClockSyncWithNow(skewed.now))
+
+	if clock.Synced() || !clock.ServerNow().Equal(skewed.local) {
+		t.Errorf("Expected the local time before any exchange, got: %s", clock.ServerNow())
+	}
+}
+
+// testClockSync_Offset tests that a constant offset is measured exactly over a symmetric network.
+func testClockSync_Offset(t *testing.T) {
+	skewed := newSkewedClock(2*time.Second, 0)
+	clock := NewClockSync(ClockSyncWithNow(skewed.now))
+
+	for n := 0; n < 5; n++ {
+		skewed.exchange(clock, 20*time.Millisecond, 20*time.Millisecond)
+		skewed.local = skewed.local.Add(time.Second)
+	}
+
+	assertServerTime(t, clock, skewed, 0)
+	if !clock.Synced() {
+		t.Errorf("Expected the clock to be synced")
+	}
+}
+
+// testClockSync_AsymmetricDelay tests that the slow exchanges, whose offset is skewed by an asymmetric network,
+// are filtered out by the faster ones.
+func testClockSync_AsymmetricDelay(t *testing.T) {
+	skewed := newSkewedClock(-750*time.Millisecond, 0)
+	clock := NewClockSync(ClockSyncWithNow(skewed.now))
+
+	for n := 0; n < 20; n++ {
+		if n%2 == 0 {
+			skewed.exchange(clock, 20*time.Millisecond, 20*time.Millisecond)
+		} else {
+			skewed.exchange(clock, 300*time.Millisecond, 20*time.Millisecond)
+		}
+		skewed.local = skewed.local.Add(time.Second)
+	}
+
+	assertServerTime(t, clock, skewed, time.Millisecond)
+}
+
+// testClockSync_Drift tests that a server clock running faster is followed between two exchanges.
+func testClockSync_Drift(t *testing.T) {
+	skewed := newSkewedClock(-500*time.Millisecond, 0.001)
+	clock := NewClockSync(ClockSyncWithNow(skewed.now))
+
+	for n := 0; n < 60; n++ {
+		skewed.exchange(clock, time.Duration(15+n%3*5)*time.Millisecond, time.Duration(15+n%3*5)*time.Millisecond)
+		skewed.local = skewed.local.Add(time.Second)
+	}
+
+	if drift := clock.Drift(); math.Abs(drift-0.001) > 0.00005 {
+		t.Errorf("Expected a drift of 0.001, got: %g", drift)
+	}
+
+	// Without the drift, the estimate would be 30ms late after 30 seconds.
+	skewed.local = skewed.local.Add(30 * time.Second)
+	assertServerTime(t, clock, skewed, 2*time.Millisecond)
+}
+
+// testClockSync_Observe tests that the network stats feed the clock once a pong was received.
+func testClockSync_Observe(t *testing.T) {
+	skewed := newSkewedClock(0, 0)
+	clock := NewClockSync(ClockSyncWithNow(skewed.now))
+
+	clock.Observe(i.NetworkStats{})
+	if clock.Synced() {
+		t.Fatalf("Expected the stats without pong to be ignored")
+	}
+
+	clock.Observe(i.NetworkStats{RTT: 40 * time.Millisecond, Offset: time.Second, MeasuredAt: skewed.local})
+	if offset := clock.Offset(); offset != time.Second {
+		t.Errorf("Expected a 1s offset, got: %s", offset)
+	}
+}
+
+// testClockSync_OutOfOrder tests that an exchange ending before the last one added is ignored.
+func testClockSync_OutOfOrder(t *testing.T) {
+	skewed := newSkewedClock(0, 0)
+	clock := NewClockSync(ClockSyncWithNow(skewed.now))
+
+	clock.AddSample(skewed.local, time.Second, 40*time.Millisecond)
+	clock.AddSample(skewed.local.Add(-time.Second), 3*time.Second, 10*time.Millisecond)
+
+	if offset := clock.Offset(); offset != time.Second {
+		t.Errorf("Expected the late exchange to be ignored, got offset: %s", offset)
+	}
+}
+
+// testClockSync_GameServer tests that the game server tells the server time from the pongs of its connection,
+// with or without a ping callback.
+func testClockSync_GameServer(t *testing.T) {
+	skewed := newSkewedClock(0, 0)
+	conn := &fakeConnection{}
+	server, err := NewGameServer(&GameServerConfig{
+		ServerConnection: conn,
+		Encoder:          &gamepb.Protobuf{},
+		PlayerID:         testPlayerID,
+		Clock:            NewClockSync(ClockSyncWithNow(skewed.now)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.onPingResult(i.NetworkStats{RTT: 40 * time.Millisecond, Offset: 3 * time.Second, MeasuredAt: skewed.local})
+
+	clock, ok := server.(i.ServerClock)
+	if !ok {
+		t.Fatalf("Expected the game server to implement i.ServerClock")
+	}
+	if got := clock.ServerNow().Sub(skewed.local); got != 3*time.Second {
+		t.Errorf("Expected the server time 3s ahead, got: %s", got)
+	}
+}
+
+func assertServerTime(t *testing.T, clock *ClockSync, skewed *skewedClock, tolerance time.Duration) {
+	t.Helper()
+	diff := clock.ServerNow().Sub(skewed.server(skewed.local))
+	if diff < -tolerance || diff > tolerance {
+		t.Errorf("Expected the server time within %s, got an error of %s", tolerance, diff)
+	}
+}
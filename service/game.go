@@ -1,7 +1,9 @@
 package service
 
 import (
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sofc-t/puzzle-client/service/i"
@@ -13,16 +15,26 @@ const (
 
 	gameStateRecordType = 10
 	gameEndedRecordType = 11
+
+	maxPendingActions = 64 // Unacknowledged actions kept for reconciliation; older ones are dropped.
 )
 
 type GameServer struct {
 	serverConnection i.ClientManager
 	encoder          i.GameEncoder
 	onGameEnd        func(i.GameState)
-	gameState        i.GameState
+	ended            bool               // Ended is set once the game ended record is handled.
+	gameState        i.GameState        // Authoritative state received from the server.
+	predictedState   i.GameState        // gameState with the pending actions applied locally.
+	pendingActions   map[int64]i.Action // Actions sent to the server and not acknowledged yet, keyed by sequence.
+	actionSeq        int64              // Sequence of the last action sent.
+	acknowledged     bool               // Acknowledged is set once the server echoed the sequence of an action.
 	playerID         uuid.UUID
 	onStateChange    func(i.GameState)
-	onPingResult     func(int64)
+	onPingResult     func(i.NetworkStats)
+	onConnChange     func(i.ConnectionState)
+	recorder         i.GameRecorder // Recorder persists the match when set.
+	clock            *ClockSync     // Clock is fed with every pong, it tells the server time.
 	sync.Mutex
 }
 
@@ -31,6 +43,8 @@ type GameServerConfig struct {
 	Encoder          i.GameEncoder
 	OnGameEnd        func(i.GameState)
 	PlayerID         uuid.UUID
+	Recorder         i.GameRecorder // Recorder is optional, every versioned state and local action is recorded when set.
+	Clock            *ClockSync     // Clock is optional, a new one is fed with the pongs of the connection when nil.
 }
 
 func NewGameServer(cfg *GameServerConfig) (i.GameServer, error) {
@@ -38,10 +52,18 @@ func NewGameServer(cfg *GameServerConfig) (i.GameServer, error) {
 		serverConnection: cfg.ServerConnection,
 		encoder:          cfg.Encoder,
 		playerID:         cfg.PlayerID,
+		onGameEnd:        cfg.OnGameEnd,
+		recorder:         cfg.Recorder,
+		pendingActions:   make(map[int64]i.Action),
+		clock:            cfg.Clock,
+	}
+	if server.clock == nil {
+		server.clock = NewClockSync()
 	}
 
 	server.serverConnection.SetOnServerResponse(server.handleServerResponse)
 	server.serverConnection.SetOnPingResult(server.handlePingResponse)
+	server.serverConnection.SetOnConnectionStateChange(server.handleConnectionStateChange)
 	return server, nil
 }
 
@@ -55,67 +77,246 @@ func (g *GameServer) Start(authToken []byte) error {
 
 func (g *GameServer) Stop() error {
 	g.serverConnection.Disconnect()
+	if g.recorder != nil {
+		return g.recorder.Close()
+	}
 	return nil
 }
 
-// move implements i.GameServer.
+// Move implements i.GameServer.
+// The move is applied optimistically to the predicted state, which is published right away,
+// and kept as a pending action until the server acknowledges it.
 func (g *GameServer) Move(direction string) {
+	g.Lock()
+	if g.predictedState == nil || g.ended {
+		g.Unlock()
+		return
+	}
+
+	player := g.localPlayer(g.predictedState)
+	if player == nil {
+		g.Unlock()
+		return
+	}
+
 	action := g.encoder.NewAction()
 	action.SetDirection(direction)
 	action.SetID(g.playerID)
-	action.SetFrom(g.playerPosition())
+	action.SetFrom(player.RetrivePos())
+	action.SetSeq(g.actionSeq + 1)
+
+	predicted, err := g.cloneState(g.predictedState)
+	if err != nil || !g.applyAction(predicted, action) {
+		g.Unlock()
+		return // The move is blocked by a wall, the server would reject it too.
+	}
 
 	payload, err := g.encoder.MarshalAction(action)
 	if err != nil {
+		g.Unlock()
 		return
 	}
 
+	g.actionSeq = action.GetSeq()
+	g.pendingActions[action.GetSeq()] = action
+	g.dropOldActions()
+	g.predictedState = predicted
+	g.Unlock()
+
+	g.onStateChange(predicted)
+
 	err = g.serverConnection.SendToServer(moveActionType, payload)
 	if err != nil {
 		return
 	}
+	g.record(nil, action)
 }
 
 func (g *GameServer) handleServerResponse(t byte, p []byte) {
-	g.Lock()
-	defer g.Unlock()
-
 	gameState, err := g.encoder.UnmarshalGameState(p)
 	if err != nil {
 		return
 	}
 
 	if t == gameEndedRecordType {
-		g.onGameEnd(gameState)
+		g.handleGameEnd(gameState)
 		return
 	}
 
-	if g.gameState == nil || g.gameState.GetVersion() < gameState.GetVersion() {
-		g.gameState = gameState
-		g.onStateChange(g.gameState)
+	g.Lock()
+	if g.ended || (g.gameState != nil && g.gameState.GetVersion() >= gameState.GetVersion()) {
+		g.Unlock()
+		return
 	}
-}
 
-func (g *GameServer) handlePingResponse(ping int64) {
-	g.onPingResult(ping)
+	g.record(gameState, nil)
+	g.gameState = gameState
+	g.reconcile()
+	predicted := g.predictedState
+	g.Unlock()
+
+	g.onStateChange(predicted)
 }
 
-func (g *GameServer) playerPosition() i.CellPosition {
+// handleGameEnd tears down the UDP session and hands the final state to onGameEnd.
+// The server may repeat the game ended record, only the first one is handled.
+func (g *GameServer) handleGameEnd(gs i.GameState) {
 	g.Lock()
-	defer g.Unlock()
+	if g.ended {
+		g.Unlock()
+		return
+	}
+	g.ended = true
+	g.pendingActions = make(map[int64]i.Action)
+	g.Unlock()
+
+	g.record(gs, nil)
+	_ = g.Stop()
+	if g.onGameEnd != nil {
+		g.onGameEnd(gs)
+	}
+}
 
-	for _, player := range g.gameState.RetrivePlayers() {
+// record persists a state or an action when a recorder is set. Recording errors never interrupt the game.
+func (g *GameServer) record(gs i.GameState, action i.Action) {
+	if g.recorder == nil {
+		return
+	}
+
+	if gs != nil {
+		_ = g.recorder.RecordState(gs)
+	}
+	if action != nil {
+		_ = g.recorder.RecordAction(action)
+	}
+}
+
+// reconcile rebuilds the predicted state from the authoritative one. Actions acknowledged
+// by the server are dropped and the remaining ones are replayed in sequence order; actions
+// that are no longer valid on the new state are discarded. The caller must hold the lock.
+//
+// Until the server echoes the sequence of an action, it may not echo them at all and the state may
+// already include the pending actions, so they are dropped instead of being applied twice.
+func (g *GameServer) reconcile() {
+	if player := g.localPlayer(g.gameState); player != nil && player.GetLastActionSeq() > 0 {
+		g.acknowledged = true
+		for seq := range g.pendingActions {
+			if seq <= player.GetLastActionSeq() {
+				delete(g.pendingActions, seq)
+			}
+		}
+	}
+	if !g.acknowledged {
+		clear(g.pendingActions)
+	}
+
+	predicted, err := g.cloneState(g.gameState)
+	if err != nil {
+		g.predictedState = g.gameState
+		return
+	}
+
+	for _, seq := range g.pendingSeqs() {
+		if !g.applyAction(predicted, g.pendingActions[seq]) {
+			delete(g.pendingActions, seq)
+		}
+	}
+	g.predictedState = predicted
+}
+
+// applyAction moves the local player of gs following the maze wall rules and collects the reward.
+// It reports whether the move was valid.
+func (g *GameServer) applyAction(gs i.GameState, action i.Action) bool {
+	player := g.localPlayer(gs)
+	if player == nil || gs.RetriveMaze() == nil {
+		return false
+	}
+
+	maze := gs.RetriveMaze()
+	move, err := maze.NewValidMove(player.RetrivePos(), action.GetDirection())
+	if err != nil {
+		return false
+	}
+
+	reward, err := maze.Move(move)
+	if err != nil {
+		return false
+	}
+
+	player.SetPos(move.To())
+	player.SetReward(player.GetReward() + reward)
+	return true
+}
+
+// dropOldActions keeps at most maxPendingActions in the buffer, dropping the oldest ones.
+// The caller must hold the lock.
+func (g *GameServer) dropOldActions() {
+	seqs := g.pendingSeqs()
+	for len(seqs) > maxPendingActions {
+		delete(g.pendingActions, seqs[0])
+		seqs = seqs[1:]
+	}
+}
+
+// pendingSeqs returns the sequences of the pending actions in ascending order.
+func (g *GameServer) pendingSeqs() []int64 {
+	seqs := make([]int64, 0, len(g.pendingActions))
+	for seq := range g.pendingActions {
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs
+}
+
+// cloneState returns a deep copy of gs, so published states are never modified.
+func (g *GameServer) cloneState(gs i.GameState) (i.GameState, error) {
+	payload, err := g.encoder.MarshalGameState(gs)
+	if err != nil {
+		return nil, err
+	}
+	return g.encoder.UnmarshalGameState(payload)
+}
+
+func (g *GameServer) handlePingResponse(stats i.NetworkStats) {
+	g.clock.Observe(stats)
+	if g.onPingResult != nil {
+		g.onPingResult(stats)
+	}
+}
+
+// ServerNow implements i.ServerClock with the time of the server estimated from the pongs.
+func (g *GameServer) ServerNow() time.Time {
+	return g.clock.ServerNow()
+}
+
+func (g *GameServer) handleConnectionStateChange(s i.ConnectionState) {
+	if g.onConnChange != nil {
+		g.onConnChange(s)
+	}
+}
+
+// localPlayer returns the player of this client in gs.
+func (g *GameServer) localPlayer(gs i.GameState) i.Player {
+	for _, player := range gs.RetrivePlayers() {
 		if player.GetID() == g.playerID {
-			return player.RetrivePos()
+			return player
 		}
 	}
-	return nil // code will no reach this; or at least I hope it does not
+	return nil
 }
 
 func (g *GameServer) SetOnStateChange(f func(i.GameState)) {
 	g.onStateChange = f
 }
 
-func (g *GameServer) SetOnPingResult(f func(int64)) {
+func (g *GameServer) SetOnPingResult(f func(i.NetworkStats)) {
 	g.onPingResult = f
 }
+
+func (g *GameServer) SetOnConnectionStateChange(f func(i.ConnectionState)) {
+	g.onConnChange = f
+}
+
+func (g *GameServer) SetOnGameEnd(f func(i.GameState)) {
+	g.onGameEnd = f
+}
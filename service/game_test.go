@@ -0,0 +1,282 @@
+package service
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	gamepb "github.com/sofc-t/puzzle-client/infrastruture/pb_encoder/game"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// fakeConnection is an i.ClientManager recording the messages sent to the server.
+type fakeConnection struct {
+	sent             [][]byte
+	disconnects      int
+	onServerResponse func(byte, []byte)
+	onPingResult     func(i.NetworkStats)
+	mu               sync.Mutex
+}
+
+func (f *fakeConnection) Connect([]byte) error { return nil }
+func (f *fakeConnection) Disconnect()          { f.disconnects++ }
+func (f *fakeConnection) SendToServer(t byte, message []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, message)
+	return nil
+}
+func (f *fakeConnection) SetOnServerResponse(fn func(byte, []byte))          { f.onServerResponse = fn }
+func (f *fakeConnection) SetOnPingResult(fn func(i.NetworkStats))            { f.onPingResult = fn }
+func (f *fakeConnection) SetOnConnectionStateChange(func(i.ConnectionState)) {}
+func (f *fakeConnection) Stats() i.RecordStats                               { return i.RecordStats{} }
+func (f *fakeConnection) NetworkStats() i.NetworkStats                       { return i.NetworkStats{} }
+
+var testPlayerID = uuid.MustParse("7f1b4c9e-3f57-4a51-9d0b-2c9a35c6a001")
+
+// newTestState returns a state of a 1x4 corridor without inner walls and the local player at col.
+func newTestState(version int64, col int32, reward int32, lastActionSeq int64) []byte {
+	row := &gamepb.Maze_Row{}
+	for c := 0; c < 4; c++ {
+		row.Cells = append(row.Cells, &gamepb.Cell{NorthWall: true, SouthWall: true, WestWall: c == 0, EastWall: c == 3, Reward: 1})
+	}
+
+	state := &gamepb.GameState{
+		Version: version,
+		Maze:    &gamepb.Maze{Grid: []*gamepb.Maze_Row{row}},
+		Players: []*gamepb.Player{{
+			Id:            testPlayerID.String(),
+			Pos:           &gamepb.Pos{Row: 0, Col: col},
+			Reward:        reward,
+			LastActionSeq: lastActionSeq,
+		}},
+	}
+	payload, err := (&gamepb.Protobuf{}).MarshalGameState(state)
+	if err != nil {
+		panic(err)
+	}
+	return payload
+}
+
+func newTestGameServer(t *testing.T) (*GameServer, *fakeConnection, *[]i.GameState) {
+	conn := &fakeConnection{}
+	server, err := NewGameServer(&GameServerConfig{
+		ServerConnection: conn,
+		Encoder:          &gamepb.Protobuf{},
+		PlayerID:         testPlayerID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	states := &[]i.GameState{}
+	server.SetOnStateChange(func(gs i.GameState) { *states = append(*states, gs) })
+	return server.(*GameServer), conn, states
+}
+
+func playerCol(gs i.GameState) int32 {
+	return gs.RetrivePlayers()[0].RetrivePos().GetCol()
+}
+
+// TestGameServerPrediction contains all the tests related to client-side prediction and reconciliation.
+func TestGameServerPrediction(t *testing.T) {
+	t.Run("Prediction_AppliesMoveLocally", testPrediction_AppliesMoveLocally)
+	t.Run("Prediction_BlockedMoveIsNotSent", testPrediction_BlockedMoveIsNotSent)
+	t.Run("Prediction_ReplaysPendingActions", testPrediction_ReplaysPendingActions)
+	t.Run("Prediction_DropsStaleStates", testPrediction_DropsStaleStates)
+	t.Run("Prediction_NonEchoingServer", testPrediction_NonEchoingServer)
+	t.Run("Prediction_KeepsFogOfWar", testPrediction_KeepsFogOfWar)
+	t.Run("Prediction_PublishesUnlocked", testPrediction_PublishesUnlocked)
+}
+
+// testPrediction_AppliesMoveLocally tests that a move is rendered before the server answers.
+func testPrediction_AppliesMoveLocally(t *testing.T) {
+	server, conn, states := newTestGameServer(t)
+	conn.onServerResponse(gameStateRecordType, newTestState(1, 0, 0, 0))
+
+	server.Move(i.East)
+
+	if len(conn.sent) != 1 {
+		t.Fatalf("Expected one action sent, got: %d", len(conn.sent))
+	}
+
+	last := (*states)[len(*states)-1]
+	if playerCol(last) != 1 || last.RetrivePlayers()[0].GetReward() != 1 {
+		t.Errorf("Expected predicted player at col 1 with reward 1, got col %d reward %d", playerCol(last), last.RetrivePlayers()[0].GetReward())
+	}
+
+	if playerCol(server.gameState) != 0 {
+		t.Errorf("Expected authoritative state to be untouched, got col: %d", playerCol(server.gameState))
+	}
+}
+
+// testPrediction_BlockedMoveIsNotSent tests that moves into a wall are neither predicted nor sent.
+func testPrediction_BlockedMoveIsNotSent(t *testing.T) {
+	server, conn, states := newTestGameServer(t)
+	conn.onServerResponse(gameStateRecordType, newTestState(1, 0, 0, 0))
+
+	server.Move(i.West)
+	server.Move(i.North)
+
+	if len(conn.sent) != 0 || len(*states) != 1 || len(server.pendingActions) != 0 {
+		t.Errorf("Expected blocked moves to be ignored, got %d sent and %d states", len(conn.sent), len(*states))
+	}
+}
+
+// testPrediction_ReplaysPendingActions tests that unacknowledged actions are replayed on a newer state.
+func testPrediction_ReplaysPendingActions(t *testing.T) {
+	server, conn, states := newTestGameServer(t)
+	conn.onServerResponse(gameStateRecordType, newTestState(1, 0, 0, 0))
+
+	server.Move(i.East)
+	server.Move(i.East)
+
+	// The server processed the first action only.
+	conn.onServerResponse(gameStateRecordType, newTestState(2, 1, 1, 1))
+
+	last := (*states)[len(*states)-1]
+	if playerCol(last) != 2 {
+		t.Errorf("Expected second action to be replayed to col 2, got col: %d", playerCol(last))
+	}
+
+	if len(server.pendingActions) != 1 || server.pendingActions[2] == nil {
+		t.Errorf("Expected only action 2 to be pending, got: %v", server.pendingActions)
+	}
+
+	// The server processed both actions.
+	conn.onServerResponse(gameStateRecordType, newTestState(3, 2, 2, 2))
+	if len(server.pendingActions) != 0 {
+		t.Errorf("Expected no pending action, got: %v", server.pendingActions)
+	}
+}
+
+// testPrediction_DropsStaleStates tests that states with an older version are ignored.
+func testPrediction_DropsStaleStates(t *testing.T) {
+	_, conn, states := newTestGameServer(t)
+	conn.onServerResponse(gameStateRecordType, newTestState(5, 3, 0, 0))
+	conn.onServerResponse(gameStateRecordType, newTestState(4, 0, 0, 0))
+
+	if len(*states) != 1 || playerCol((*states)[0]) != 3 {
+		t.Errorf("Expected stale state to be ignored, got %d states", len(*states))
+	}
+}
+
+// testPrediction_NonEchoingServer tests that the actions are not applied twice by a server that never echoes
+// their sequence, and that they do not pile up.
+func testPrediction_NonEchoingServer(t *testing.T) {
+	server, conn, states := newTestGameServer(t)
+	conn.onServerResponse(gameStateRecordType, newTestState(1, 0, 0, 0))
+
+	server.Move(i.East)
+	conn.onServerResponse(gameStateRecordType, newTestState(2, 1, 1, 0))
+	server.Move(i.East)
+	conn.onServerResponse(gameStateRecordType, newTestState(3, 2, 2, 0))
+
+	if last := (*states)[len(*states)-1]; playerCol(last) != 2 {
+		t.Errorf("Expected the moves applied once to col 2, got col: %d", playerCol(last))
+	}
+	if len(server.pendingActions) != 0 {
+		t.Errorf("Expected no pending action, got: %v", server.pendingActions)
+	}
+}
+
+// testPrediction_KeepsFogOfWar tests that the fog-of-war mode signaled by the server is kept by the published states,
+// the authoritative and the predicted ones.
+func testPrediction_KeepsFogOfWar(t *testing.T) {
+	server, conn, states := newTestGameServer(t)
+
+	encoder := &gamepb.Protobuf{}
+	state, _ := encoder.UnmarshalGameState(newTestState(1, 0, 0, 0))
+	state.SetFogOfWar(true)
+	payload, _ := encoder.MarshalGameState(state)
+	conn.onServerResponse(gameStateRecordType, payload)
+
+	server.Move(i.East)
+
+	if len(*states) != 2 {
+		t.Fatalf("Expected the authoritative and the predicted states, got: %d", len(*states))
+	}
+	for _, published := range *states {
+		if !published.GetFogOfWar() {
+			t.Errorf("Expected the published state to keep the fog of war")
+		}
+	}
+}
+
+// testPrediction_PublishesUnlocked tests that the states of the server are published without holding the lock,
+// so the state callback may move the player.
+func testPrediction_PublishesUnlocked(t *testing.T) {
+	server, conn, _ := newTestGameServer(t)
+	moved := false
+	server.SetOnStateChange(func(i.GameState) {
+		if !moved {
+			moved = true
+			server.Move(i.East)
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		conn.onServerResponse(gameStateRecordType, newTestState(1, 0, 0, 0))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the state to be published without holding the lock")
+	}
+	if len(conn.sent) != 1 {
+		t.Errorf("Expected the move of the callback to be sent, got: %d", len(conn.sent))
+	}
+}
+
+// TestGameServerEnd contains all the tests related to the end of the game.
+func TestGameServerEnd(t *testing.T) {
+	t.Run("GameEnd_HandledOnce", testGameEnd_HandledOnce)
+}
+
+// testGameEnd_HandledOnce tests that the end of the game tears down the session and hands the final state over once,
+// whatever the server or the player does afterwards.
+func testGameEnd_HandledOnce(t *testing.T) {
+	tests := []struct {
+		name  string
+		after func(*GameServer, *fakeConnection)
+	}{
+		{"Nothing", func(*GameServer, *fakeConnection) {}},
+		{"RepeatedEnd", func(_ *GameServer, conn *fakeConnection) {
+			conn.onServerResponse(gameEndedRecordType, newTestState(3, 2, 2, 0))
+		}},
+		{"StateAfterEnd", func(_ *GameServer, conn *fakeConnection) {
+			conn.onServerResponse(gameStateRecordType, newTestState(4, 2, 2, 0))
+		}},
+		{"MoveAfterEnd", func(server *GameServer, _ *fakeConnection) { server.Move(i.East) }},
+	}
+
+	for _, test := range tests {
+		server, conn, states := newTestGameServer(t)
+		var ended []i.GameState
+		server.SetOnGameEnd(func(gs i.GameState) { ended = append(ended, gs) })
+		conn.onServerResponse(gameStateRecordType, newTestState(1, 0, 0, 0))
+		server.Move(i.East)
+		conn.onServerResponse(gameEndedRecordType, newTestState(2, 1, 1, 0))
+		published, sent := len(*states), len(conn.sent)
+
+		test.after(server, conn)
+
+		if len(ended) != 1 || playerCol(ended[0]) != 1 {
+			t.Errorf("%s: expected the final state handed over once, got: %d", test.name, len(ended))
+		}
+		if conn.disconnects != 1 {
+			t.Errorf("%s: expected the session torn down once, got: %d", test.name, conn.disconnects)
+		}
+		if len(server.pendingActions) != 0 {
+			t.Errorf("%s: expected no pending action, got: %d", test.name, len(server.pendingActions))
+		}
+		if len(*states) != published || len(conn.sent) != sent {
+			t.Errorf("%s: expected nothing published or sent after the end, got %d states and %d actions",
+				test.name, len(*states)-published, len(conn.sent)-sent)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package i
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Errors of the HttpRequester, matched with errors.Is against an APIError by its status code.
+var (
+	ErrUnauthorized      = errors.New("unauthorized")
+	ErrNotFound          = errors.New("not found")
+	ErrConflict          = errors.New("conflict")
+	ErrRateLimited       = errors.New("rate limited")
+	ErrServerUnavailable = errors.New("server unavailable") // Also wraps the requests that did not reach the server.
+)
+
+// APIError is the error of a request the server answered with an error status.
+// Its JSON form is the error body of the server.
+type APIError struct {
+	StatusCode int           `json:"-"`
+	Code       string        `json:"code,omitempty"` // Code is the server's error code, empty when the body is not a JSON error.
+	Message    string        `json:"message"`        // Message is the server's message, or the text of the body.
+	RetryAfter time.Duration `json:"-"`              // RetryAfter is the delay the server asked for before retrying, 0 when unset.
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("request failed with status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+// Is matches the sentinel error of the status code.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServerUnavailable:
+		return e.StatusCode == http.StatusBadGateway || e.StatusCode == http.StatusServiceUnavailable ||
+			e.StatusCode == http.StatusGatewayTimeout
+	}
+	return false
+}
@@ -5,4 +5,20 @@ import "github.com/sofc-t/puzzle-client/dmn"
 type AuthServer interface {
 	Login(username string, password string) (*dmn.Player, string, error)
 	Register(username string, password string) error
+
+	// Token returns the access token of the session, refreshed beforehand when it is about to expire.
+	Token() (string, error)
+	// Remember persists the session so that Resume restores it on the next launch.
+	Remember() error
+	// Resume restores the persisted session, refreshing its token when needed.
+	Resume() (*dmn.Player, string, error)
+	// Logout revokes the session on the server and forgets the persisted one.
+	Logout() error
+}
+
+// CredentialStore persists the credentials of a remembered session.
+type CredentialStore interface {
+	Load() (*dmn.Credentials, error)
+	Save(*dmn.Credentials) error
+	Clear() error
 }
@@ -1,5 +1,88 @@
 package i
 
+import "time"
+
+// ConnectionState describes the health of the session between the client and the server.
+type ConnectionState int
+
+const (
+	ConnectionConnected    ConnectionState = iota // The handshake succeeded and pongs are arriving.
+	ConnectionDegraded                            // Pongs are being missed but the session is not considered dead yet.
+	ConnectionReconnecting                        // The session was declared dead and a new handshake is in progress.
+	ConnectionLost                                // Every reconnection attempt failed.
+)
+
+// String returns a human readable name of the connection state.
+func (s ConnectionState) String() string {
+	switch s {
+	case ConnectionConnected:
+		return "connected"
+	case ConnectionDegraded:
+		return "degraded"
+	case ConnectionReconnecting:
+		return "reconnecting"
+	case ConnectionLost:
+		return "lost"
+	default:
+		return "unknown"
+	}
+}
+
+// RecordStats holds counters of the records exchanged with the server, exposed for diagnostics.
+type RecordStats struct {
+	Sent       uint64 // Records sent to the server.
+	Received   uint64 // Records authenticated and accepted.
+	Dropped    uint64 // Records that were malformed, failed decryption or fell behind the replay window.
+	Duplicates uint64 // Records rejected because their sequence number was already seen.
+	OutOfOrder uint64 // Accepted records that arrived after a record with a higher sequence number.
+}
+
+// NetworkStats summarizes the pings exchanged with the server, exposed for diagnostics.
+type NetworkStats struct {
+	RTT     time.Duration   // RTT of the last answered ping, measured on the local clock without the server's processing time.
+	Offset  time.Duration   // Offset of the server clock from the local one, estimated NTP-style from the last exchange.
+	Jitter  time.Duration   // Jitter is the smoothed variation between consecutive RTTs, as in RFC 3550.
+	Loss    float64         // Loss is the fraction of the recent pings left unanswered, from 0 to 1.
+	History []time.Duration // History holds the RTT of the recent answered pings, oldest first.
+
+	// MeasuredAt is the local time the last pong was received, zero before the first one.
+	MeasuredAt time.Time
+}
+
+// ConnectionQuality grades the latency and the losses of a connection.
+type ConnectionQuality int
+
+const (
+	QualityGood ConnectionQuality = iota
+	QualityFair
+	QualityPoor
+)
+
+// String returns a human readable name of the connection quality.
+func (q ConnectionQuality) String() string {
+	switch q {
+	case QualityGood:
+		return "good"
+	case QualityFair:
+		return "fair"
+	case QualityPoor:
+		return "poor"
+	default:
+		return "unknown"
+	}
+}
+
+// Quality grades the stats by their worst measure.
+func (s NetworkStats) Quality() ConnectionQuality {
+	switch {
+	case s.Loss >= 0.1 || s.RTT >= 250*time.Millisecond || s.Jitter >= 50*time.Millisecond:
+		return QualityPoor
+	case s.Loss > 0.02 || s.RTT >= 100*time.Millisecond || s.Jitter >= 20*time.Millisecond:
+		return QualityFair
+	}
+	return QualityGood
+}
+
 // ClientManager defines the interface for the UDP client socket manager.
 type ClientManager interface {
 	// Connect establishes the initial handshake with the server.
@@ -14,6 +97,15 @@ type ClientManager interface {
 	// SetOnServerResponse updates onServerResponse func.
 	SetOnServerResponse(f func(byte, []byte))
 
-	// SetOnPingResult updates onServerResponse func.
-	SetOnPingResult(f func(int64))
+	// SetOnPingResult updates the func called with the network stats whenever a pong is received.
+	SetOnPingResult(f func(NetworkStats))
+
+	// SetOnConnectionStateChange updates the func called whenever the connection state changes.
+	SetOnConnectionStateChange(f func(ConnectionState))
+
+	// Stats returns a snapshot of the record counters.
+	Stats() RecordStats
+
+	// NetworkStats returns a snapshot of the latency and the losses measured by the pings.
+	NetworkStats() NetworkStats
 }
@@ -7,6 +7,16 @@ type Symmetric interface {
 	Decrypt(c []byte, key []byte) ([]byte, error)
 }
 
+// The authenticated symmetric cryptography interface
+// Besides encrypting the message it authenticates associated data, like record headers, that is sent in the clear.
+// Suite identifies the algorithm during the cipher suite negotiation of the handshake
+type AEAD interface {
+	Symmetric
+	Suite() uint32
+	Seal(p []byte, key []byte, ad []byte) ([]byte, error)
+	Open(c []byte, key []byte, ad []byte) ([]byte, error)
+}
+
 // The asymmetric cryptography interface
 // This type of cryptography uses to decrypt the encrypted message from the client in the handshaking process
 type Asymmetric interface {
@@ -15,6 +25,16 @@ type Asymmetric interface {
 	GetPublicKey() []byte
 }
 
+// The session key management interface
+// It provides the symmetric key of the current connection, replaces it on every new handshake and erases it once the connection is closed.
+// KeyShare returns the public value the server derives the key from, or nil when the key itself is sent in the client hello
+type KeyManager interface {
+	Key() []byte
+	KeyShare() []byte
+	Rotate() error
+	Zero()
+}
+
 type HMAC interface {
 	Sign([]byte, ...[]byte) []byte
 	Compare([]byte, []byte) bool
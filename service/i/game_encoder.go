@@ -2,6 +2,14 @@ package i
 
 import "github.com/google/uuid"
 
+// Directions of a move, as used by Maze.NewValidMove and Action.
+const (
+	North = "North"
+	South = "South"
+	East  = "East"
+	West  = "West"
+)
+
 // Cell represents a cell in the maze with walls and rewards.
 type Cell interface {
 	HasNorthWall() bool
@@ -40,6 +48,10 @@ type Player interface {
 	SetPos(CellPosition)
 	GetReward() int32
 	SetReward(int32)
+	GetLastActionSeq() int64
+	SetLastActionSeq(int64)
+	GetRatingChange() int32
+	SetRatingChange(int32)
 }
 
 // Maze represents a maze structure with cells, dimensions, and actions.
@@ -70,6 +82,8 @@ type Action interface {
 	SetDirection(string)
 	RetriveFrom() CellPosition
 	SetFrom(CellPosition)
+	GetSeq() int64
+	SetSeq(int64)
 }
 
 // TODO: include time left!!!
@@ -81,6 +95,12 @@ type GameState interface {
 	SetMaze(Maze)
 	RetrivePlayers() []Player
 	SetPlayers([]Player)
+	GetStartedAt() int64
+	SetStartedAt(int64)
+	GetEndedAt() int64
+	SetEndedAt(int64)
+	GetFogOfWar() bool // GetFogOfWar reports whether the players only see the cells they explored.
+	SetFogOfWar(bool)
 }
 
 type GameEncoder interface {
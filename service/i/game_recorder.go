@@ -0,0 +1,32 @@
+package i
+
+// GameRecorder persists the game states received and the actions sent during a match.
+type GameRecorder interface {
+	RecordState(GameState) error
+	RecordAction(Action) error
+	Close() error
+}
+
+// ReplayFrame is a recorded game state or local action with the time it was recorded at, in milliseconds.
+type ReplayFrame interface {
+	GetRecordedAt() int64
+	RetriveState() GameState // RetriveState returns nil for action frames.
+	RetriveAction() Action   // RetriveAction returns nil for state frames.
+}
+
+// ReplayStatus describes the playback position of a replay.
+type ReplayStatus struct {
+	Position int64   // Position is the playback time since the first frame, in milliseconds.
+	Duration int64   // Duration is the time between the first and the last frame, in milliseconds.
+	Speed    float64 // Speed is the playback speed multiplier.
+	Paused   bool
+}
+
+// ReplayController controls the playback of a recorded match.
+type ReplayController interface {
+	TogglePause()
+	Step(frames int)
+	Skip(offset int64)
+	SetSpeed(speed float64)
+	Status() ReplayStatus
+}
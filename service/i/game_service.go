@@ -1,8 +1,24 @@
 package i
 
+import "time"
+
 type GameServer interface {
 	Move(string)
 	Start([]byte) error
+	Stop() error
+	SetOnGameEnd(f func(GameState))
 	SetOnStateChange(f func(GameState))
-	SetOnPingResult(f func(int64))
+	SetOnPingResult(f func(NetworkStats))
+	SetOnConnectionStateChange(f func(ConnectionState))
+}
+
+// TimedGame is implemented by game servers whose match ends when a timer runs out.
+type TimedGame interface {
+	TimeLeft() time.Duration
+}
+
+// ServerClock is implemented by the components estimating the time of the server, so timers, deadlines and
+// replays share its timebase.
+type ServerClock interface {
+	ServerNow() time.Time
 }
@@ -1,9 +1,28 @@
 package i
 
-import "io"
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotEventStream is returned by Subscribe when the server answers with anything but an event stream.
+var ErrNotEventStream = errors.New("server did not answer with an event stream")
 
 // HttpRequester defines an interface for HTTP requests.
+// The requests are abandoned once ctx is done, failing with the error of ctx.
 type HttpRequester interface {
-	Post(uri string, body io.Reader, authToken string) (io.Reader, error)
-	Get(uri, authToken string) (io.Reader, error)
+	Post(ctx context.Context, uri string, body io.Reader, authToken string) (io.Reader, error)
+	Get(ctx context.Context, uri, authToken string) (io.Reader, error)
+	Delete(ctx context.Context, uri, authToken string) (io.Reader, error)
+
+	// Subscribe opens a stream of events pushed by the server, failing when the server does not stream the uri.
+	// The channel is closed when the stream ends or ctx is done.
+	Subscribe(ctx context.Context, uri, authToken string) (<-chan ServerEvent, error)
+}
+
+// ServerEvent is an event pushed by the server on a stream.
+type ServerEvent struct {
+	Name string // Name is the type of the event, "message" when the server did not name it.
+	Data string
 }
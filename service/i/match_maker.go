@@ -1,9 +1,14 @@
 package i
 
 import (
+	"context"
+
 	"github.com/google/uuid"
+	"github.com/sofc-t/puzzle-client/dmn"
 )
 
 type MatchMaker interface {
-	Match(ID uuid.UUID, token string) ([]byte, string, error)
+	// Match queues the player and waits for a match until ctx is done, reporting the search to onProgress when not nil.
+	// The player is removed from the queue when ctx is done before a match is found.
+	Match(ctx context.Context, ID uuid.UUID, token string, onProgress func(dmn.MatchProgress)) (*dmn.Match, error)
 }
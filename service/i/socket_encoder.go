@@ -11,8 +11,16 @@ type HandshakeRecord interface {
 	SetToken([]byte)
 	GetKey() []byte
 	SetKey([]byte)
+	GetKeyShare() []byte
+	SetKeyShare([]byte)
 	GetTimestamp() int64
 	SetTimestamp(int64)
+	GetTicket() []byte
+	SetTicket([]byte)
+	GetCipherSuites() []uint32
+	SetCipherSuites([]uint32)
+	GetCipherSuite() uint32
+	SetCipherSuite(uint32)
 }
 
 type PingRecord interface {
@@ -0,0 +1,9 @@
+package i
+
+import "github.com/google/uuid"
+
+// Strategy picks the moves of a headless bot.
+type Strategy interface {
+	// NextMove returns the direction the player should move to in gs, false when there is no move to make.
+	NextMove(gs GameState, playerID uuid.UUID) (string, bool)
+}
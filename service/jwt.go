@@ -0,0 +1,36 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// tokenExpiry returns the expiry of a JWT from its exp claim. The signature is left to the server to verify, the
+// client only schedules the refresh with it. It reports false for opaque tokens and tokens without expiry.
+func tokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var claims struct {
+		Exp *float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(*claims.Exp), 0), true
+}
+
+// expiresWithin reports whether the token expires in less than d. Tokens without a known expiry never expire.
+func expiresWithin(token string, d time.Duration) bool {
+	expiry, ok := tokenExpiry(token)
+	return ok && time.Until(expiry) < d
+}
@@ -2,75 +2,305 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math/rand"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/sofc-t/puzzle-client/dmn"
 	"github.com/sofc-t/puzzle-client/service/i"
 )
 
+const (
+	defaultMatchTimeout         = time.Minute
+	defaultMatchPollInterval    = 500 * time.Millisecond
+	defaultMatchMaxPollInterval = 8 * time.Second
+	matchProgressInterval       = time.Second // The elapsed time is reported between two updates of the server.
+
+	matchEventsPath = "/events" // The match events of a player are streamed under its match info URI.
+	matchEventName  = "match"   // A match event carries a MatchInfoResponse.
+)
+
+var ErrMatchTimeout = errors.New("match request timeout")
+
+// pushSupport is what the capability probe learnt of the server's match events.
+type pushSupport int
+
+const (
+	pushUnknown pushSupport = iota
+	pushSupported
+	pushUnsupported
+)
+
 type MatchMaking struct {
-	httpClient i.HttpRequester
-	matchUri   string
+	httpClient      i.HttpRequester
+	matchUri        string
+	timeout         time.Duration
+	pollInterval    time.Duration
+	maxPollInterval time.Duration
+	push            pushSupport
+	clock           i.ServerClock
+	mu              sync.Mutex
 }
 
 type MatchMakingConfig struct {
-	HttpClient i.HttpRequester
-	MatchUri   string
+	HttpClient      i.HttpRequester
+	MatchUri        string
+	Timeout         time.Duration // Timeout gives up the search, defaults to 1 minute.
+	PollInterval    time.Duration // PollInterval is the delay before the second poll, defaults to 500ms.
+	MaxPollInterval time.Duration // MaxPollInterval caps the delay doubled after every poll, defaults to 8s.
+	Clock           i.ServerClock // Clock tells the server time the requests are sent at, the local time by default.
 }
 
 func NewMatchMaking(mc MatchMakingConfig) (*MatchMaking, error) {
-	return &MatchMaking{
-		httpClient: mc.HttpClient,
-		matchUri:   mc.MatchUri,
-	}, nil
+	mm := &MatchMaking{
+		httpClient:      mc.HttpClient,
+		matchUri:        mc.MatchUri,
+		timeout:         mc.Timeout,
+		pollInterval:    mc.PollInterval,
+		maxPollInterval: mc.MaxPollInterval,
+		clock:           mc.Clock,
+	}
+	if mm.clock == nil {
+		mm.clock = NewClockSync()
+	}
+	if mm.timeout <= 0 {
+		mm.timeout = defaultMatchTimeout
+	}
+	if mm.pollInterval <= 0 {
+		mm.pollInterval = defaultMatchPollInterval
+	}
+	if mm.maxPollInterval < mm.pollInterval {
+		mm.maxPollInterval = max(defaultMatchMaxPollInterval, mm.pollInterval)
+	}
+	return mm, nil
 }
 
-func (mm *MatchMaking) Match(ID uuid.UUID, token string) ([]byte, string, error) {
-	sentAt := time.Now().UnixNano() / int64(time.Millisecond)
-	body := MatchRequest{ID: ID, SentAt: sentAt}
+// Match queues the player and waits for its match until ctx is done or the timeout expires.
+// The match is pushed by the server when it streams match events, otherwise it is polled with an exponential backoff.
+// The progress is reported on every update of the server and every second in between.
+// The request tells the server time it is sent at, the durations of the search are measured on the local clock.
+func (mm *MatchMaking) Match(ctx context.Context, ID uuid.UUID, token string, onProgress func(dmn.MatchProgress)) (*dmn.Match, error) {
+	search := newMatchSearch(onProgress)
+	body := MatchRequest{ID: ID, SentAt: mm.clock.ServerNow().UnixNano() / int64(time.Millisecond)}
 
 	payload, err := json.Marshal(body)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
-	_, err = mm.httpClient.Post(mm.matchUri, bytes.NewReader(payload), token)
+	_, err = mm.httpClient.Post(ctx, mm.matchUri, bytes.NewReader(payload), token)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
-	maxTime := time.NewTimer(time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, mm.timeout)
+	defer cancel()
+
 	matchInfoUri := fmt.Sprintf("%s/%s", mm.matchUri, ID)
+	if match, ok := mm.waitPush(ctx, search, matchInfoUri, token); ok {
+		return match, nil
+	}
+	if ctx.Err() != nil {
+		return nil, mm.withdraw(ctx, matchInfoUri, token)
+	}
+	return mm.waitPoll(ctx, search, matchInfoUri, token)
+}
+
+// waitPush waits for the match on the event stream of the server. It reports false when the server does not stream
+// match events, when the stream ends before the match is found or when ctx is done.
+// The subscriptions probe the server until it answers: a server telling it does not stream match events is polled
+// for the next searches, other errors only fall back to polling for this search.
+func (mm *MatchMaking) waitPush(ctx context.Context, search *matchSearch, matchInfoUri, token string) (*dmn.Match, bool) {
+	mm.mu.Lock()
+	push := mm.push
+	mm.mu.Unlock()
+	if push == pushUnsupported {
+		return nil, false
+	}
+
+	events, err := mm.httpClient.Subscribe(ctx, matchInfoUri+matchEventsPath, token)
+	if err != nil {
+		if pushUnsupportedBy(err) {
+			mm.mu.Lock()
+			mm.push = pushUnsupported
+			mm.mu.Unlock()
+		}
+		return nil, false
+	}
+	if push == pushUnknown {
+		mm.mu.Lock()
+		mm.push = pushSupported
+		mm.mu.Unlock()
+	}
+
+	progressTicker := time.NewTicker(matchProgressInterval)
+	defer progressTicker.Stop()
+
 	for {
 		select {
-		case <-maxTime.C:
-			return nil, "", errors.New("Match Request Timeout.")
-		default:
-			response, err := mm.httpClient.Get(matchInfoUri, token)
-			if err != nil {
-				time.Sleep(2 * time.Second)
-			} else {
-				return parseInfoResponse(response)
+		case <-ctx.Done():
+			return nil, false
+		case now := <-progressTicker.C:
+			search.report(now)
+		case event, ok := <-events:
+			if !ok {
+				return nil, false
+			}
+			if event.Name != matchEventName {
+				continue
+			}
+
+			var info MatchInfoResponse
+			if err := json.Unmarshal([]byte(event.Data), &info); err != nil {
+				continue
+			}
+			if match := search.update(&info); match != nil {
+				return match, true
+			}
+		}
+	}
+}
+
+// pushUnsupportedBy reports whether the error of a subscription tells the server does not stream match events.
+// Other errors, such as a rejected token or an unavailable server, may be gone by the next search.
+func pushUnsupportedBy(err error) bool {
+	var apiErr *i.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+			return true
+		}
+	}
+	return errors.Is(err, i.ErrNotEventStream)
+}
+
+// waitPoll polls for the match with an exponential backoff until it is found or ctx is done.
+func (mm *MatchMaking) waitPoll(ctx context.Context, search *matchSearch, matchInfoUri, token string) (*dmn.Match, error) {
+	progressTicker := time.NewTicker(matchProgressInterval)
+	defer progressTicker.Stop()
+	poll := time.NewTimer(0)
+	defer poll.Stop()
+
+	for attempt := 0; ; {
+		select {
+		case <-ctx.Done():
+			return nil, mm.withdraw(ctx, matchInfoUri, token)
+		case now := <-progressTicker.C:
+			search.report(now)
+		case <-poll.C:
+			info, err := mm.pollInfo(ctx, matchInfoUri, token)
+			if ctx.Err() != nil {
+				continue
+			}
+
+			// A rejected token fails every poll, other errors, such as a not found match, are retried until the timeout.
+			if errors.Is(err, i.ErrUnauthorized) {
+				return nil, err
+			}
+			if err == nil {
+				if match := search.update(info); match != nil {
+					return match, nil
+				}
 			}
+
+			poll.Reset(mm.pollDelay(attempt))
+			attempt++
 		}
 	}
 }
 
-func parseInfoResponse(response io.Reader) ([]byte, string, error) {
+// withdraw removes the player from the queue once ctx is done and returns why the search stopped.
+func (mm *MatchMaking) withdraw(ctx context.Context, matchInfoUri, token string) error {
+	reason := ctx.Err()
+	if errors.Is(reason, context.DeadlineExceeded) {
+		reason = ErrMatchTimeout
+	}
+
+	// ctx is done, the withdrawal is only bound by the timeout of the requester.
+	if _, err := mm.httpClient.Delete(context.Background(), matchInfoUri, token); err != nil {
+		return errors.Join(reason, err)
+	}
+	return reason
+}
+
+// pollDelay returns the delay following the given poll attempt: the poll interval doubled after every attempt up to the
+// maximum, with a random jitter of up to half of it so that the queued clients do not poll in lockstep.
+func (mm *MatchMaking) pollDelay(attempt int) time.Duration {
+	delay := mm.pollInterval
+	for n := 0; n < attempt && delay < mm.maxPollInterval; n++ {
+		delay *= 2
+	}
+	delay = min(delay, mm.maxPollInterval)
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func (mm *MatchMaking) pollInfo(ctx context.Context, matchInfoUri, token string) (*MatchInfoResponse, error) {
+	response, err := mm.httpClient.Get(ctx, matchInfoUri, token)
+	if err != nil {
+		return nil, err
+	}
+	return parseInfoResponse(response)
+}
+
+func parseInfoResponse(response io.Reader) (*MatchInfoResponse, error) {
 	payload, err := io.ReadAll(response)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
 	var matchInfo MatchInfoResponse
 	err = json.Unmarshal(payload, &matchInfo)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
+	return &matchInfo, nil
+}
+
+// matchSearch tracks the progress of a single search.
+type matchSearch struct {
+	startedAt   time.Time
+	estimatedAt time.Time // EstimatedAt is when the server last estimated the wait, counted down until the next update.
+	progress    dmn.MatchProgress
+	onProgress  func(dmn.MatchProgress)
+}
+
+func newMatchSearch(onProgress func(dmn.MatchProgress)) *matchSearch {
+	if onProgress == nil {
+		onProgress = func(dmn.MatchProgress) {}
+	}
+
+	now := time.Now()
+	return &matchSearch{startedAt: now, estimatedAt: now, onProgress: onProgress}
+}
+
+// update returns the match of info, or reports the queue progress it holds when the player is still queued.
+func (s *matchSearch) update(info *MatchInfoResponse) *dmn.Match {
+	if info.SocketAddr != "" {
+		return &dmn.Match{
+			SocketPubKey:   info.SocketPubKey,
+			SocketAddr:     info.SocketAddr,
+			SocketKeyShare: info.SocketKeyShare,
+		}
+	}
+
+	s.estimatedAt = time.Now()
+	s.progress.QueuePosition = info.QueuePosition
+	s.progress.EstimatedWait = time.Duration(info.EstimatedWait) * time.Millisecond
+	s.report(s.estimatedAt)
+	return nil
+}
 
-	return matchInfo.SocketPubKey, matchInfo.SocketAddr, nil
+func (s *matchSearch) report(now time.Time) {
+	s.onProgress(dmn.MatchProgress{
+		Elapsed:       now.Sub(s.startedAt),
+		QueuePosition: s.progress.QueuePosition,
+		EstimatedWait: max(s.progress.EstimatedWait-now.Sub(s.estimatedAt), 0),
+	})
 }
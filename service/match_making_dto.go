@@ -11,7 +11,14 @@ type MatchRequest struct {
 }
 
 // MatchInfoResponse represents the response containing information about a specific match.
+// A response without a socket address means the player is still queued.
 type MatchInfoResponse struct {
 	SocketPubKey []byte `json:"socket_pubkey"`
 	SocketAddr   string `json:"socket_addr"`
+
+	// SocketKeyShare is the server's X25519 public key. When present the session key is derived by a key exchange.
+	SocketKeyShare []byte `json:"socket_key_share"`
+
+	QueuePosition int   `json:"queue_position,omitempty"`    // QueuePosition of a queued player, 1 being the next one matched.
+	EstimatedWait int64 `json:"estimated_wait_ms,omitempty"` // EstimatedWait of a queued player in milliseconds.
 }
@@ -0,0 +1,337 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sofc-t/puzzle-client/dmn"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// fakeRequester is an i.HttpRequester answering the match info polls in order, repeating the last answer.
+// It streams match events only when stream is set.
+type fakeRequester struct {
+	answers      []*MatchInfoResponse // A nil answer fails the poll with pollErr, a 404 by default.
+	pollErr      error
+	stream       []*MatchInfoResponse // Stream is pushed as match events before the stream ends.
+	subscribeErr error                // SubscribeErr fails the subscriptions without stream, i.ErrNotEventStream by default.
+	posted       []byte
+	deleted      []string
+	polls        int
+	subscribes   int
+	mu           sync.Mutex
+}
+
+func (f *fakeRequester) Post(_ context.Context, _ string, body io.Reader, _ string) (io.Reader, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	posted, err := io.ReadAll(body)
+	f.posted = posted
+	return bytes.NewReader(nil), err
+}
+
+func (f *fakeRequester) Get(context.Context, string, string) (io.Reader, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.polls++
+	answer := f.answers[0]
+	if len(f.answers) > 1 {
+		f.answers = f.answers[1:]
+	}
+	if answer == nil && f.pollErr != nil {
+		return nil, f.pollErr
+	}
+	if answer == nil {
+		return nil, &i.APIError{StatusCode: http.StatusNotFound}
+	}
+
+	payload, err := json.Marshal(answer)
+	return bytes.NewReader(payload), err
+}
+
+func (f *fakeRequester) Delete(_ context.Context, uri, _ string) (io.Reader, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = append(f.deleted, uri)
+	return bytes.NewReader(nil), nil
+}
+
+func (f *fakeRequester) Subscribe(ctx context.Context, uri, _ string) (<-chan i.ServerEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.subscribes++
+	if f.stream == nil && f.subscribeErr != nil {
+		return nil, f.subscribeErr
+	}
+	if f.stream == nil {
+		return nil, i.ErrNotEventStream
+	}
+
+	events := make(chan i.ServerEvent)
+	go func(stream []*MatchInfoResponse) {
+		defer close(events)
+		for _, info := range stream {
+			payload, _ := json.Marshal(info)
+			select {
+			case events <- i.ServerEvent{Name: "keep-alive"}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case events <- i.ServerEvent{Name: matchEventName, Data: string(payload)}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}(f.stream)
+	return events, nil
+}
+
+func (f *fakeRequester) counts() (polls, subscribes int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.polls, f.subscribes
+}
+
+func (f *fakeRequester) deletedURIs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.deleted...)
+}
+
+func newTestMatchMaking(requester *fakeRequester, timeout time.Duration) *MatchMaking {
+	mm, _ := NewMatchMaking(MatchMakingConfig{
+		HttpClient:      requester,
+		MatchUri:        "/match",
+		Timeout:         timeout,
+		PollInterval:    time.Millisecond,
+		MaxPollInterval: 4 * time.Millisecond,
+	})
+	return mm
+}
+
+// TestMatchMaking contains all the tests related to searching for a match.
+func TestMatchMaking(t *testing.T) {
+	t.Run("MatchMaking_Found", testMatchMaking_Found)
+	t.Run("MatchMaking_Cancelled", testMatchMaking_Cancelled)
+	t.Run("MatchMaking_Timeout", testMatchMaking_Timeout)
+	t.Run("MatchMaking_Unauthorized", testMatchMaking_Unauthorized)
+	t.Run("MatchMaking_Backoff", testMatchMaking_Backoff)
+	t.Run("MatchMaking_Push", testMatchMaking_Push)
+	t.Run("MatchMaking_PushEndsEarly", testMatchMaking_PushEndsEarly)
+	t.Run("MatchMaking_PushUnsupported", testMatchMaking_PushUnsupported)
+	t.Run("MatchMaking_PushProbeErrors", testMatchMaking_PushProbeErrors)
+	t.Run("MatchMaking_ServerClock", testMatchMaking_ServerClock)
+}
+
+// testMatchMaking_Found tests that the queue progress is reported and failed polls are retried until a match is found.
+func testMatchMaking_Found(t *testing.T) {
+	requester := &fakeRequester{answers: []*MatchInfoResponse{
+		{QueuePosition: 2, EstimatedWait: 3000},
+		nil,
+		{QueuePosition: 1, EstimatedWait: 1000},
+		{SocketAddr: "127.0.0.1:9090"},
+	}}
+
+	var positions []int
+	match, err := newTestMatchMaking(requester, time.Second).Match(context.Background(), testPlayerID, "token",
+		func(p dmn.MatchProgress) { positions = append(positions, p.QueuePosition) })
+	if err != nil {
+		t.Fatalf("Expected a match, got error: %s", err)
+	}
+
+	if match.SocketAddr != "127.0.0.1:9090" {
+		t.Errorf("Expected the socket of the match, got: %q", match.SocketAddr)
+	}
+	if len(positions) != 2 || positions[0] != 2 || positions[1] != 1 {
+		t.Errorf("Expected the positions 2 then 1 to be reported, got: %v", positions)
+	}
+	if deleted := requester.deletedURIs(); len(deleted) != 0 {
+		t.Errorf("Expected the match request to be kept, got deleted: %v", deleted)
+	}
+}
+
+// testMatchMaking_Cancelled tests that cancelling the search removes the player from the queue.
+func testMatchMaking_Cancelled(t *testing.T) {
+	requester := &fakeRequester{answers: []*MatchInfoResponse{{QueuePosition: 3}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err := newTestMatchMaking(requester, time.Minute).Match(ctx, testPlayerID, "token",
+		func(dmn.MatchProgress) { cancel() })
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got: %v", err)
+	}
+
+	deleted := requester.deletedURIs()
+	if len(deleted) != 1 || deleted[0] != "/match/"+testPlayerID.String() {
+		t.Errorf("Expected the match request to be deleted once, got: %v", deleted)
+	}
+}
+
+// testMatchMaking_Timeout tests that the search gives up after the timeout and leaves the queue.
+func testMatchMaking_Timeout(t *testing.T) {
+	requester := &fakeRequester{answers: []*MatchInfoResponse{nil}}
+
+	_, err := newTestMatchMaking(requester, 20*time.Millisecond).Match(context.Background(), uuid.New(), "token", nil)
+	if !errors.Is(err, ErrMatchTimeout) {
+		t.Fatalf("Expected ErrMatchTimeout, got: %v", err)
+	}
+
+	if deleted := requester.deletedURIs(); len(deleted) != 1 {
+		t.Errorf("Expected the match request to be deleted once, got: %v", deleted)
+	}
+}
+
+// testMatchMaking_Unauthorized tests that a rejected token stops the search instead of polling until the timeout.
+func testMatchMaking_Unauthorized(t *testing.T) {
+	requester := &fakeRequester{
+		answers: []*MatchInfoResponse{nil},
+		pollErr: &i.APIError{StatusCode: http.StatusUnauthorized, Code: "expired_token"},
+	}
+
+	_, err := newTestMatchMaking(requester, time.Minute).Match(context.Background(), testPlayerID, "token", nil)
+	if !errors.Is(err, i.ErrUnauthorized) {
+		t.Fatalf("Expected ErrUnauthorized, got: %v", err)
+	}
+	if polls, _ := requester.counts(); polls != 1 {
+		t.Errorf("Expected a single poll, got: %d", polls)
+	}
+}
+
+// testMatchMaking_Backoff tests that the poll delay doubles up to the maximum, jittered by at most half of it.
+func testMatchMaking_Backoff(t *testing.T) {
+	mm, _ := NewMatchMaking(MatchMakingConfig{PollInterval: 100 * time.Millisecond, MaxPollInterval: time.Second})
+
+	for attempt, full := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		full *= time.Millisecond
+		for n := 0; n < 20; n++ {
+			if delay := mm.pollDelay(attempt); delay < full/2 || delay > full {
+				t.Fatalf("Expected the delay of attempt %d between %s and %s, got: %s", attempt, full/2, full, delay)
+			}
+		}
+	}
+}
+
+// testMatchMaking_Push tests that a pushed match is used without polling, ignoring the other events.
+func testMatchMaking_Push(t *testing.T) {
+	requester := &fakeRequester{
+		answers: []*MatchInfoResponse{nil},
+		stream:  []*MatchInfoResponse{{QueuePosition: 1}, {SocketAddr: "127.0.0.1:9090"}},
+	}
+
+	var positions []int
+	match, err := newTestMatchMaking(requester, time.Second).Match(context.Background(), testPlayerID, "token",
+		func(p dmn.MatchProgress) { positions = append(positions, p.QueuePosition) })
+	if err != nil {
+		t.Fatalf("Expected a match, got error: %s", err)
+	}
+
+	if match.SocketAddr != "127.0.0.1:9090" {
+		t.Errorf("Expected the pushed socket, got: %q", match.SocketAddr)
+	}
+	if len(positions) != 1 || positions[0] != 1 {
+		t.Errorf("Expected the pushed position to be reported, got: %v", positions)
+	}
+	if polls, _ := requester.counts(); polls != 0 {
+		t.Errorf("Expected no poll, got: %d", polls)
+	}
+}
+
+// testMatchMaking_PushEndsEarly tests that polling takes over a stream ending before the match.
+func testMatchMaking_PushEndsEarly(t *testing.T) {
+	requester := &fakeRequester{
+		answers: []*MatchInfoResponse{{SocketAddr: "127.0.0.1:9090"}},
+		stream:  []*MatchInfoResponse{{QueuePosition: 2}},
+	}
+	mm := newTestMatchMaking(requester, time.Second)
+
+	if _, err := mm.Match(context.Background(), testPlayerID, "token", nil); err != nil {
+		t.Fatalf("Expected a polled match, got error: %s", err)
+	}
+	if _, err := mm.Match(context.Background(), testPlayerID, "token", nil); err != nil {
+		t.Fatalf("Expected a polled match, got error: %s", err)
+	}
+
+	if polls, subscribes := requester.counts(); polls != 2 || subscribes != 2 {
+		t.Errorf("Expected the stream to be tried again, got %d polls and %d subscriptions", polls, subscribes)
+	}
+}
+
+// testMatchMaking_PushUnsupported tests that a server without match events is only probed once.
+func testMatchMaking_PushUnsupported(t *testing.T) {
+	requester := &fakeRequester{answers: []*MatchInfoResponse{{SocketAddr: "127.0.0.1:9090"}}}
+	mm := newTestMatchMaking(requester, time.Second)
+
+	for n := 0; n < 3; n++ {
+		if _, err := mm.Match(context.Background(), testPlayerID, "token", nil); err != nil {
+			t.Fatalf("Expected a polled match, got error: %s", err)
+		}
+	}
+
+	if polls, subscribes := requester.counts(); polls != 3 || subscribes != 1 {
+		t.Errorf("Expected 3 polls and a single probe, got %d polls and %d subscriptions", polls, subscribes)
+	}
+}
+
+// testMatchMaking_PushProbeErrors tests that only the errors telling the server does not stream match events stop
+// the probing, the others falling back to polling for a single search.
+func testMatchMaking_PushProbeErrors(t *testing.T) {
+	tests := []struct {
+		err        error
+		subscribes int
+	}{
+		{&i.APIError{StatusCode: http.StatusNotFound}, 1},
+		{&i.APIError{StatusCode: http.StatusNotImplemented}, 1},
+		{&i.APIError{StatusCode: http.StatusUnauthorized}, 2},
+		{&i.APIError{StatusCode: http.StatusServiceUnavailable}, 2},
+		{fmt.Errorf("%w: connection refused", i.ErrServerUnavailable), 2},
+	}
+	for _, tt := range tests {
+		requester := &fakeRequester{answers: []*MatchInfoResponse{{SocketAddr: "127.0.0.1:9090"}}, subscribeErr: tt.err}
+		mm := newTestMatchMaking(requester, time.Second)
+
+		for n := 0; n < 2; n++ {
+			if _, err := mm.Match(context.Background(), testPlayerID, "token", nil); err != nil {
+				t.Fatalf("Expected a polled match, got error: %s", err)
+			}
+		}
+
+		if _, subscribes := requester.counts(); subscribes != tt.subscribes {
+			t.Errorf("Expected %d subscriptions after %q, got: %d", tt.subscribes, tt.err, subscribes)
+		}
+	}
+}
+
+// testMatchMaking_ServerClock tests that the match request tells the server time it is sent at.
+func testMatchMaking_ServerClock(t *testing.T) {
+	skewed := newSkewedClock(time.Hour, 0)
+	clock := NewClockSync(ClockSyncWithNow(skewed.now))
+	skewed.exchange(clock, 20*time.Millisecond, 20*time.Millisecond)
+
+	requester := &fakeRequester{answers: []*MatchInfoResponse{{SocketAddr: "127.0.0.1:9090"}}}
+	mm, _ := NewMatchMaking(MatchMakingConfig{HttpClient: requester, MatchUri: "/match", Clock: clock})
+	if _, err := mm.Match(context.Background(), testPlayerID, "token", nil); err != nil {
+		t.Fatalf("Expected a match, got error: %s", err)
+	}
+
+	var request MatchRequest
+	if err := json.Unmarshal(requester.posted, &request); err != nil {
+		t.Fatalf("Expected the match request, got error: %s", err)
+	}
+	if expected := skewed.server(skewed.local).UnixMilli(); request.SentAt != expected {
+		t.Errorf("Expected the request sent at the server time %d, got: %d", expected, request.SentAt)
+	}
+}
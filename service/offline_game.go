@@ -0,0 +1,181 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+var ErrOfflineGameMissingMaze = errors.New("offline game requires a maze and an encoder")
+
+var _ i.GameServer = &OfflineGameServer{}
+var _ i.TimedGame = &OfflineGameServer{}
+
+// OfflineGameServer plays a single-player match locally, without any connection.
+// The match ends when every reward is collected or the timer runs out.
+type OfflineGameServer struct {
+	state         i.GameState
+	playerID      uuid.UUID
+	duration      time.Duration
+	deadline      time.Time // Deadline is the server time the match ends at.
+	clock         i.ServerClock
+	timer         *time.Timer
+	ended         bool
+	onStateChange func(i.GameState)
+	onGameEnd     func(i.GameState)
+	sync.Mutex
+}
+
+type OfflineGameConfig struct {
+	Maze     i.Maze // Maze is played as is, rewards included.
+	Encoder  i.GameEncoder
+	PlayerID uuid.UUID
+	Duration time.Duration // Duration is the time to collect the rewards.
+	FogOfWar bool          // FogOfWar hides the cells the player did not explore, as a practice for such matches.
+	Clock    i.ServerClock // Clock is the timebase of the states and the deadline, the local time by default.
+}
+
+// NewOfflineGameServer creates a new OfflineGameServer with the player in the top left cell of the maze.
+func NewOfflineGameServer(cfg OfflineGameConfig) (*OfflineGameServer, error) {
+	if cfg.Maze == nil || cfg.Encoder == nil {
+		return nil, ErrOfflineGameMissingMaze
+	}
+
+	pos := cfg.Encoder.NewCellPosition()
+	_ = cfg.Maze.RemoveReward(pos)
+
+	player := cfg.Encoder.NewPlayer()
+	player.SetID(cfg.PlayerID)
+	player.SetPos(pos)
+
+	state := cfg.Encoder.NewGameState()
+	state.SetMaze(cfg.Maze)
+	state.SetPlayers([]i.Player{player})
+	state.SetFogOfWar(cfg.FogOfWar)
+
+	clock := cfg.Clock
+	if clock == nil {
+		clock = NewClockSync()
+	}
+
+	return &OfflineGameServer{
+		state:    state,
+		playerID: cfg.PlayerID,
+		duration: cfg.Duration,
+		clock:    clock,
+	}, nil
+}
+
+// Start implements i.GameServer. It starts the timer and publishes the first state.
+func (o *OfflineGameServer) Start([]byte) error {
+	o.Lock()
+	now := o.clock.ServerNow()
+	o.deadline = now.Add(o.duration)
+	o.timer = time.AfterFunc(o.duration, o.end)
+	o.state.SetVersion(1)
+	o.state.SetStartedAt(now.UnixNano() / int64(time.Millisecond))
+	o.Unlock()
+
+	o.publish()
+	return nil
+}
+
+// Stop implements i.GameServer.
+func (o *OfflineGameServer) Stop() error {
+	o.Lock()
+	defer o.Unlock()
+
+	if o.timer != nil {
+		o.timer.Stop()
+	}
+	o.ended = true
+	return nil
+}
+
+// Move implements i.GameServer. Moves blocked by a wall are ignored.
+func (o *OfflineGameServer) Move(direction string) {
+	o.Lock()
+	if o.ended || o.timer == nil {
+		o.Unlock()
+		return
+	}
+
+	player := o.state.RetrivePlayers()[0]
+	maze := o.state.RetriveMaze()
+	move, err := maze.NewValidMove(player.RetrivePos(), direction)
+	if err != nil {
+		o.Unlock()
+		return
+	}
+
+	reward, err := maze.Move(move)
+	if err != nil {
+		o.Unlock()
+		return
+	}
+
+	player.SetPos(move.To())
+	player.SetReward(player.GetReward() + reward)
+	o.state.SetVersion(o.state.GetVersion() + 1)
+	cleared := maze.GetTotalReward() == 0
+	o.Unlock()
+
+	o.publish()
+	if cleared {
+		o.end()
+	}
+}
+
+// TimeLeft implements i.TimedGame.
+func (o *OfflineGameServer) TimeLeft() time.Duration {
+	o.Lock()
+	defer o.Unlock()
+
+	if o.timer == nil {
+		return o.duration
+	}
+	return max(o.deadline.Sub(o.clock.ServerNow()), 0)
+}
+
+// end finishes the match once, when the rewards are cleared or the timer runs out.
+func (o *OfflineGameServer) end() {
+	o.Lock()
+	if o.ended {
+		o.Unlock()
+		return
+	}
+	o.ended = true
+	o.timer.Stop()
+	o.state.SetEndedAt(o.clock.ServerNow().UnixNano() / int64(time.Millisecond))
+	o.Unlock()
+
+	if o.onGameEnd != nil {
+		o.onGameEnd(o.state)
+	}
+}
+
+// publish hands the current state to onStateChange.
+func (o *OfflineGameServer) publish() {
+	if o.onStateChange != nil {
+		o.onStateChange(o.state)
+	}
+}
+
+// SetOnStateChange implements i.GameServer.
+func (o *OfflineGameServer) SetOnStateChange(f func(i.GameState)) {
+	o.onStateChange = f
+}
+
+// SetOnGameEnd implements i.GameServer.
+func (o *OfflineGameServer) SetOnGameEnd(f func(i.GameState)) {
+	o.onGameEnd = f
+}
+
+// SetOnPingResult implements i.GameServer. There is no connection in an offline game.
+func (o *OfflineGameServer) SetOnPingResult(func(i.NetworkStats)) {}
+
+// SetOnConnectionStateChange implements i.GameServer. There is no connection in an offline game.
+func (o *OfflineGameServer) SetOnConnectionStateChange(func(i.ConnectionState)) {}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	gamepb "github.com/sofc-t/puzzle-client/infrastruture/pb_encoder/game"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// newTestOfflineGame returns an offline game on a 1x3 corridor with a reward in every cell.
+func newTestOfflineGame(t *testing.T, duration time.Duration) (*OfflineGameServer, chan i.GameState) {
+	row := &gamepb.Maze_Row{}
+	for c := 0; c < 3; c++ {
+		row.Cells = append(row.Cells, &gamepb.Cell{NorthWall: true, SouthWall: true, WestWall: c == 0, EastWall: c == 2, Reward: 1})
+	}
+
+	server, err := NewOfflineGameServer(OfflineGameConfig{
+		Maze:     &gamepb.Maze{Grid: []*gamepb.Maze_Row{row}},
+		Encoder:  &gamepb.Protobuf{},
+		PlayerID: testPlayerID,
+		Duration: duration,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ended := make(chan i.GameState, 1)
+	server.SetOnStateChange(func(i.GameState) {})
+	server.SetOnGameEnd(func(gs i.GameState) { ended <- gs })
+	return server, ended
+}
+
+// TestOfflineGameServer contains all the tests related to the offline single-player mode.
+func TestOfflineGameServer(t *testing.T) {
+	t.Run("OfflineGameServer_EndsWhenCleared", testOfflineGameServer_EndsWhenCleared)
+	t.Run("OfflineGameServer_EndsOnTimeout", testOfflineGameServer_EndsOnTimeout)
+	t.Run("OfflineGameServer_ServerClock", testOfflineGameServer_ServerClock)
+}
+
+// testOfflineGameServer_EndsWhenCleared tests that collecting the last reward ends the match.
+func testOfflineGameServer_EndsWhenCleared(t *testing.T) {
+	server, ended := newTestOfflineGame(t, time.Minute)
+	_ = server.Start(nil)
+
+	server.Move(i.West) // Blocked by the border.
+	server.Move(i.East)
+	server.Move(i.East)
+
+	select {
+	case gs := <-ended:
+		if reward := gs.RetrivePlayers()[0].GetReward(); reward != 2 {
+			t.Errorf("Expected a reward of 2, got: %d", reward)
+		}
+		if gs.GetEndedAt() == 0 {
+			t.Errorf("Expected the end time to be set")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the match to end once the rewards are cleared")
+	}
+
+	server.Move(i.West)
+	if col := server.state.RetrivePlayers()[0].RetrivePos().GetCol(); col != 2 {
+		t.Errorf("Expected moves to be ignored after the end, got col: %d", col)
+	}
+}
+
+// testOfflineGameServer_EndsOnTimeout tests that the timer ends the match with rewards left.
+func testOfflineGameServer_EndsOnTimeout(t *testing.T) {
+	server, ended := newTestOfflineGame(t, 20*time.Millisecond)
+	if server.TimeLeft() != 20*time.Millisecond {
+		t.Errorf("Expected the full duration before the start, got: %s", server.TimeLeft())
+	}
+	_ = server.Start(nil)
+
+	select {
+	case gs := <-ended:
+		if gs.RetriveMaze().GetTotalReward() != 2 {
+			t.Errorf("Expected the rewards to be left, got: %d", gs.RetriveMaze().GetTotalReward())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the match to end on timeout")
+	}
+
+	if server.TimeLeft() != 0 {
+		t.Errorf("Expected no time left, got: %s", server.TimeLeft())
+	}
+}
+
+// testOfflineGameServer_ServerClock tests that the start time and the time left follow the server clock.
+func testOfflineGameServer_ServerClock(t *testing.T) {
+	skewed := newSkewedClock(time.Hour, 0)
+	clock := NewClockSync(ClockSyncWithNow(skewed.now))
+	skewed.exchange(clock, 20*time.Millisecond, 20*time.Millisecond)
+
+	server, _ := newTestOfflineGame(t, time.Minute)
+	server.clock = clock
+	_ = server.Start(nil)
+	defer server.Stop()
+
+	if startedAt := server.state.GetStartedAt(); startedAt != skewed.server(skewed.local).UnixMilli() {
+		t.Errorf("Expected the match started at the server time, got: %d", startedAt)
+	}
+
+	skewed.local = skewed.local.Add(10 * time.Second)
+	if left := server.TimeLeft(); left != 50*time.Second {
+		t.Errorf("Expected 50s left on the server clock, got: %s", left)
+	}
+}
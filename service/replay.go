@@ -0,0 +1,201 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+const (
+	replayTickInterval = 50 * time.Millisecond
+	minReplaySpeed     = 0.25
+	maxReplaySpeed     = 16
+)
+
+var ErrEmptyReplay = errors.New("replay has no game state")
+
+var _ i.GameServer = &ReplayServer{}
+var _ i.ReplayController = &ReplayServer{}
+
+// ReplayServer plays a recorded match back through the i.GameServer interface,
+// so it is rendered by the same page as a live match.
+type ReplayServer struct {
+	states        []i.ReplayFrame // States are the state frames ordered by recording time.
+	start         int64           // Start is the recording time of the first state, in milliseconds.
+	position      int64           // Position is the playback time since start, in milliseconds.
+	current       int             // Current is the index of the state shown, -1 before the first render.
+	speed         float64
+	paused        bool
+	onStateChange func(i.GameState)
+	stopSignal    chan struct{}
+	sync.Mutex
+}
+
+// NewReplayServer creates a new ReplayServer from recorded frames. Action frames are not rendered.
+func NewReplayServer(frames []i.ReplayFrame) (*ReplayServer, error) {
+	states := make([]i.ReplayFrame, 0, len(frames))
+	for _, frame := range frames {
+		if frame.RetriveState() != nil {
+			states = append(states, frame)
+		}
+	}
+
+	if len(states) == 0 {
+		return nil, ErrEmptyReplay
+	}
+
+	sort.SliceStable(states, func(i, j int) bool {
+		return states[i].GetRecordedAt() < states[j].GetRecordedAt()
+	})
+
+	return &ReplayServer{
+		states:     states,
+		start:      states[0].GetRecordedAt(),
+		current:    -1,
+		speed:      1,
+		stopSignal: make(chan struct{}),
+	}, nil
+}
+
+// Start implements i.GameServer. It starts the playback from the first state.
+func (r *ReplayServer) Start([]byte) error {
+	r.Lock()
+	gs := r.render()
+	r.Unlock()
+	r.publish(gs)
+
+	ticker := time.NewTicker(replayTickInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stopSignal:
+				return
+			case <-ticker.C:
+				r.Lock()
+				var gs i.GameState
+				if !r.paused {
+					r.position += int64(float64(replayTickInterval/time.Millisecond) * r.speed)
+					if r.position >= r.duration() {
+						r.position = r.duration()
+						r.paused = true
+					}
+					gs = r.render()
+				}
+				r.Unlock()
+				r.publish(gs)
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop implements i.GameServer.
+func (r *ReplayServer) Stop() error {
+	close(r.stopSignal)
+	return nil
+}
+
+// Move implements i.GameServer. Moves are ignored during a replay.
+func (r *ReplayServer) Move(string) {}
+
+// TogglePause implements i.ReplayController. Resuming at the end restarts the playback.
+func (r *ReplayServer) TogglePause() {
+	r.Lock()
+	if r.paused && r.position >= r.duration() {
+		r.position = 0
+	}
+	r.paused = !r.paused
+	gs := r.render()
+	r.Unlock()
+
+	r.publish(gs)
+}
+
+// Step implements i.ReplayController. It pauses the playback and moves by the given number of states.
+func (r *ReplayServer) Step(frames int) {
+	r.Lock()
+	r.paused = true
+	idx := max(r.current, 0) + frames
+	idx = min(max(idx, 0), len(r.states)-1)
+	r.position = r.states[idx].GetRecordedAt() - r.start
+	gs := r.render()
+	r.Unlock()
+
+	r.publish(gs)
+}
+
+// Skip implements i.ReplayController. It moves the playback position by offset milliseconds.
+func (r *ReplayServer) Skip(offset int64) {
+	r.Lock()
+	r.position = min(max(r.position+offset, 0), r.duration())
+	gs := r.render()
+	r.Unlock()
+
+	r.publish(gs)
+}
+
+// SetSpeed implements i.ReplayController.
+func (r *ReplayServer) SetSpeed(speed float64) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.speed = min(max(speed, minReplaySpeed), maxReplaySpeed)
+}
+
+// Status implements i.ReplayController.
+func (r *ReplayServer) Status() i.ReplayStatus {
+	r.Lock()
+	defer r.Unlock()
+
+	return i.ReplayStatus{
+		Position: r.position,
+		Duration: r.duration(),
+		Speed:    r.speed,
+		Paused:   r.paused,
+	}
+}
+
+// render moves to the last state recorded at or before the playback position.
+// It returns the state to publish, or nil when it did not change. The caller must hold the lock.
+func (r *ReplayServer) render() i.GameState {
+	idx := sort.Search(len(r.states), func(i int) bool {
+		return r.states[i].GetRecordedAt()-r.start > r.position
+	}) - 1
+	idx = max(idx, 0)
+
+	if idx == r.current {
+		return nil
+	}
+	r.current = idx
+	return r.states[idx].RetriveState()
+}
+
+// publish hands a rendered state to onStateChange outside of the lock.
+func (r *ReplayServer) publish(gs i.GameState) {
+	if gs != nil && r.onStateChange != nil {
+		r.onStateChange(gs)
+	}
+}
+
+// duration returns the playback time of the last state, in milliseconds.
+func (r *ReplayServer) duration() int64 {
+	return r.states[len(r.states)-1].GetRecordedAt() - r.start
+}
+
+// SetOnStateChange implements i.GameServer.
+func (r *ReplayServer) SetOnStateChange(f func(i.GameState)) {
+	r.onStateChange = f
+}
+
+// SetOnPingResult implements i.GameServer. There is no connection during a replay.
+func (r *ReplayServer) SetOnPingResult(func(i.NetworkStats)) {}
+
+// SetOnConnectionStateChange implements i.GameServer. There is no connection during a replay.
+func (r *ReplayServer) SetOnConnectionStateChange(func(i.ConnectionState)) {}
+
+// SetOnGameEnd implements i.GameServer. The playback pauses on the last state instead of ending the game.
+func (r *ReplayServer) SetOnGameEnd(func(i.GameState)) {}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"testing"
+
+	gamepb "github.com/sofc-t/puzzle-client/infrastruture/pb_encoder/game"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// newTestReplay returns a replay of three states recorded one second apart, with an action in between.
+func newTestReplay(t *testing.T) (*ReplayServer, *[]i.GameState) {
+	frames := []i.ReplayFrame{
+		&gamepb.ReplayFrame{RecordedAt: 3000, Entry: &gamepb.ReplayFrame_State{State: &gamepb.GameState{Version: 3}}},
+		&gamepb.ReplayFrame{RecordedAt: 1000, Entry: &gamepb.ReplayFrame_State{State: &gamepb.GameState{Version: 1}}},
+		&gamepb.ReplayFrame{RecordedAt: 1500, Entry: &gamepb.ReplayFrame_Action{Action: &gamepb.Action{Seq: 1}}},
+		&gamepb.ReplayFrame{RecordedAt: 2000, Entry: &gamepb.ReplayFrame_State{State: &gamepb.GameState{Version: 2}}},
+	}
+
+	server, err := NewReplayServer(frames)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	states := &[]i.GameState{}
+	server.SetOnStateChange(func(gs i.GameState) { *states = append(*states, gs) })
+	server.render()
+	return server, states
+}
+
+// TestReplayServer contains all the tests related to the playback of recorded matches.
+func TestReplayServer(t *testing.T) {
+	t.Run("ReplayServer_Empty", testReplayServer_Empty)
+	t.Run("ReplayServer_Step", testReplayServer_Step)
+	t.Run("ReplayServer_Skip", testReplayServer_Skip)
+	t.Run("ReplayServer_SpeedIsClamped", testReplayServer_SpeedIsClamped)
+}
+
+// testReplayServer_Empty tests that a replay without states is rejected.
+func testReplayServer_Empty(t *testing.T) {
+	_, err := NewReplayServer([]i.ReplayFrame{&gamepb.ReplayFrame{Entry: &gamepb.ReplayFrame_Action{Action: &gamepb.Action{}}}})
+	if err != ErrEmptyReplay {
+		t.Errorf("Expected ErrEmptyReplay, got: %v", err)
+	}
+}
+
+// testReplayServer_Step tests stepping through the states in recording order.
+func testReplayServer_Step(t *testing.T) {
+	server, states := newTestReplay(t)
+
+	server.Step(1)
+	server.Step(1)
+	server.Step(1)
+	server.Step(-1)
+
+	versions := make([]int64, 0)
+	for _, gs := range *states {
+		versions = append(versions, gs.GetVersion())
+	}
+
+	if len(versions) != 3 || versions[0] != 2 || versions[1] != 3 || versions[2] != 2 {
+		t.Errorf("Expected versions [2 3 2], got: %v", versions)
+	}
+
+	if status := server.Status(); !status.Paused || status.Position != 1000 || status.Duration != 2000 {
+		t.Errorf("Expected paused at 1000ms of 2000ms, got: %+v", status)
+	}
+}
+
+// testReplayServer_Skip tests that skipping renders the last state before the position and stays in bounds.
+func testReplayServer_Skip(t *testing.T) {
+	server, states := newTestReplay(t)
+
+	server.Skip(1500)
+	if len(*states) != 1 || (*states)[0].GetVersion() != 2 {
+		t.Fatalf("Expected state 2 at 1500ms, got %d states", len(*states))
+	}
+
+	server.Skip(-5000)
+	if server.Status().Position != 0 || (*states)[1].GetVersion() != 1 {
+		t.Errorf("Expected state 1 at 0ms, got position: %d", server.Status().Position)
+	}
+
+	server.Skip(5000)
+	if server.Status().Position != 2000 || (*states)[2].GetVersion() != 3 {
+		t.Errorf("Expected state 3 at 2000ms, got position: %d", server.Status().Position)
+	}
+}
+
+// testReplayServer_SpeedIsClamped tests the bounds of the playback speed.
+func testReplayServer_SpeedIsClamped(t *testing.T) {
+	server, _ := newTestReplay(t)
+
+	server.SetSpeed(100)
+	if server.Status().Speed != maxReplaySpeed {
+		t.Errorf("Expected speed %v, got: %v", maxReplaySpeed, server.Status().Speed)
+	}
+
+	server.SetSpeed(0)
+	if server.Status().Speed != minReplaySpeed {
+		t.Errorf("Expected speed %v, got: %v", minReplaySpeed, server.Status().Speed)
+	}
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"math/rand"
+
+	"github.com/google/uuid"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+var _ i.Strategy = &RandomWalk{}
+var _ i.Strategy = &GreedyReward{}
+var _ i.Strategy = &ShortestPath{}
+
+// moveDirections lists the directions tried by the strategies, in a fixed order for deterministic results.
+var moveDirections = []string{i.North, i.East, i.South, i.West}
+
+// RandomWalk moves to a random open neighbour.
+type RandomWalk struct {
+	rnd *rand.Rand
+}
+
+// NewRandomWalk creates a new RandomWalk strategy seeded with seed.
+func NewRandomWalk(seed int64) *RandomWalk {
+	return &RandomWalk{rnd: rand.New(rand.NewSource(seed))}
+}
+
+// NextMove implements i.Strategy.
+func (s *RandomWalk) NextMove(gs i.GameState, playerID uuid.UUID) (string, bool) {
+	pos := playerPos(gs, playerID)
+	if pos == nil {
+		return "", false
+	}
+
+	open := openDirections(gs.RetriveMaze(), pos)
+	if len(open) == 0 {
+		return "", false
+	}
+	return open[s.rnd.Intn(len(open))], true
+}
+
+// GreedyReward moves to the open neighbour closest to the nearest reward, ignoring the walls in between.
+// It falls back to a random walk when no open neighbour gets closer.
+type GreedyReward struct {
+	fallback *RandomWalk
+}
+
+// NewGreedyReward creates a new GreedyReward strategy seeded with seed.
+func NewGreedyReward(seed int64) *GreedyReward {
+	return &GreedyReward{fallback: NewRandomWalk(seed)}
+}
+
+// NextMove implements i.Strategy.
+func (s *GreedyReward) NextMove(gs i.GameState, playerID uuid.UUID) (string, bool) {
+	pos := playerPos(gs, playerID)
+	if pos == nil {
+		return "", false
+	}
+
+	maze := gs.RetriveMaze()
+	row, col, ok := nearestReward(maze, pos)
+	if !ok {
+		return "", false
+	}
+
+	best, bestDistance := "", manhattan(pos.GetRow(), pos.GetCol(), row, col)
+	for _, direction := range openDirections(maze, pos) {
+		move, _ := maze.NewValidMove(pos, direction)
+		if d := manhattan(move.To().GetRow(), move.To().GetCol(), row, col); d < bestDistance {
+			best, bestDistance = direction, d
+		}
+	}
+
+	if best == "" {
+		return s.fallback.NextMove(gs, playerID)
+	}
+	return best, true
+}
+
+// ShortestPath follows the shortest path through the maze to the closest reward, found by breadth-first search.
+type ShortestPath struct{}
+
+// NewShortestPath creates a new ShortestPath strategy.
+func NewShortestPath() *ShortestPath {
+	return &ShortestPath{}
+}
+
+// NextMove implements i.Strategy.
+func (s *ShortestPath) NextMove(gs i.GameState, playerID uuid.UUID) (string, bool) {
+	pos := playerPos(gs, playerID)
+	if pos == nil {
+		return "", false
+	}
+
+	maze := gs.RetriveMaze()
+	grid := maze.RetriveGrid()
+
+	// firstStep holds the first direction taken from pos to reach each visited cell.
+	type cell struct{ row, col int32 }
+	firstStep := map[cell]string{{pos.GetRow(), pos.GetCol()}: ""}
+	queue := []i.CellPosition{pos}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		step := firstStep[cell{current.GetRow(), current.GetCol()}]
+
+		if step != "" && grid[current.GetRow()][current.GetCol()].GetReward() > 0 {
+			return step, true
+		}
+
+		for _, direction := range openDirections(maze, current) {
+			move, _ := maze.NewValidMove(current, direction)
+			next := cell{move.To().GetRow(), move.To().GetCol()}
+			if _, visited := firstStep[next]; visited {
+				continue
+			}
+
+			if step == "" {
+				firstStep[next] = direction
+			} else {
+				firstStep[next] = step
+			}
+			queue = append(queue, move.To())
+		}
+	}
+	return "", false
+}
+
+// playerPos returns the position of the player in gs, nil when the player or the maze is missing.
+func playerPos(gs i.GameState, playerID uuid.UUID) i.CellPosition {
+	if gs == nil || gs.RetriveMaze() == nil {
+		return nil
+	}
+
+	for _, player := range gs.RetrivePlayers() {
+		if player.GetID() == playerID {
+			return player.RetrivePos()
+		}
+	}
+	return nil
+}
+
+// openDirections returns the directions not blocked by a wall from pos.
+func openDirections(maze i.Maze, pos i.CellPosition) []string {
+	open := make([]string, 0, len(moveDirections))
+	for _, direction := range moveDirections {
+		if _, err := maze.NewValidMove(pos, direction); err == nil {
+			open = append(open, direction)
+		}
+	}
+	return open
+}
+
+// nearestReward returns the cell with a reward closest to pos by Manhattan distance, other than pos itself.
+func nearestReward(maze i.Maze, pos i.CellPosition) (int32, int32, bool) {
+	bestRow, bestCol, bestDistance := int32(0), int32(0), int32(-1)
+	for r, cells := range maze.RetriveGrid() {
+		for c, cell := range cells {
+			row, col := int32(r), int32(c)
+			if cell.GetReward() <= 0 || (row == pos.GetRow() && col == pos.GetCol()) {
+				continue
+			}
+
+			if d := manhattan(pos.GetRow(), pos.GetCol(), row, col); bestDistance < 0 || d < bestDistance {
+				bestRow, bestCol, bestDistance = row, col, d
+			}
+		}
+	}
+	return bestRow, bestCol, bestDistance >= 0
+}
+
+func manhattan(fromRow, fromCol, toRow, toCol int32) int32 {
+	return abs(fromRow-toRow) + abs(fromCol-toCol)
+}
+
+func abs(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"testing"
+
+	gamepb "github.com/sofc-t/puzzle-client/infrastruture/pb_encoder/game"
+	"github.com/sofc-t/puzzle-client/service/i"
+)
+
+// newStrategyState returns a state of a 2x3 maze with the local player at (0, 0) and a single reward at (0, 1).
+// When walled, a wall separates (0, 0) from (0, 1) so the reward is reached through the bottom row.
+func newStrategyState(walled bool) i.GameState {
+	grid := make([]*gamepb.Maze_Row, 2)
+	for r := range grid {
+		grid[r] = &gamepb.Maze_Row{}
+		for c := 0; c < 3; c++ {
+			grid[r].Cells = append(grid[r].Cells, &gamepb.Cell{NorthWall: r == 0, SouthWall: r == 1, WestWall: c == 0, EastWall: c == 2})
+		}
+	}
+	grid[0].Cells[1].Reward = 1
+	grid[0].Cells[0].EastWall = walled
+	grid[0].Cells[1].WestWall = walled
+
+	return &gamepb.GameState{
+		Maze:    &gamepb.Maze{Grid: grid},
+		Players: []*gamepb.Player{{Id: testPlayerID.String(), Pos: &gamepb.Pos{Row: 0, Col: 0}}},
+	}
+}
+
+// TestStrategy contains all the tests related to the bot strategies.
+func TestStrategy(t *testing.T) {
+	t.Run("Strategy_RandomWalkStaysOpen", testStrategy_RandomWalkStaysOpen)
+	t.Run("Strategy_GreedyMovesCloser", testStrategy_GreedyMovesCloser)
+	t.Run("Strategy_ShortestPathGoesAround", testStrategy_ShortestPathGoesAround)
+	t.Run("Strategy_NoReward", testStrategy_NoReward)
+}
+
+// testStrategy_RandomWalkStaysOpen tests that the random walk never picks a blocked direction.
+func testStrategy_RandomWalkStaysOpen(t *testing.T) {
+	strategy := NewRandomWalk(1)
+	gs := newStrategyState(true)
+
+	for n := 0; n < 20; n++ {
+		if direction, ok := strategy.NextMove(gs, testPlayerID); !ok || direction != i.South {
+			t.Fatalf("Expected South as the only open direction, got: %q", direction)
+		}
+	}
+}
+
+// testStrategy_GreedyMovesCloser tests that the greedy strategy heads to the reward.
+func testStrategy_GreedyMovesCloser(t *testing.T) {
+	direction, ok := NewGreedyReward(1).NextMove(newStrategyState(false), testPlayerID)
+	if !ok || direction != i.East {
+		t.Errorf("Expected East, got: %q", direction)
+	}
+}
+
+// testStrategy_ShortestPathGoesAround tests that the shortest path goes around the walls.
+func testStrategy_ShortestPathGoesAround(t *testing.T) {
+	gs := newStrategyState(true)
+	strategy := NewShortestPath()
+
+	path := make([]string, 0)
+	for n := 0; n < 3; n++ {
+		direction, ok := strategy.NextMove(gs, testPlayerID)
+		if !ok {
+			t.Fatalf("Expected a move after %v", path)
+		}
+		path = append(path, direction)
+
+		player := gs.RetrivePlayers()[0]
+		move, err := gs.RetriveMaze().NewValidMove(player.RetrivePos(), direction)
+		if err != nil {
+			t.Fatalf("Expected a valid move, got error: %s", err)
+		}
+		player.SetPos(move.To())
+	}
+
+	if path[0] != i.South || path[1] != i.East || path[2] != i.North {
+		t.Errorf("Expected path [South East North], got: %v", path)
+	}
+}
+
+// testStrategy_NoReward tests that reward seeking strategies stop when the maze is empty.
+func testStrategy_NoReward(t *testing.T) {
+	gs := newStrategyState(false)
+	gs.RetriveMaze().RetriveGrid()[0][1].SetReward(0)
+
+	if _, ok := NewGreedyReward(1).NextMove(gs, testPlayerID); ok {
+		t.Errorf("Expected no greedy move without rewards")
+	}
+	if _, ok := NewShortestPath().NextMove(gs, testPlayerID); ok {
+		t.Errorf("Expected no shortest path move without rewards")
+	}
+}